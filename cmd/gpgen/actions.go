@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/terrpan/gpgen/pkg/generator"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+// httpDoer sends HTTP requests; *http.Client satisfies it
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// actionsHTTPClient is the client used to resolve actions, replaceable in tests
+var actionsHTTPClient httpDoer = http.DefaultClient
+
+// githubAPIURL is the base URL of the GitHub REST API
+const githubAPIURL = "https://api.github.com"
+
+// errGitHubUnreachable is returned when GitHub can't answer whether an action exists, e.g.
+// when offline, timed out or rate limited
+var errGitHubUnreachable = errors.New("GitHub is unreachable")
+
+// actionExists reports whether an action reference (owner/repo[/path]@ref) resolves: its
+// repository exists and has the ref as a branch, tag or commit. GITHUB_TOKEN, when set,
+// authenticates the request to raise the API rate limit.
+func actionExists(ctx context.Context, uses string) (bool, error) {
+	action, ref, _ := strings.Cut(uses, "@")
+	parts := strings.SplitN(action, "/", 3)
+	if len(parts) < 2 || ref == "" {
+		return false, fmt.Errorf("invalid action reference %q", uses)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", githubAPIURL, parts[0], parts[1], ref)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := actionsHTTPClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errGitHubUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		// 404 for a missing repository, 422 for a ref the repository doesn't have
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: %s", errGitHubUnreachable, resp.Status)
+	}
+}
+
+// checkActions resolves every remote action used by the manifest's workflows and returns the
+// ones that don't exist. When GitHub can't be reached within timeout the check is skipped
+// with a warning, so validation still works offline.
+func checkActions(m *manifest.Manifest, timeout time.Duration) ([]string, error) {
+	actions, err := generator.NewWorkflowGenerator("").RemoteActions(m, workflowEnvironments(m, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var missing []string
+	for _, action := range actions {
+		exists, err := actionExists(ctx, action)
+		if errors.Is(err, errGitHubUnreachable) {
+			fmt.Printf("⚠️  Warning: skipping action checks, %v\n", err)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, action)
+		}
+	}
+	return missing, nil
+}
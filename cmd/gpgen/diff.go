@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [manifest-file]",
+	Short: "Show how generated workflows differ from the existing files",
+	Long: `Generate workflows from a GPGen manifest in memory and print a unified diff against
+the workflow files they would be written to. Exits non-zero when any file differs or is
+missing, so it can be used for drift checks in CI.
+If no file is specified, it will look for manifest.yaml in the current directory.`,
+	RunE: runDiff,
+}
+
+var (
+	diffOutput  string
+	diffEnv     string
+	diffOptions generatorOptions
+)
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", ".github/workflows", "Directory holding the existing workflows")
+	diffCmd.Flags().StringVarP(&diffEnv, "environment", "e", "", "Diff a specific environment (default: all environments)")
+	diffOptions.addFlags(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	// Determine manifest file path
+	manifestPath := "manifest.yaml"
+	if len(args) > 0 {
+		manifestPath = args[0]
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		return fmt.Errorf("manifest file not found: %s", manifestPath)
+	}
+
+	m, err := manifest.LoadManifestFromFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if err := manifest.ValidateManifest(m); err != nil {
+		return fmt.Errorf("manifest validation failed: %w", err)
+	}
+
+	gen := diffOptions.newGenerator()
+	changed := 0
+	for _, env := range workflowEnvironments(m, diffEnv) {
+		outputPath := filepath.Join(diffOutput, workflowFileName(m, env, layoutFlat))
+
+		workflowContent, err := gen.GenerateWorkflow(m, env)
+		if err != nil {
+			return fmt.Errorf("failed to generate workflow for %s: %w", env, err)
+		}
+
+		diff, err := diffWorkflowFile(outputPath, workflowContent)
+		if err != nil {
+			return err
+		}
+		if diff != "" {
+			fmt.Print(diff)
+			changed++
+		}
+	}
+
+	if changed > 0 {
+		// The diff already explains the failure, usage would only bury it
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d workflow file(s) differ from the manifest", changed)
+	}
+	fmt.Println("No differences")
+	return nil
+}
+
+// diffWorkflowFile returns a unified diff from the workflow file at path to content, or ""
+// when they match. A missing file is diffed as empty.
+func diffWorkflowFile(path, content string) (string, error) {
+	fromFile := path
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fromFile = "/dev/null"
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if string(existing) == content {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(string(existing)),
+		B:        diffLines(content),
+		FromFile: fromFile,
+		ToFile:   path,
+		Context:  3,
+	})
+}
+
+// diffLines splits content into lines that keep their newline, without the empty line
+// difflib.SplitLines adds after a trailing newline
+func diffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
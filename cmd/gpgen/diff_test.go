@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/generator"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+func TestDiffCommand(t *testing.T) {
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "workflows")
+	require.NoError(t, os.MkdirAll(outputDir, 0755))
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: diff-test
+spec:
+  template: go-service
+  environments:
+    staging:
+      environment:
+        name: staging`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	m, err := manifest.LoadManifestFromFile(manifestPath)
+	require.NoError(t, err)
+	generated, err := generator.NewWorkflowGenerator("").GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	defaultPath := filepath.Join(outputDir, "diff-test.yml")
+	stagingPath := filepath.Join(outputDir, "diff-test-staging.yml")
+
+	run := func(t *testing.T, env string, flags ...string) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "diff [manifest-file]",
+			RunE: runDiff,
+		}
+		cmd.Flags().StringVarP(&diffOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&diffEnv, "environment", "e", "", "Environment")
+		diffOptions.addFlags(cmd)
+		require.NoError(t, cmd.Flags().Set("output", outputDir))
+		if env != "" {
+			require.NoError(t, cmd.Flags().Set("environment", env))
+		}
+		for _, flag := range flags {
+			require.NoError(t, cmd.Flags().Set(flag, "true"))
+		}
+		defer func() {
+			diffOutput = ".github/workflows"
+			diffEnv = ""
+			diffOptions = generatorOptions{}
+		}()
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	t.Run("identical workflow", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(defaultPath, []byte(generated), 0644))
+
+		output, err := run(t, "default")
+		require.NoError(t, err)
+		assert.Contains(t, output, "No differences")
+	})
+
+	t.Run("changed workflow", func(t *testing.T) {
+		edited := strings.Replace(generated, "name: diff-test", "name: renamed", 1)
+		require.NoError(t, os.WriteFile(defaultPath, []byte(edited), 0644))
+
+		output, err := run(t, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 workflow file(s) differ from the manifest")
+		assert.Contains(t, output, "--- "+defaultPath)
+		assert.Contains(t, output, "+++ "+defaultPath)
+		assert.Contains(t, output, "-name: renamed\n+name: diff-test\n")
+		assert.NotContains(t, output, "staging", "--environment limits the diff")
+	})
+
+	t.Run("missing workflow", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(defaultPath, []byte(generated), 0644))
+
+		output, err := run(t, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 workflow file(s) differ from the manifest")
+		assert.Contains(t, output, "--- /dev/null\n+++ "+stagingPath+"\n@@ -0,0 +1,")
+		assert.NotContains(t, output, "--- "+defaultPath)
+	})
+	t.Run("generator options match generate", func(t *testing.T) {
+		gen := generator.NewWorkflowGenerator("")
+		gen.DisableManifestHash()
+		withoutSHA, err := gen.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(defaultPath, []byte(withoutSHA), 0644))
+
+		_, err = run(t, "default")
+		require.Error(t, err, "the file lacks the manifest hash diff generates by default")
+
+		output, err := run(t, "default", "no-manifest-sha")
+		require.NoError(t, err)
+		assert.Contains(t, output, "No differences")
+	})
+}
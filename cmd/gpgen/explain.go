@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/generator"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [manifest-file]",
+	Short: "Describe the steps of the generated workflows",
+	Long: `Describe every step a GPGen manifest generates: whether it comes from the template
+or a custom step, its effective if condition, and the inputs that shaped it.
+If no file is specified, it will look for manifest.yaml in the current directory.`,
+	RunE: runExplain,
+}
+
+var (
+	explainEnv        string
+	explainNoColor    bool
+	explainForceColor bool
+	explainOptions    generatorOptions
+)
+
+func init() {
+	explainCmd.Flags().StringVarP(&explainEnv, "environment", "e", "", "Explain a specific environment (default: all environments)")
+	explainCmd.Flags().BoolVar(&explainNoColor, "no-color", false, "Disable emoji and colored output")
+	explainCmd.Flags().BoolVar(&explainForceColor, "force-color", false, "Force emoji and colored output even when stdout is not a terminal")
+	explainOptions.addFlags(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	out := newPrinter(explainNoColor, explainForceColor)
+
+	// Determine manifest file path
+	manifestPath := "manifest.yaml"
+	if len(args) > 0 {
+		manifestPath = args[0]
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		return fmt.Errorf("manifest file not found: %s", manifestPath)
+	}
+
+	m, err := manifest.LoadManifestFromFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if err := manifest.ValidateManifest(m); err != nil {
+		return fmt.Errorf("manifest validation failed: %w", err)
+	}
+
+	gen := explainOptions.newGenerator()
+
+	for _, env := range workflowEnvironments(m, explainEnv) {
+		steps, err := gen.ExplainSteps(m, env)
+		if err != nil {
+			return fmt.Errorf("failed to explain environment %s: %w", env, err)
+		}
+
+		out.status("🔎", "Environment: %s", env)
+		for i, step := range steps {
+			out.plain("   %d. %s", i+1, formatStepTitle(step))
+			out.plain("      Source: %s", step.Source)
+			out.plain("      If: %s", formatStepCondition(step.If))
+			if step.Source == generator.StepSourceTemplate {
+				out.plain("      Inputs: %s", formatStepInputs(step.Inputs))
+			}
+		}
+		out.plain("")
+	}
+
+	return nil
+}
+
+// formatStepTitle renders a step's name followed by its ID, when it has one
+func formatStepTitle(step generator.StepExplanation) string {
+	if step.ID == "" {
+		return step.Name
+	}
+	return fmt.Sprintf("%s (%s)", step.Name, step.ID)
+}
+
+// formatStepCondition renders a step condition, noting steps without one
+func formatStepCondition(condition string) string {
+	if condition == "" {
+		return "(always runs)"
+	}
+	return condition
+}
+
+// formatStepInputs renders the inputs a step reads as path=value pairs in sorted order
+func formatStepInputs(inputs map[string]interface{}) string {
+	if len(inputs) == 0 {
+		return "(none)"
+	}
+
+	parts := make([]string, 0, len(inputs))
+	for _, path := range sortedKeys(inputs) {
+		value := inputs[path]
+		if value == nil {
+			value = "(unset)"
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", path, value))
+	}
+	return strings.Join(parts, ", ")
+}
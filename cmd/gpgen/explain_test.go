@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainCommand(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: explain-test
+spec:
+  template: node-app
+  inputs:
+    security:
+      trivy:
+        enabled: true
+  customSteps:
+    - id: lint
+      name: Lint
+      position: after:test
+      run: npm run lint
+  environments:
+    production:
+      inputs:
+        security:
+          trivy:
+            enabled: false`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	run := func(t *testing.T, environment string) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "explain [manifest-file]",
+			RunE: runExplain,
+		}
+		cmd.Flags().StringVarP(&explainEnv, "environment", "e", "", "Explain a specific environment")
+		if environment != "" {
+			require.NoError(t, cmd.Flags().Set("environment", environment))
+		}
+		defer func() { explainEnv = "" }()
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	t.Run("every environment", func(t *testing.T) {
+		output, err := run(t, "")
+		require.NoError(t, err)
+
+		assert.Contains(t, output, "Environment: default")
+		assert.Contains(t, output, "Environment: production")
+		assert.Contains(t, output, "Run Trivy vulnerability scanner (security-scan)\n      Source: template\n      If: true\n      Inputs: security.trivy.enabled=true, security.trivy.severity=CRITICAL,HIGH")
+		assert.Contains(t, output, "5. Lint (lint)\n      Source: custom\n      If: (always runs)\n")
+	})
+
+	t.Run("single environment", func(t *testing.T) {
+		output, err := run(t, "production")
+		require.NoError(t, err)
+
+		assert.NotContains(t, output, "Environment: default")
+		assert.Contains(t, output, "Run Trivy vulnerability scanner (security-scan)\n      Source: template\n      If: false\n      Inputs: security.trivy.enabled=false")
+	})
+
+	t.Run("missing manifest", func(t *testing.T) {
+		cmd := &cobra.Command{Use: "explain [manifest-file]", RunE: runExplain}
+		err := cmd.RunE(cmd, []string{filepath.Join(tempDir, "missing.yaml")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "manifest file not found")
+	})
+}
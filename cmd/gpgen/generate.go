@@ -1,13 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/terrpan/gpgen/pkg/generator"
 	"github.com/terrpan/gpgen/pkg/manifest"
+	"gopkg.in/yaml.v3"
 )
 
 var generateCmd = &cobra.Command{
@@ -19,20 +25,98 @@ If no file is specified, it will look for manifest.yaml in the current directory
 }
 
 var (
-	generateOutput    string
-	generateEnv       string
-	generateDryRun    bool
-	generateOverwrite bool
+	generateOutput     string
+	generateEnv        string
+	generateDryRun     bool
+	generateOverwrite  bool
+	generateMerge      bool
+	generateNoColor    bool
+	generateForceColor bool
+	generateSummary    string
+	generateCheck      bool
+	generateFormatCmd  string
+	generateScaffold   bool
+	generateLayout     string
+	generateFormat     string
+	generateWriteLock  bool
+	generateOptions    generatorOptions
 )
 
+// Output formats for generate
+const (
+	outputFormatText = "text"
+	outputFormatPlan = "plan"
+)
+
+// Output layouts for environment workflows
+const (
+	layoutFlat   = "flat"
+	layoutNested = "nested"
+)
+
+// generatorOptions are the flags that change the generated workflows. Every command that
+// generates workflows accepts them, so diff and verify can match what generate wrote.
+type generatorOptions struct {
+	noTimeout bool
+	prune     bool
+	harden    bool
+	noSHA     bool
+}
+
+// addFlags registers the generator option flags on cmd
+func (o *generatorOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.noTimeout, "no-default-timeout", false, "Don't apply a default job timeout when the manifest sets none (use GitHub's default)")
+	cmd.Flags().BoolVar(&o.prune, "prune", false, "Omit steps whose condition is always false for the manifest's inputs (e.g. container steps when container.enabled is false)")
+	cmd.Flags().BoolVar(&o.harden, "harden-scripts", false, "Prepend \"set -euo pipefail\" to multi-line bash custom steps that don't enable strict mode")
+	cmd.Flags().BoolVar(&o.noSHA, "no-manifest-sha", false, "Don't record the manifest hash in the "+generator.ManifestHashEnv+" workflow env variable")
+}
+
+// newGenerator creates a workflow generator configured by the options
+func (o *generatorOptions) newGenerator() *generator.WorkflowGenerator {
+	gen := generator.NewWorkflowGenerator("")
+	if o.noTimeout {
+		gen.DisableDefaultJobTimeout()
+	}
+	if o.prune {
+		gen.EnablePruning()
+	}
+	if o.harden {
+		gen.EnableScriptHardening()
+	}
+	if o.noSHA {
+		gen.DisableManifestHash()
+	}
+	return gen
+}
+
 func init() {
 	generateCmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory for generated workflows")
 	generateCmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment (default: all environments)")
 	generateCmd.Flags().BoolVarP(&generateDryRun, "dry-run", "d", false, "Show what would be generated without writing files")
 	generateCmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing workflow files")
+	generateCmd.Flags().BoolVar(&generateMerge, "merge", false, "Update only the generated jobs in existing workflow files, keeping other jobs")
+	generateCmd.Flags().BoolVar(&generateNoColor, "no-color", false, "Disable emoji and colored output")
+	generateCmd.Flags().BoolVar(&generateForceColor, "force-color", false, "Force emoji and colored output even when stdout is not a terminal")
+	generateCmd.Flags().BoolVar(&generateCheck, "check", false, "Check that existing workflow files are up to date without writing them")
+	generateCmd.Flags().StringVar(&generateFormatCmd, "format-command", "", "Shell command to pipe each generated workflow through before writing (e.g. \"yamlfmt -\")")
+	generateCmd.Flags().StringVar(&generateLayout, "layout", layoutFlat, "Output layout for environment workflows: flat (<output>/<name>-<env>.yml) or nested (<output>/<env>/<name>.yml)")
+	generateCmd.Flags().StringVar(&generateFormat, "output-format", outputFormatText, "Output format: text, or plan to show which workflow files would be created or updated without writing them")
+	generateCmd.Flags().BoolVar(&generateScaffold, "scaffold-actions", false, "Write a starter composite action.yml for local actions (uses: ./path) that don't have one yet")
+	generateCmd.Flags().BoolVar(&generateWriteLock, "write-lock", false, "Write "+generator.LockFileName+" next to the workflows, recording the gpgen version, template and effective inputs of each environment")
+	generateOptions.addFlags(generateCmd)
+	generateCmd.Flags().StringVar(&generateSummary, "summary", "", "Append a markdown report of generated workflows to this file (e.g. $GITHUB_STEP_SUMMARY)")
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
+	out := newPrinter(generateNoColor, generateForceColor)
+
+	if generateLayout != layoutFlat && generateLayout != layoutNested {
+		return fmt.Errorf("invalid layout %q, must be one of %s, %s", generateLayout, layoutFlat, layoutNested)
+	}
+	if generateFormat != outputFormatText && generateFormat != outputFormatPlan {
+		return fmt.Errorf("invalid output format %q, must be one of %s, %s", generateFormat, outputFormatText, outputFormatPlan)
+	}
+
 	// Determine manifest file path
 	manifestPath := "manifest.yaml"
 	if len(args) > 0 {
@@ -50,7 +134,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	fmt.Printf("📄 Loading manifest: %s\n", absPath)
+	out.status("📄", "Loading manifest: %s", absPath)
 
 	// Load and validate the manifest
 	m, err := manifest.LoadManifestFromFile(absPath)
@@ -62,21 +146,44 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	if err := manifest.ValidateManifest(m); err != nil {
 		return fmt.Errorf("manifest validation failed: %w", err)
 	}
-	fmt.Printf("✅ Manifest loaded and validated\n")
-	fmt.Printf("🏗️  Template: %s\n", m.Spec.Template)
+
+	// Check that local files referenced by inputs exist (error in strict mode, warning otherwise)
+	for _, fileErr := range manifest.CheckRequirementsFiles(m, filepath.Dir(absPath)) {
+		if manifest.GetValidationMode(m) == manifest.ValidationModeStrict {
+			return fmt.Errorf("manifest validation failed: %w", fileErr)
+		}
+		out.warning("Warning: %v", fileErr)
+	}
+	for _, warning := range manifest.CheckDeploymentEnvironments(m) {
+		out.warning("Warning: %s", warning)
+	}
+	for _, warning := range manifest.CheckScriptStrictMode(m, generateOptions.harden) {
+		out.warning("Warning: %s", warning)
+	}
+
+	out.success("Manifest loaded and validated")
+	out.status("🏗️ ", "Template: %s", m.Spec.Template)
 
 	// Create workflow generator
-	gen := generator.NewWorkflowGenerator("")
+	gen := generateOptions.newGenerator()
 
 	// Determine which environments to generate
-	environments := []string{"default"}
-	if generateEnv != "" {
-		environments = []string{generateEnv}
-	} else if len(m.Spec.Environments) > 0 {
-		environments = []string{"default"}
-		for env := range m.Spec.Environments {
-			environments = append(environments, env)
-		}
+	environments := workflowEnvironments(m, generateEnv)
+
+	// GitHub only discovers workflows directly under .github/workflows
+	if generateLayout == layoutNested && slices.ContainsFunc(environments, func(env string) bool { return env != "default" }) {
+		out.warning("Warning: the nested layout writes environment workflows to subdirectories of %s, which GitHub does not run; move them directly under .github/workflows to use them", generateOutput)
+	}
+
+	if err := checkPolicy(gen, m, environments); err != nil {
+		return err
+	}
+
+	if generateCheck {
+		return checkWorkflows(out, m, gen, environments)
+	}
+	if generateFormat == outputFormatPlan {
+		return planWorkflows(out, manifestPath, m, gen, environments)
 	}
 
 	// Create output directory if it doesn't exist
@@ -86,36 +193,60 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	generated := make([]generatedWorkflow, 0, len(environments))
 	for _, env := range environments {
-		workflowName := fmt.Sprintf("%s.yml", m.Metadata.Name)
-		if env != "default" {
-			workflowName = fmt.Sprintf("%s-%s.yml", m.Metadata.Name, env)
-		}
-
-		outputPath := filepath.Join(generateOutput, workflowName)
+		outputPath := filepath.Join(generateOutput, workflowFileName(m, env, generateLayout))
 
 		if generateDryRun {
-			fmt.Printf("📝 Would generate: %s\n", outputPath)
-			fmt.Printf("   Environment: %s\n", env)
+			// Generate the workflow anyway so encoding problems surface before anything is written
+			workflowContent, err := generateFormatted(gen, m, env)
+			if err != nil {
+				return fmt.Errorf("failed to generate workflow for %s: %w", env, err)
+			}
+			if err := verifyWorkflowYAML(workflowContent); err != nil {
+				return fmt.Errorf("generated workflow for %s is invalid: %w", env, err)
+			}
+
+			explanation, err := gen.ExplainWorkflow(m, env)
+			if err != nil {
+				return fmt.Errorf("failed to explain environment %s: %w", env, err)
+			}
+
+			out.status("📝", "Would generate: %s", outputPath)
+			out.plain("   Environment: %s", env)
 			if env != "default" {
 				if _, exists := m.Spec.Environments[env]; exists {
-					fmt.Printf("   Environment-specific config: yes\n")
+					out.plain("   Environment-specific config: yes")
 				}
 			}
-			fmt.Printf("   Custom steps: %d\n", len(m.Spec.CustomSteps))
-			fmt.Printf("\n")
+			out.plain("   Custom steps: %d", len(m.Spec.CustomSteps))
+			out.plain("   Concurrency: %s", formatConcurrency(explanation.Concurrency))
+			out.plain("   Timeout: %s", formatTimeout(explanation.TimeoutMinutes))
+			out.plain("   Workflow YAML: valid")
+			out.plain("")
 		} else {
 			// Generate the workflow
-			fmt.Printf("🔨 Generating workflow for environment: %s\n", env)
+			out.status("🔨", "Generating workflow for environment: %s", env)
 
-			workflowContent, err := gen.GenerateWorkflow(m, env)
+			workflowContent, err := generateFormatted(gen, m, env)
 			if err != nil {
 				return fmt.Errorf("failed to generate workflow for %s: %w", env, err)
 			}
 
-			// Check if file exists and handle overwrite
-			if _, err := os.Stat(outputPath); err == nil && !generateOverwrite {
-				return fmt.Errorf("workflow file %s already exists. Use --overwrite to replace it", outputPath)
+			// Check if file exists and handle merge or overwrite
+			if _, err := os.Stat(outputPath); err == nil {
+				if generateMerge {
+					workflowContent, err = mergeWorkflowFile(outputPath, workflowContent)
+					if err != nil {
+						return err
+					}
+				} else if !generateOverwrite {
+					return fmt.Errorf("workflow file %s already exists. Use --overwrite to replace it or --merge to update only its generated jobs", outputPath)
+				}
+			}
+
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
 			}
 
 			// Write workflow file
@@ -123,17 +254,423 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf("failed to write workflow file %s: %w", outputPath, err)
 			}
 
-			fmt.Printf("✅ Generated: %s\n", outputPath)
+			out.success("Generated: %s", outputPath)
+		}
+
+		generated = append(generated, generatedWorkflow{Environment: env, Path: outputPath})
+	}
+
+	if generateWriteLock && !generateDryRun {
+		lockPath, err := writeLock(m, gen, environments)
+		if err != nil {
+			return err
 		}
+		out.success("Locked inputs: %s", lockPath)
+	}
+
+	if generateScaffold && !generateDryRun {
+		// Local action paths are relative to the repository root, i.e. the working directory
+		scaffolded, err := gen.ScaffoldLocalActions(m, environments, ".")
+		if err != nil {
+			return fmt.Errorf("failed to scaffold local actions: %w", err)
+		}
+		for _, actionFile := range scaffolded {
+			out.success("Scaffolded: %s", actionFile)
+		}
+	}
+
+	if generateSummary != "" {
+		if err := writeGenerateSummary(generateSummary, m, gen, generated, generateDryRun); err != nil {
+			return fmt.Errorf("failed to write summary: %w", err)
+		}
+		out.status("📋", "Summary written to: %s", generateSummary)
 	}
 
 	if generateDryRun {
-		fmt.Printf("💡 Run without --dry-run to generate the actual workflow files\n")
+		out.status("💡", "Run without --dry-run to generate the actual workflow files")
+	} else {
+		out.plain("")
+		out.status("🎉", "Successfully generated %d workflow file(s)", len(environments))
+		out.status("📁", "Output directory: %s", generateOutput)
+		out.status("🚀", "Commit and push to trigger your workflows!")
+	}
+
+	return nil
+}
+
+// generateFormatted generates the workflow for an environment and pipes it through
+// --format-command when one is set
+func generateFormatted(gen *generator.WorkflowGenerator, m *manifest.Manifest, env string) (string, error) {
+	content, err := gen.GenerateWorkflow(m, env)
+	if err != nil {
+		return "", err
+	}
+	if generateFormatCmd == "" {
+		return content, nil
+	}
+	return formatWorkflow(generateFormatCmd, content)
+}
+
+// mergeWorkflowFile merges generated content into the workflow file at path, replacing only
+// its gpgen-managed jobs and settings
+func mergeWorkflowFile(path, content string) (string, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	merged, err := generator.MergeWorkflow(string(existing), content)
+	if err != nil {
+		return "", fmt.Errorf("failed to merge into %s: %w", path, err)
+	}
+	return merged, nil
+}
+
+// formatWorkflow runs command through the shell with content on stdin and returns its stdout
+func formatWorkflow(command, content string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("format command %q failed: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("format command %q failed: %w", command, err)
+	}
+	if stdout.Len() == 0 {
+		return "", fmt.Errorf("format command %q produced no output", command)
+	}
+	return stdout.String(), nil
+}
+
+// checkWorkflows regenerates each environment's workflow and reports committed files
+// that are missing, were produced from a different template, or no longer match the manifest
+func checkWorkflows(out *printer, m *manifest.Manifest, gen *generator.WorkflowGenerator, environments []string) error {
+	templateHash, err := gen.TemplateHash(m.Spec.Template)
+	if err != nil {
+		return fmt.Errorf("failed to hash template: %w", err)
+	}
+
+	stale := 0
+	for _, env := range environments {
+		outputPath, workflowContent, err := expectedWorkflow(gen, m, env)
+		if err != nil {
+			return err
+		}
+
+		if err := checkWorkflowFile(outputPath, workflowContent, m.Spec.Template, templateHash); err != nil {
+			out.warning("%v", err)
+			stale++
+			continue
+		}
+		out.success("Up to date: %s", outputPath)
+	}
+
+	drift, err := checkLock(m, gen, environments)
+	if err != nil {
+		return err
+	}
+	for _, change := range drift {
+		out.warning("%s", change)
+	}
+
+	if stale > 0 {
+		return fmt.Errorf("%d workflow file(s) out of date, run gpgen generate --overwrite to update them", stale)
+	}
+	if len(drift) > 0 {
+		return fmt.Errorf("%s is out of date, run gpgen generate --overwrite --write-lock to update it", lockPath())
+	}
+	return nil
+}
+
+// lockPath returns the path of the lockfile next to the generated workflows
+func lockPath() string {
+	return filepath.Join(generateOutput, generator.LockFileName)
+}
+
+// readLock reads the lockfile, returning nil when it doesn't exist
+func readLock() (*generator.LockFile, error) {
+	data, err := os.ReadFile(lockPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", lockPath(), err)
+	}
+	file, err := generator.ParseLockFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", lockPath(), err)
+	}
+	return file, nil
+}
+
+// writeLock records the environments' effective inputs in the manifest's lockfile entry,
+// keeping the entries of other manifests and of environments that weren't generated, and
+// returns its path
+func writeLock(m *manifest.Manifest, gen *generator.WorkflowGenerator, environments []string) (string, error) {
+	current, err := gen.BuildLock(m, environments, version)
+	if err != nil {
+		return "", fmt.Errorf("failed to build lockfile: %w", err)
+	}
+
+	file, err := readLock()
+	if err != nil {
+		return "", err
+	}
+	if file == nil {
+		file = &generator.LockFile{Manifests: make(map[string]*generator.Lock)}
+	}
+	key := generator.LockKey(m)
+	if lock := file.Manifests[key]; lock == nil || lock.Template.Name != current.Template.Name {
+		file.Manifests[key] = current
 	} else {
-		fmt.Printf("\n🎉 Successfully generated %d workflow file(s)\n", len(environments))
-		fmt.Printf("📁 Output directory: %s\n", generateOutput)
-		fmt.Printf("🚀 Commit and push to trigger your workflows!\n")
+		lock.Update(current)
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(lockPath(), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", lockPath(), err)
+	}
+	return lockPath(), nil
+}
+
+// checkLock compares the manifest's lockfile entry against the environments' effective inputs
+// and returns a description of each difference. Without a lockfile or an entry there is
+// nothing to compare, unless --write-lock asks for one.
+func checkLock(m *manifest.Manifest, gen *generator.WorkflowGenerator, environments []string) ([]string, error) {
+	file, err := readLock()
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		if generateWriteLock {
+			return []string{fmt.Sprintf("%s is missing", lockPath())}, nil
+		}
+		return nil, nil
+	}
+	lock := file.Manifests[generator.LockKey(m)]
+	if lock == nil {
+		if generateWriteLock {
+			return []string{fmt.Sprintf("%s has no entry for %s", lockPath(), generator.LockKey(m))}, nil
+		}
+		return nil, nil
+	}
+
+	current, err := gen.BuildLock(m, environments, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build lockfile: %w", err)
 	}
 
+	drift := lock.Drift(current)
+	for i, change := range drift {
+		drift[i] = fmt.Sprintf("%s: %s", lockPath(), change)
+	}
+	return drift, nil
+}
+
+// expectedWorkflow returns the output path of an environment's workflow and the content it
+// should have, merged into the existing file when --merge is set
+func expectedWorkflow(gen *generator.WorkflowGenerator, m *manifest.Manifest, env string) (string, string, error) {
+	outputPath := filepath.Join(generateOutput, workflowFileName(m, env, generateLayout))
+
+	content, err := generateFormatted(gen, m, env)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate workflow for %s: %w", env, err)
+	}
+	if _, err := os.Stat(outputPath); err == nil && generateMerge {
+		if content, err = mergeWorkflowFile(outputPath, content); err != nil {
+			return "", "", err
+		}
+	}
+	return outputPath, content, nil
+}
+
+// Plan actions for a workflow file
+const (
+	planCreate    = "create"
+	planUpdate    = "update"
+	planUnchanged = "unchanged"
+)
+
+// planWorkflows prints a tree of the workflow files generate would write for each environment
+// and whether each would be created, updated or left unchanged, without writing anything
+func planWorkflows(out *printer, manifestPath string, m *manifest.Manifest, gen *generator.WorkflowGenerator, environments []string) error {
+	counts := make(map[string]int)
+
+	out.plain("")
+	out.plain("%s (template %s)", manifestPath, m.Spec.Template)
+	for i, env := range environments {
+		outputPath, content, err := expectedWorkflow(gen, m, env)
+		if err != nil {
+			return err
+		}
+		action, err := planWorkflowFile(outputPath, content)
+		if err != nil {
+			return err
+		}
+		counts[action]++
+
+		branch, indent := "├──", "│  "
+		if i == len(environments)-1 {
+			branch, indent = "└──", "   "
+		}
+		out.plain("%s %s", branch, env)
+		out.plain("%s └── %s %s", indent, action, outputPath)
+	}
+
+	out.plain("")
+	out.plain("Plan: %d to create, %d to update, %d unchanged", counts[planCreate], counts[planUpdate], counts[planUnchanged])
+	return nil
+}
+
+// planWorkflowFile reports whether writing content to path would create, update or leave
+// the file unchanged
+func planWorkflowFile(path, content string) (string, error) {
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return planCreate, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if string(existing) != content {
+		return planUpdate, nil
+	}
+	return planUnchanged, nil
+}
+
+// checkWorkflowFile compares a committed workflow file against freshly generated content
+func checkWorkflowFile(path, content, templateName, templateHash string) error {
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s is missing", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if committedHash := generator.TemplateHashFromWorkflow(string(existing)); committedHash != templateHash {
+		return fmt.Errorf("%s is stale: template %s changed since it was generated (hash %q, now %q)",
+			path, templateName, committedHash, templateHash)
+	}
+	if string(existing) != content {
+		return fmt.Errorf("%s is out of date with the manifest", path)
+	}
+	return nil
+}
+
+// verifyWorkflowYAML re-parses generated workflow content and checks it has the basic
+// shape of a GitHub Actions workflow
+func verifyWorkflowYAML(content string) error {
+	var workflow struct {
+		Name string                 `yaml:"name"`
+		On   map[string]interface{} `yaml:"on"`
+		Jobs map[string]struct {
+			RunsOn interface{}              `yaml:"runs-on"`
+			Steps  []map[string]interface{} `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+
+	if err := yaml.Unmarshal([]byte(content), &workflow); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(workflow.On) == 0 {
+		return fmt.Errorf("workflow has no triggers")
+	}
+	if len(workflow.Jobs) == 0 {
+		return fmt.Errorf("workflow has no jobs")
+	}
+	for name, job := range workflow.Jobs {
+		if job.RunsOn == nil {
+			return fmt.Errorf("job %s has no runs-on", name)
+		}
+		if len(job.Steps) == 0 {
+			return fmt.Errorf("job %s has no steps", name)
+		}
+	}
+
+	return nil
+}
+
+// workflowEnvironments returns the environments to generate workflows for: only, when set,
+// otherwise the default environment followed by the manifest's environments in sorted order
+func workflowEnvironments(m *manifest.Manifest, only string) []string {
+	if only != "" {
+		return []string{only}
+	}
+
+	environments := make([]string, 0, len(m.Spec.Environments))
+	for env := range m.Spec.Environments {
+		environments = append(environments, env)
+	}
+	sort.Strings(environments)
+	return append([]string{"default"}, environments...)
+}
+
+// workflowFileName returns the path, relative to the output directory, a manifest's workflow
+// is written to for an environment under the given layout
+func workflowFileName(m *manifest.Manifest, env, layout string) string {
+	if env != "default" {
+		if layout == layoutNested {
+			return filepath.Join(env, fmt.Sprintf("%s.yml", m.Metadata.Name))
+		}
+		return fmt.Sprintf("%s-%s.yml", m.Metadata.Name, env)
+	}
+	return fmt.Sprintf("%s.yml", m.Metadata.Name)
+}
+
+// generatedWorkflow records a workflow produced (or previewed) for an environment
+type generatedWorkflow struct {
+	Environment string
+	Path        string
+}
+
+// writeGenerateSummary appends a markdown report of the generated workflows to path,
+// suitable for GitHub job summaries
+func writeGenerateSummary(path string, m *manifest.Manifest, gen *generator.WorkflowGenerator, workflows []generatedWorkflow, dryRun bool) error {
+	var sb strings.Builder
+
+	title := "## GPGen workflow summary"
+	if dryRun {
+		title += " (dry run)"
+	}
+	sb.WriteString(title + "\n\n")
+	sb.WriteString(fmt.Sprintf("- **Manifest:** %s\n", m.Metadata.Name))
+	sb.WriteString(fmt.Sprintf("- **Template:** `%s`\n\n", m.Spec.Template))
+
+	sb.WriteString("| Environment | Workflow | Security scanning | Container build | Container push | Concurrency | Timeout |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, workflow := range workflows {
+		explanation, err := gen.ExplainWorkflow(m, workflow.Environment)
+		if err != nil {
+			return fmt.Errorf("failed to explain environment %s: %w", workflow.Environment, err)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s | %s | %s | %s |\n",
+			workflow.Environment,
+			workflow.Path,
+			enabledString(explanation.SecurityEnabled),
+			enabledString(explanation.ContainerEnabled),
+			enabledString(explanation.ContainerPush),
+			strings.ReplaceAll(formatConcurrency(explanation.Concurrency), "|", "\\|"),
+			formatTimeout(explanation.TimeoutMinutes),
+		))
+	}
+	sb.WriteString("\n")
+
+	// Append like $GITHUB_STEP_SUMMARY so multiple steps can contribute to one report
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(sb.String()); err != nil {
+		return err
+	}
 	return nil
 }
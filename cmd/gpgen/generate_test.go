@@ -1,14 +1,17 @@
 package main
 
 import (
-	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/generator"
+	"github.com/terrpan/gpgen/pkg/templates"
 )
 
 func TestGenerateCommand(t *testing.T) {
@@ -354,20 +357,9 @@ spec:
 				}
 			}
 
-			// Capture output to avoid cluttering test output
-			originalStdout := os.Stdout
-			r, w, _ := os.Pipe()
-			os.Stdout = w
-
-			// Execute command
-			err = cmd.RunE(cmd, tt.args)
-
-			// Restore stdout
-			w.Close()
-			os.Stdout = originalStdout
-
-			// Drain the pipe
-			_, _ = io.ReadAll(r)
+			_, err = captureStdout(t, func() error {
+				return cmd.RunE(cmd, tt.args)
+			})
 
 			// Run validation
 			if tt.validateFunc != nil {
@@ -387,6 +379,12 @@ func TestGenerateCmdFlagsAndHelp(t *testing.T) {
 	assert.NotNil(t, generateCmd.Flags().Lookup("environment"))
 	assert.NotNil(t, generateCmd.Flags().Lookup("dry-run"))
 	assert.NotNil(t, generateCmd.Flags().Lookup("overwrite"))
+	assert.NotNil(t, generateCmd.Flags().Lookup("no-color"))
+	assert.NotNil(t, generateCmd.Flags().Lookup("force-color"))
+	assert.NotNil(t, generateCmd.Flags().Lookup("summary"))
+	assert.NotNil(t, generateCmd.Flags().Lookup("no-default-timeout"))
+	assert.NotNil(t, generateCmd.Flags().Lookup("check"))
+	assert.NotNil(t, generateCmd.Flags().Lookup("format-command"))
 
 	// Test flag shortcuts
 	assert.NotNil(t, generateCmd.Flags().ShorthandLookup("o"))
@@ -448,17 +446,9 @@ spec:
 	cmd.Flags().BoolVarP(&generateDryRun, "dry-run", "d", false, "Show what would be generated")
 	cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
 
-	// Capture output
-	originalStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	err = cmd.RunE(cmd, []string{})
-
-	// Restore stdout
-	w.Close()
-	os.Stdout = originalStdout
-	_, _ = io.ReadAll(r)
+	_, err = captureStdout(t, func() error {
+		return cmd.RunE(cmd, []string{})
+	})
 
 	assert.NoError(t, err)
 
@@ -471,3 +461,799 @@ spec:
 	assert.FileExists(t, stagingWorkflow)
 	assert.FileExists(t, productionWorkflow)
 }
+
+func TestGenerateOutputDecoration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: color-test
+spec:
+  template: node-app`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	runWithPipe := func(t *testing.T, flags ...string) string {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+		cmd.Flags().BoolVarP(&generateDryRun, "dry-run", "d", false, "Show what would be generated")
+		cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+		cmd.Flags().BoolVar(&generateNoColor, "no-color", false, "Disable emoji and colored output")
+		cmd.Flags().BoolVar(&generateForceColor, "force-color", false, "Force emoji and colored output")
+		require.NoError(t, cmd.Flags().Set("dry-run", "true"))
+		for _, flag := range flags {
+			require.NoError(t, cmd.Flags().Set(flag, "true"))
+		}
+		defer func() {
+			generateDryRun = false
+			generateNoColor = false
+			generateForceColor = false
+		}()
+
+		out, err := captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("non-TTY output omits ANSI codes and emoji", func(t *testing.T) {
+		output := runWithPipe(t)
+
+		assert.Contains(t, output, "Loading manifest:")
+		assert.Contains(t, output, "Manifest loaded and validated")
+		assert.Contains(t, output, "Concurrency: none")
+		assert.Contains(t, output, "Timeout: 30 minutes")
+		assert.NotContains(t, output, "\033[")
+		assert.NotContains(t, output, "✅")
+		assert.NotContains(t, output, "📄")
+	})
+
+	t.Run("force-color decorates non-TTY output", func(t *testing.T) {
+		output := runWithPipe(t, "force-color")
+
+		assert.Contains(t, output, "📄 Loading manifest:")
+		assert.Contains(t, output, ansiGreen+"✅ Manifest loaded and validated"+ansiReset)
+	})
+
+	t.Run("no-color wins over environment defaults", func(t *testing.T) {
+		output := runWithPipe(t, "no-color")
+
+		assert.NotContains(t, output, "\033[")
+		assert.NotContains(t, output, "✅")
+	})
+}
+
+func TestVerifyWorkflowYAML(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name: "valid workflow",
+			content: `name: ci
+on:
+  push:
+    branches: [main]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4`,
+		},
+		{
+			name: "malformed yaml",
+			content: `name: ci
+on:
+  push: [main
+jobs: {}`,
+			expectedErr: "failed to parse YAML",
+		},
+		{
+			name: "missing jobs",
+			content: `name: ci
+on:
+  push: {}`,
+			expectedErr: "workflow has no jobs",
+		},
+		{
+			name: "job without steps",
+			content: `name: ci
+on:
+  push: {}
+jobs:
+  build:
+    runs-on: ubuntu-latest`,
+			expectedErr: "job build has no steps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyWorkflowYAML(tt.content)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestGenerateSummary(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: summary-test
+spec:
+  template: go-service
+  inputs:
+    container:
+      enabled: true
+  environments:
+    staging:
+      inputs:
+        goVersion: "1.23"`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	summaryPath := filepath.Join(tempDir, "summary.md")
+	require.NoError(t, os.WriteFile(summaryPath, []byte("# Existing summary\n\n"), 0644))
+
+	cmd := &cobra.Command{
+		Use:  "generate [manifest-file]",
+		RunE: runGenerate,
+	}
+	cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+	cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+	cmd.Flags().BoolVarP(&generateDryRun, "dry-run", "d", false, "Show what would be generated")
+	cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+	cmd.Flags().StringVar(&generateSummary, "summary", "", "Append a markdown report to this file")
+	require.NoError(t, cmd.Flags().Set("output", filepath.Join(tempDir, "workflows")))
+	require.NoError(t, cmd.Flags().Set("summary", summaryPath))
+	defer func() {
+		generateOutput = ".github/workflows"
+		generateSummary = ""
+	}()
+
+	_, err := captureStdout(t, func() error {
+		return cmd.RunE(cmd, []string{manifestPath})
+	})
+
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	summary := string(content)
+
+	assert.True(t, strings.HasPrefix(summary, "# Existing summary"), "summary should be appended")
+	assert.Contains(t, summary, "## GPGen workflow summary")
+	assert.Contains(t, summary, "**Template:** `go-service`")
+	assert.Contains(t, summary, "| default | `"+filepath.Join(tempDir, "workflows", "summary-test.yml")+"` | enabled | enabled | enabled | none | 30 minutes |")
+	assert.Contains(t, summary, "| staging | `"+filepath.Join(tempDir, "workflows", "summary-test-staging.yml")+"`")
+}
+
+func TestGenerateDefaultJobTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: timeout-test
+spec:
+  template: node-app`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	generateWith := func(t *testing.T, noTimeout bool) string {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+		cmd.Flags().BoolVarP(&generateDryRun, "dry-run", "d", false, "Show what would be generated")
+		cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+		cmd.Flags().BoolVar(&generateOptions.noTimeout, "no-default-timeout", false, "Don't apply a default job timeout")
+		require.NoError(t, cmd.Flags().Set("output", filepath.Join(tempDir, "workflows")))
+		require.NoError(t, cmd.Flags().Set("overwrite", "true"))
+		if noTimeout {
+			require.NoError(t, cmd.Flags().Set("no-default-timeout", "true"))
+		}
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateOverwrite = false
+			generateOptions.noTimeout = false
+		}()
+
+		_, err := captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "workflows", "timeout-test.yml"))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("default timeout is applied", func(t *testing.T) {
+		assert.Contains(t, generateWith(t, false), "    timeout-minutes: 30\n")
+	})
+
+	t.Run("default timeout can be disabled", func(t *testing.T) {
+		assert.NotContains(t, generateWith(t, true), "    timeout-minutes: 30\n")
+	})
+}
+
+func TestGenerateManifestSHA(t *testing.T) {
+	tempDir := t.TempDir()
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+
+	generateWith := func(t *testing.T, manifestContent string, noSHA bool) string {
+		t.Helper()
+		require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+		cmd.Flags().BoolVar(&generateOptions.noSHA, "no-manifest-sha", false, "Don't record the manifest hash")
+		require.NoError(t, cmd.Flags().Set("output", filepath.Join(tempDir, "workflows")))
+		require.NoError(t, cmd.Flags().Set("overwrite", "true"))
+		if noSHA {
+			require.NoError(t, cmd.Flags().Set("no-manifest-sha", "true"))
+		}
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateOverwrite = false
+			generateOptions.noSHA = false
+		}()
+
+		_, err := captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "workflows", "sha-test.yml"))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	manifestSHA := func(t *testing.T, workflow string) string {
+		t.Helper()
+		match := regexp.MustCompile(`\n  GPGEN_MANIFEST_SHA: ([0-9a-f]{16})\n`).FindStringSubmatch(workflow)
+		require.NotNil(t, match, "workflow should record the manifest hash")
+		return match[1]
+	}
+
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: sha-test
+spec:
+  template: node-app
+  inputs:
+    nodeVersion: "20"`
+
+	sha := manifestSHA(t, generateWith(t, manifestContent, false))
+
+	t.Run("stable across regenerations and comments", func(t *testing.T) {
+		assert.Equal(t, sha, manifestSHA(t, generateWith(t, manifestContent, false)))
+		assert.Equal(t, sha, manifestSHA(t, generateWith(t, "# pinned for the release train\n"+manifestContent, false)))
+	})
+
+	t.Run("changes when the manifest changes", func(t *testing.T) {
+		changed := strings.Replace(manifestContent, `nodeVersion: "20"`, `nodeVersion: "22"`, 1)
+		assert.NotEqual(t, sha, manifestSHA(t, generateWith(t, changed, false)))
+	})
+
+	t.Run("can be disabled", func(t *testing.T) {
+		assert.NotContains(t, generateWith(t, manifestContent, true), "GPGEN_MANIFEST_SHA")
+	})
+}
+
+func TestGenerateCheck(t *testing.T) {
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "workflows")
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: check-test
+spec:
+  template: go-service`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	run := func(t *testing.T, check bool) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+		cmd.Flags().BoolVarP(&generateDryRun, "dry-run", "d", false, "Show what would be generated")
+		cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+		cmd.Flags().BoolVar(&generateCheck, "check", false, "Check existing workflow files")
+		require.NoError(t, cmd.Flags().Set("output", outputDir))
+		require.NoError(t, cmd.Flags().Set("overwrite", "true"))
+		if check {
+			require.NoError(t, cmd.Flags().Set("check", "true"))
+		}
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateOverwrite = false
+			generateCheck = false
+		}()
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	workflowPath := filepath.Join(outputDir, "check-test.yml")
+
+	t.Run("missing workflow is reported", func(t *testing.T) {
+		output, err := run(t, true)
+		assert.Error(t, err)
+		assert.Contains(t, output, workflowPath+" is missing")
+	})
+
+	t.Run("freshly generated workflow is up to date", func(t *testing.T) {
+		_, err := run(t, false)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(workflowPath)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "# Generated by gpgen from template go-service"))
+
+		output, err := run(t, true)
+		assert.NoError(t, err)
+		assert.Contains(t, output, "Up to date: "+workflowPath)
+	})
+
+	t.Run("template change is detected as drift", func(t *testing.T) {
+		_, err := run(t, false)
+		require.NoError(t, err)
+
+		original := templates.GitHubActionVersions
+		defer func() { templates.GitHubActionVersions = original }()
+		require.NoError(t, templates.SetActionVersions(map[string]string{"setupGo": "v3"}))
+
+		output, err := run(t, true)
+		assert.Error(t, err)
+		assert.Contains(t, output, "template go-service changed since it was generated")
+	})
+
+	t.Run("manifest change is detected as drift", func(t *testing.T) {
+		_, err := run(t, false)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(workflowPath)
+		require.NoError(t, err)
+		edited := strings.Replace(string(content), "name: check-test", "name: renamed", 1)
+		require.NoError(t, os.WriteFile(workflowPath, []byte(edited), 0644))
+
+		output, err := run(t, true)
+		assert.Error(t, err)
+		assert.Contains(t, output, "is out of date with the manifest")
+	})
+}
+
+func TestGenerateWriteLock(t *testing.T) {
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "workflows")
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	writeManifest := func(t *testing.T, goVersion string) {
+		t.Helper()
+		manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: lock-test
+spec:
+  template: go-service
+  inputs:
+    goVersion: "` + goVersion + `"
+  environments:
+    production:
+      inputs:
+        testCommand: go test -race ./...`
+		require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+	}
+
+	run := func(t *testing.T, flags ...string) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+		cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+		cmd.Flags().BoolVar(&generateCheck, "check", false, "Check existing workflow files")
+		cmd.Flags().BoolVar(&generateWriteLock, "write-lock", false, "Write the lockfile")
+		require.NoError(t, cmd.Flags().Set("output", outputDir))
+		require.NoError(t, cmd.Flags().Set("overwrite", "true"))
+		for _, flag := range flags {
+			require.NoError(t, cmd.Flags().Set(flag, "true"))
+		}
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateOverwrite = false
+			generateCheck = false
+			generateWriteLock = false
+		}()
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	lockPath := filepath.Join(outputDir, generator.LockFileName)
+
+	t.Run("lockfile captures the effective inputs", func(t *testing.T) {
+		writeManifest(t, "1.23")
+		_, err := run(t, "write-lock")
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(lockPath)
+		require.NoError(t, err)
+		file, err := generator.ParseLockFile(data)
+		require.NoError(t, err)
+		require.Contains(t, file.Manifests, "lock-test")
+		lock := file.Manifests["lock-test"]
+
+		assert.Equal(t, version, lock.GpgenVersion)
+		assert.Equal(t, "go-service", lock.Template.Name)
+		assert.NotEmpty(t, lock.Template.Version)
+		assert.Equal(t, "1.23", lock.Environments["default"].Inputs["goVersion"])
+		assert.Equal(t, "go test ./...", lock.Environments["default"].Inputs["testCommand"], "template defaults are recorded")
+		assert.Equal(t, "go test -race ./...", lock.Environments["production"].Inputs["testCommand"])
+	})
+
+	t.Run("manifests sharing the output directory keep their own entries", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(manifestPath, []byte(`apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: lock-other
+spec:
+  template: node-app`), 0644))
+		_, err := run(t, "write-lock")
+		require.NoError(t, err)
+		writeManifest(t, "1.23")
+
+		data, err := os.ReadFile(lockPath)
+		require.NoError(t, err)
+		file, err := generator.ParseLockFile(data)
+		require.NoError(t, err)
+		assert.Equal(t, "go-service", file.Manifests["lock-test"].Template.Name)
+		assert.Equal(t, "node-app", file.Manifests["lock-other"].Template.Name)
+	})
+
+	t.Run("check passes while inputs are unchanged", func(t *testing.T) {
+		_, err := run(t, "check")
+		assert.NoError(t, err)
+	})
+
+	t.Run("check flags a changed input", func(t *testing.T) {
+		writeManifest(t, "1.24")
+		output, err := run(t, "check")
+		assert.Error(t, err)
+		assert.Contains(t, output, lockPath+": environment default: input goVersion changed from \"1.23\" to \"1.24\"")
+	})
+
+	t.Run("check reports a missing lockfile when asked to write one", func(t *testing.T) {
+		require.NoError(t, os.Remove(lockPath))
+		output, err := run(t, "check", "write-lock")
+		assert.Error(t, err)
+		assert.Contains(t, output, lockPath+" is missing")
+	})
+}
+
+func TestGenerateFormatCommand(t *testing.T) {
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "workflows")
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: format-test
+spec:
+  template: node-app`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	run := func(t *testing.T, formatCommand string) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+		cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+		cmd.Flags().StringVar(&generateFormatCmd, "format-command", "", "Format command")
+		require.NoError(t, cmd.Flags().Set("output", outputDir))
+		require.NoError(t, cmd.Flags().Set("overwrite", "true"))
+		if formatCommand != "" {
+			require.NoError(t, cmd.Flags().Set("format-command", formatCommand))
+		}
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateOverwrite = false
+			generateFormatCmd = ""
+		}()
+
+		_, err := captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+		if err != nil {
+			return "", err
+		}
+
+		content, err := os.ReadFile(filepath.Join(outputDir, "format-test.yml"))
+		require.NoError(t, err)
+		return string(content), nil
+	}
+
+	t.Run("output passes through the formatter unchanged", func(t *testing.T) {
+		unformatted, err := run(t, "")
+		require.NoError(t, err)
+
+		formatted, err := run(t, "cat")
+		require.NoError(t, err)
+		assert.Equal(t, unformatted, formatted)
+	})
+
+	t.Run("formatter output is written", func(t *testing.T) {
+		formatted, err := run(t, "sed 's/^name: /name: formatted-/'")
+		require.NoError(t, err)
+		assert.Contains(t, formatted, "name: formatted-format-test")
+	})
+
+	t.Run("failing formatter fails generation", func(t *testing.T) {
+		_, err := run(t, "echo 'bad style' >&2; exit 3")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "format command")
+		assert.Contains(t, err.Error(), "bad style")
+	})
+}
+
+func TestGenerateLayout(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: layout-test
+spec:
+  template: node-app
+  environments:
+    staging:
+      inputs:
+        nodeVersion: "20"`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	run := func(t *testing.T, layout, outputDir string) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+		cmd.Flags().StringVar(&generateLayout, "layout", "flat", "Output layout")
+		require.NoError(t, cmd.Flags().Set("output", outputDir))
+		require.NoError(t, cmd.Flags().Set("layout", layout))
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateLayout = "flat"
+		}()
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	t.Run("flat", func(t *testing.T) {
+		outputDir := filepath.Join(tempDir, "flat")
+		output, err := run(t, "flat", outputDir)
+		require.NoError(t, err)
+
+		assert.FileExists(t, filepath.Join(outputDir, "layout-test.yml"))
+		assert.FileExists(t, filepath.Join(outputDir, "layout-test-staging.yml"))
+		assert.NotContains(t, output, "nested layout")
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		outputDir := filepath.Join(tempDir, "nested")
+		output, err := run(t, "nested", outputDir)
+		require.NoError(t, err)
+
+		assert.FileExists(t, filepath.Join(outputDir, "layout-test.yml"))
+		assert.FileExists(t, filepath.Join(outputDir, "staging", "layout-test.yml"))
+		assert.NoFileExists(t, filepath.Join(outputDir, "layout-test-staging.yml"))
+		assert.Contains(t, output, "nested layout")
+	})
+
+	t.Run("invalid layout", func(t *testing.T) {
+		_, err := run(t, "tree", filepath.Join(tempDir, "invalid"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid layout")
+	})
+}
+
+func TestGenerateMerge(t *testing.T) {
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "workflows")
+	require.NoError(t, os.MkdirAll(outputDir, 0755))
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: merge-test
+spec:
+  template: node-app`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	workflowPath := filepath.Join(outputDir, "merge-test.yml")
+	existing := `name: merge-test
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo outdated
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: npm run lint
+`
+	require.NoError(t, os.WriteFile(workflowPath, []byte(existing), 0644))
+
+	run := func(t *testing.T, check bool) error {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().StringVarP(&generateEnv, "environment", "e", "", "Generate for specific environment")
+		cmd.Flags().BoolVar(&generateMerge, "merge", false, "Merge into existing files")
+		cmd.Flags().BoolVar(&generateCheck, "check", false, "Check existing workflow files")
+		require.NoError(t, cmd.Flags().Set("output", outputDir))
+		require.NoError(t, cmd.Flags().Set("merge", "true"))
+		if check {
+			require.NoError(t, cmd.Flags().Set("check", "true"))
+		}
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateMerge = false
+			generateCheck = false
+		}()
+
+		_, err := captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+		return err
+	}
+
+	require.NoError(t, run(t, false))
+
+	content, err := os.ReadFile(workflowPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "npm run lint")
+	assert.NotContains(t, string(content), "echo outdated")
+	assert.Contains(t, string(content), "actions/setup-node")
+
+	assert.NoError(t, run(t, true), "merged workflow is up to date")
+}
+
+func TestGeneratePlan(t *testing.T) {
+	tempDir := t.TempDir()
+	outputDir := filepath.Join(tempDir, "workflows")
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: plan-test
+spec:
+  template: go-service
+  environments:
+    staging:
+      environment:
+        name: staging`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	run := func(t *testing.T, plan bool) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "generate [manifest-file]",
+			RunE: runGenerate,
+		}
+		cmd.Flags().StringVarP(&generateOutput, "output", "o", ".github/workflows", "Output directory")
+		cmd.Flags().BoolVarP(&generateOverwrite, "overwrite", "f", false, "Overwrite existing files")
+		cmd.Flags().StringVar(&generateFormat, "output-format", outputFormatText, "Output format")
+		require.NoError(t, cmd.Flags().Set("output", outputDir))
+		require.NoError(t, cmd.Flags().Set("overwrite", "true"))
+		if plan {
+			require.NoError(t, cmd.Flags().Set("output-format", outputFormatPlan))
+		}
+		defer func() {
+			generateOutput = ".github/workflows"
+			generateOverwrite = false
+			generateFormat = outputFormatText
+		}()
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	defaultPath := filepath.Join(outputDir, "plan-test.yml")
+	stagingPath := filepath.Join(outputDir, "plan-test-staging.yml")
+
+	t.Run("new files are planned as create", func(t *testing.T) {
+		output, err := run(t, true)
+		require.NoError(t, err)
+		assert.Contains(t, output, "├── default\n│   └── create "+defaultPath)
+		assert.Contains(t, output, "└── staging\n    └── create "+stagingPath)
+		assert.Contains(t, output, "Plan: 2 to create, 0 to update, 0 unchanged")
+		assert.NoFileExists(t, defaultPath, "plan must not write files")
+	})
+
+	t.Run("changed files are planned as update", func(t *testing.T) {
+		_, err := run(t, false)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(stagingPath)
+		require.NoError(t, err)
+		edited := strings.Replace(string(content), "name: plan-test", "name: renamed", 1)
+		require.NoError(t, os.WriteFile(stagingPath, []byte(edited), 0644))
+
+		output, err := run(t, true)
+		require.NoError(t, err)
+		assert.Contains(t, output, "unchanged "+defaultPath)
+		assert.Contains(t, output, "update "+stagingPath)
+		assert.Contains(t, output, "Plan: 0 to create, 1 to update, 1 unchanged")
+
+		after, err := os.ReadFile(stagingPath)
+		require.NoError(t, err)
+		assert.Equal(t, edited, string(after), "plan must not rewrite files")
+	})
+
+	t.Run("invalid output format", func(t *testing.T) {
+		generateFormat = "table"
+		defer func() { generateFormat = outputFormatText }()
+
+		err := runGenerate(&cobra.Command{}, []string{manifestPath})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid output format")
+	})
+}
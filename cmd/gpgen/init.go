@@ -8,6 +8,10 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/config"
+	"github.com/terrpan/gpgen/pkg/manifest"
+	"github.com/terrpan/gpgen/pkg/templates"
+	"gopkg.in/yaml.v3"
 )
 
 var initCmd = &cobra.Command{
@@ -15,7 +19,9 @@ var initCmd = &cobra.Command{
 	Short: "Initialize a new GPGen manifest",
 	Long: `Initialize a new GPGen manifest file with a specified template.
 This command creates a manifest.yaml file in the current directory with
-sensible defaults for the chosen template.`,
+sensible defaults for the chosen template. Without --template, the template
+is picked from the project files in the current directory (go.mod,
+package.json, pyproject.toml, ...), falling back to node-app.`,
 	RunE: runInit,
 }
 
@@ -24,16 +30,39 @@ var (
 	initName     string
 	initOutput   string
 	initForce    bool
+	initExamples bool
+
+	initVersion        string
+	initPackageManager string
 )
 
 func init() {
-	initCmd.Flags().StringVarP(&initTemplate, "template", "t", "node-app", "Template to use (node-app, go-service, python-app)")
+	initCmd.Flags().StringVarP(&initTemplate, "template", "t", "node-app", "Template to use (node-app, go-service, python-app, rust-service, java-app); detected from project files when not set")
 	initCmd.Flags().StringVarP(&initName, "name", "n", "", "Name for the pipeline (defaults to current directory name)")
 	initCmd.Flags().StringVarP(&initOutput, "output", "o", "manifest.yaml", "Output file path")
 	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "Overwrite existing manifest file")
+	initCmd.Flags().BoolVar(&initExamples, "with-examples", false, "Include commented-out example security and container blocks")
+	initCmd.Flags().StringVar(&initVersion, "version", "", "Language version for the template (e.g. 1.24 for go-service)")
+	initCmd.Flags().StringVar(&initPackageManager, "package-manager", "", "Package manager for the template (e.g. pnpm for node-app)")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	// Pick the template from the project's files unless one was requested
+	if !cmd.Flags().Changed("template") {
+		if template, lang, ok := detectTemplate("."); ok {
+			initTemplate = template
+			fmt.Printf("🔍 Detected a %s project, using the %s template\n", lang, template)
+		}
+	}
+
+	// Pick the package manager from the project's build files unless one was requested
+	if !cmd.Flags().Changed("package-manager") {
+		if manager, detected := detectPackageManager(".", initTemplate); detected {
+			initPackageManager = string(manager)
+			fmt.Printf("🔍 Detected a %s build, using the %s package manager\n", manager, manager)
+		}
+	}
+
 	// Determine the pipeline name
 	if initName == "" {
 		cwd, err := os.Getwd()
@@ -52,8 +81,13 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	overrides, err := initInputOverrides(initTemplate, initVersion, initPackageManager)
+	if err != nil {
+		return err
+	}
+
 	// Generate manifest content based on template
-	manifestContent, err := generateManifestTemplate(initTemplate, initName)
+	manifestContent, err := generateManifestTemplate(initTemplate, initName, initExamples, overrides)
 	if err != nil {
 		return fmt.Errorf("failed to generate manifest: %w", err)
 	}
@@ -70,16 +104,77 @@ func runInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func generateManifestTemplate(template, name string) (string, error) {
+// detectTemplate returns the built-in template for the language detected in dir
+func detectTemplate(dir string) (string, config.Language, bool) {
+	lang, detected := config.DetectLanguage(dir)
+	if !detected {
+		return "", "", false
+	}
+	template, exists := config.Config.GetLanguageTemplate(lang)
+	return template, lang, exists
+}
+
+// detectPackageManager returns the package manager detected in dir for the template's
+// language, when it isn't the language's default
+func detectPackageManager(dir, template string) (config.PackageManager, bool) {
+	lang, exists := config.Config.GetTemplateLanguage(template)
+	if !exists {
+		return "", false
+	}
+	manager, detected := config.DetectPackageManager(dir, lang)
+	if !detected || manager == config.Config.Languages[lang].DefaultManager {
+		return "", false
+	}
+	return manager, true
+}
+
+// initInputOverrides validates the --version and --package-manager flags against the template
+// language's configuration and returns the inputs they set, quoted for the manifest
+func initInputOverrides(template, version, packageManager string) (map[string]string, error) {
+	if version == "" && packageManager == "" {
+		return nil, nil
+	}
+
+	lang, exists := config.Config.GetTemplateLanguage(template)
+	if !exists {
+		return nil, fmt.Errorf("unknown template: %s. Available templates: node-app, go-service, python-app, rust-service, java-app", template)
+	}
+
+	overrides := make(map[string]string)
+	if version != "" {
+		if !config.Config.IsValidVersion(lang, version) {
+			versions, _ := config.Config.GetVersionsForLanguage(lang)
+			return nil, fmt.Errorf("unsupported %s version for %s: %s. Supported versions: %v", lang, template, version, versions)
+		}
+		overrides[string(config.LanguageVersionFields[lang])] = fmt.Sprintf("%q", version)
+	}
+	if packageManager != "" {
+		managers := config.Config.GetPackageManagerOptions(lang)
+		if len(managers) == 0 {
+			return nil, fmt.Errorf("template %s does not use a package manager", template)
+		}
+		if !config.Config.IsValidPackageManager(lang, config.PackageManager(packageManager)) {
+			return nil, fmt.Errorf("unsupported %s package manager for %s: %s. Supported managers: %v", lang, template, packageManager, managers)
+		}
+		overrides[string(config.InputFieldPackageManager)] = packageManager
+	}
+	return overrides, nil
+}
+
+func generateManifestTemplate(template, name string, withExamples bool, overrides map[string]string) (string, error) {
 	switch template {
 	case "node-app":
-		return generateNodeAppManifest(name), nil
+		return generateNodeAppManifest(name, withExamples, overrides), nil
 	case "go-service":
-		return generateGoServiceManifest(name), nil
+		return generateGoServiceManifest(name, withExamples, overrides), nil
 	case "python-app":
-		return generatePythonAppManifest(name), nil
+		return generatePythonAppManifest(name, withExamples, overrides), nil
+	case "rust-service":
+		return generateRustServiceManifest(name, withExamples, overrides), nil
+	case "java-app":
+		return generateJavaAppManifest(name, withExamples, overrides), nil
 	default:
-		return "", fmt.Errorf("unknown template: %s. Available templates: node-app, go-service, python-app", template)
+		return "", fmt.Errorf("unknown template: %s. Available templates: node-app, go-service, python-app, rust-service, java-app", template)
 	}
 }
 
@@ -87,11 +182,23 @@ func generateManifestTemplate(template, name string) (string, error) {
 // description provides the metadata description annotation.
 // baseInputs contains the default input values for the template. The map values
 // should include any required quoting.
-// envInputs provides environment specific input values keyed by environment name
-// (e.g. "staging" or "production").
-func generateManifest(name, tmplName, description string, baseInputs map[string]string, envInputs map[string]map[string]string) string {
+// The environments section is scaffolded from the template's DefaultEnvironments, so the
+// per-environment inputs init writes are maintained with the template in pkg/templates.
+// withExamples adds commented-out security and container blocks to the base inputs.
+// overrides replace base inputs and drop the environments' values for the same inputs.
+func generateManifest(name, tmplName, description string, baseInputs map[string]string, withExamples bool, overrides map[string]string) string {
 	var b strings.Builder
 
+	for k, v := range overrides {
+		baseInputs[k] = v
+	}
+	environments := templateEnvironments(tmplName, strings.Trim(baseInputs[string(config.InputFieldPackageManager)], `"`))
+	for k := range overrides {
+		for _, envConfig := range environments {
+			delete(envConfig.Inputs, k)
+		}
+	}
+
 	b.WriteString("apiVersion: gpgen.dev/v1\n")
 	b.WriteString("kind: Pipeline\n")
 	b.WriteString("metadata:\n")
@@ -112,78 +219,131 @@ func generateManifest(name, tmplName, description string, baseInputs map[string]
 	for _, k := range keys {
 		b.WriteString(fmt.Sprintf("    %s: %s\n", k, baseInputs[k]))
 	}
+	if withExamples {
+		writeManifestExamples(&b, tmplName)
+	}
 
 	b.WriteString("\n  # Add custom steps here\n  customSteps: []\n\n")
 
+	if len(environments) == 0 {
+		return b.String()
+	}
+
 	b.WriteString("  # Environment-specific configurations\n  environments:\n")
-	envOrder := []string{"staging", "production"}
-	for _, env := range envOrder {
+	for _, env := range environmentOrder(environments) {
 		b.WriteString(fmt.Sprintf("    %s:\n", env))
 		b.WriteString("      annotations:\n")
 		b.WriteString("        gpgen.dev/validation-mode: strict\n")
-		inputs := envInputs[env]
-		if len(inputs) == 0 {
-			b.WriteString("      inputs: {}\n\n")
-			continue
+		if len(environments[env].Inputs) == 0 {
+			b.WriteString("      inputs: {}\n")
 		}
-		b.WriteString("      inputs:\n")
-		keys := make([]string, 0, len(inputs))
-		for k := range inputs {
-			keys = append(keys, k)
+		b.WriteString(indentYAML(environments[env], "      "))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// templateEnvironments returns a copy of a built-in template's default environments for a
+// package manager, with inputs that can be edited without touching the template's own maps
+func templateEnvironments(tmplName, packageManager string) map[string]manifest.EnvironmentConfig {
+	tmpl, err := templates.NewTemplateManager("").LoadTemplate(tmplName)
+	if err != nil {
+		return nil
+	}
+
+	environments := make(map[string]manifest.EnvironmentConfig, len(tmpl.DefaultEnvironments))
+	for env := range tmpl.DefaultEnvironments {
+		environments[env], _ = templates.DefaultEnvironment(tmpl, env, packageManager)
+	}
+	return environments
+}
+
+// environmentOrder lists staging and production first, as they are promoted in that
+// order, followed by any other environments in sorted order
+func environmentOrder(environments map[string]manifest.EnvironmentConfig) []string {
+	var order, others []string
+	for _, env := range []string{"staging", "production"} {
+		if _, exists := environments[env]; exists {
+			order = append(order, env)
 		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			b.WriteString(fmt.Sprintf("        %s: %s\n", k, inputs[k]))
+	}
+	for env := range environments {
+		if env != "staging" && env != "production" {
+			others = append(others, env)
 		}
-		b.WriteString("\n")
 	}
+	sort.Strings(others)
+	return append(order, others...)
+}
 
+// indentYAML renders value as YAML with every line indented by prefix. Empty values
+// render as nothing.
+func indentYAML(value interface{}, prefix string) string {
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil || strings.TrimSpace(buf.String()) == "{}" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		b.WriteString(prefix + line + "\n")
+	}
 	return b.String()
 }
 
-func generateNodeAppManifest(name string) string {
+// writeManifestExamples writes commented-out security and container input blocks,
+// indented to sit under spec.inputs once uncommented
+func writeManifestExamples(b *strings.Builder, tmplName string) {
+	b.WriteString("\n    # Uncomment to customize security scanning\n")
+	b.WriteString("    # security:\n")
+	b.WriteString("    #   trivy:\n")
+	b.WriteString("    #     enabled: true\n")
+	b.WriteString("    #     severity: \"CRITICAL,HIGH\"\n")
+	if tmplName == "go-service" {
+		b.WriteString("    #   gosec:\n")
+		b.WriteString("    #     enabled: true\n")
+	}
+	if tmplName == "python-app" {
+		b.WriteString("    #   bandit:\n")
+		b.WriteString("    #     enabled: true\n")
+		b.WriteString("    #     configFile: bandit.yaml\n")
+	}
+
+	b.WriteString("\n    # Uncomment to build and push a container image\n")
+	b.WriteString("    # container:\n")
+	b.WriteString("    #   enabled: true\n")
+	b.WriteString("    #   registry: ghcr.io\n")
+	b.WriteString("    #   dockerfile: Dockerfile\n")
+	b.WriteString("    #   buildContext: .\n")
+	b.WriteString("    #   push:\n")
+	b.WriteString("    #     enabled: true\n")
+	b.WriteString("    #     onProduction: true\n")
+}
+
+func generateNodeAppManifest(name string, withExamples bool, overrides map[string]string) string {
 	baseInputs := map[string]string{
 		"buildCommand":   "\"npm run build\"",
 		"nodeVersion":    "\"18\"",
 		"packageManager": "npm",
 		"testCommand":    "\"npm test\"",
 	}
-	envInputs := map[string]map[string]string{
-		"staging": {
-			"testCommand": "\"npm run test:ci\"",
-		},
-		"production": {
-			"nodeVersion": "\"20\"",
-			"testCommand": "\"npm run test:all\"",
-		},
-	}
-	return generateManifest(name, "node-app", "Node.js application pipeline", baseInputs, envInputs)
+	return generateManifest(name, "node-app", "Node.js application pipeline", baseInputs, withExamples, overrides)
 }
 
-func generateGoServiceManifest(name string) string {
+func generateGoServiceManifest(name string, withExamples bool, overrides map[string]string) string {
 	baseInputs := map[string]string{
-		"buildCommand":     fmt.Sprintf("\"go build -o bin/%s ./cmd/%s\"", name, name),
-		"goVersion":        "\"1.21\"",
-		"platforms":        "\"linux/amd64,darwin/amd64\"",
-		"testCommand":      "\"go test ./...\"",
-		"trivyScanEnabled": "true",
-		"trivySeverity":    "\"CRITICAL,HIGH\"",
-	}
-	envInputs := map[string]map[string]string{
-		"staging": {
-			"testCommand":   "\"go test -race ./...\"",
-			"trivySeverity": "\"CRITICAL,HIGH,MEDIUM\"",
-		},
-		"production": {
-			"goVersion":     "\"1.22\"",
-			"testCommand":   "\"go test -race -cover ./...\"",
-			"trivySeverity": "\"CRITICAL\"",
-		},
-	}
-	return generateManifest(name, "go-service", "Go service pipeline with security scanning", baseInputs, envInputs)
-}
-
-func generatePythonAppManifest(name string) string {
+		"buildCommand": fmt.Sprintf("\"go build -o bin/%s ./cmd/%s\"", name, name),
+		"goVersion":    "\"1.21\"",
+		"platforms":    "\"linux/amd64,darwin/amd64\"",
+		"testCommand":  "\"go test ./...\"",
+	}
+	return generateManifest(name, "go-service", "Go service pipeline with security scanning", baseInputs, withExamples, overrides)
+}
+
+func generatePythonAppManifest(name string, withExamples bool, overrides map[string]string) string {
 	baseInputs := map[string]string{
 		"lintCommand":    "\"flake8\"",
 		"packageManager": "pip",
@@ -191,14 +351,31 @@ func generatePythonAppManifest(name string) string {
 		"requirements":   "\"requirements.txt\"",
 		"testCommand":    "\"pytest\"",
 	}
-	envInputs := map[string]map[string]string{
-		"staging": {
-			"testCommand": "\"pytest --cov=. --cov-report=xml\"",
-		},
-		"production": {
-			"pythonVersion": "\"3.12\"",
-			"testCommand":   "\"pytest --cov=. --cov-report=xml --cov-fail-under=80\"",
-		},
+	return generateManifest(name, "python-app", "Python application pipeline", baseInputs, withExamples, overrides)
+}
+
+func generateRustServiceManifest(name string, withExamples bool, overrides map[string]string) string {
+	baseInputs := map[string]string{
+		"buildCommand": "\"cargo build --release\"",
+		"rustVersion":  "\"stable\"",
+		"testCommand":  "\"cargo test\"",
+	}
+	return generateManifest(name, "rust-service", "Rust service pipeline", baseInputs, withExamples, overrides)
+}
+
+func generateJavaAppManifest(name string, withExamples bool, overrides map[string]string) string {
+	// The commands follow the package manager, e.g. ./gradlew for --package-manager gradle
+	manager := config.PackageManagerMaven
+	if override, ok := overrides[string(config.InputFieldPackageManager)]; ok {
+		manager = config.PackageManager(override)
+	}
+	testCommand, buildCommand := templates.JavaCommands(manager)
+	baseInputs := map[string]string{
+		"buildCommand":     fmt.Sprintf("%q", buildCommand),
+		"javaDistribution": "temurin",
+		"javaVersion":      "\"21\"",
+		"packageManager":   string(manager),
+		"testCommand":      fmt.Sprintf("%q", testCommand),
 	}
-	return generateManifest(name, "python-app", "Python application pipeline", baseInputs, envInputs)
+	return generateManifest(name, "java-app", "Java application pipeline", baseInputs, withExamples, overrides)
 }
@@ -3,11 +3,15 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/manifest"
+	"github.com/terrpan/gpgen/pkg/templates"
 )
 
 func TestInitCommand(t *testing.T) {
@@ -94,6 +98,82 @@ func TestInitCommand(t *testing.T) {
 				assert.Contains(t, string(content), "template: python-app")
 			},
 		},
+		{
+			name: "init with rust-service template",
+			args: []string{},
+			flags: map[string]string{
+				"template": "rust-service",
+				"name":     "rust-project",
+				"output":   "manifest.yaml",
+			},
+			expectedError: false,
+			setupFunc: func(t *testing.T) string {
+				tempDir := t.TempDir()
+				return tempDir
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				manifestPath := filepath.Join(tempDir, "manifest.yaml")
+				assert.FileExists(t, manifestPath)
+
+				content, err := os.ReadFile(manifestPath)
+				require.NoError(t, err)
+
+				assert.Contains(t, string(content), "name: rust-project")
+				assert.Contains(t, string(content), "template: rust-service")
+				assert.Contains(t, string(content), "rustVersion: \"stable\"")
+			},
+		},
+		{
+			name: "init with java-app template and gradle",
+			args: []string{},
+			flags: map[string]string{
+				"template":        "java-app",
+				"name":            "java-project",
+				"output":          "manifest.yaml",
+				"version":         "17",
+				"package-manager": "gradle",
+			},
+			expectedError: false,
+			setupFunc: func(t *testing.T) string {
+				tempDir := t.TempDir()
+				return tempDir
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				manifestPath := filepath.Join(tempDir, "manifest.yaml")
+				assert.FileExists(t, manifestPath)
+
+				content, err := os.ReadFile(manifestPath)
+				require.NoError(t, err)
+
+				assert.Contains(t, string(content), "template: java-app")
+				assert.Contains(t, string(content), "javaVersion: \"17\"")
+				assert.Contains(t, string(content), "packageManager: gradle")
+				assert.Contains(t, string(content), "testCommand: \"./gradlew test\"")
+				assert.Contains(t, string(content), "buildCommand: \"./gradlew build\"")
+				assert.Contains(t, string(content), "testCommand: ./gradlew check -Pci")
+				assert.NotContains(t, string(content), "mvn")
+			},
+		},
+		{
+			name: "init detects gradle from build.gradle",
+			flags: map[string]string{
+				"name":   "gradle-project",
+				"output": "manifest.yaml",
+			},
+			expectedError: false,
+			setupFunc: func(t *testing.T) string {
+				tempDir := t.TempDir()
+				require.NoError(t, os.WriteFile(filepath.Join(tempDir, "build.gradle"), []byte("plugins { id 'java' }\n"), 0644))
+				return tempDir
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+				assert.Contains(t, string(content), "template: java-app")
+				assert.Contains(t, string(content), "packageManager: gradle")
+				assert.Contains(t, string(content), "testCommand: \"./gradlew test\"")
+			},
+		},
 		{
 			name: "init with custom output path",
 			args: []string{},
@@ -165,6 +245,61 @@ func TestInitCommand(t *testing.T) {
 				assert.NotContains(t, string(content), "existing content")
 			},
 		},
+		{
+			name: "init detects template from project files",
+			flags: map[string]string{
+				"name":   "detected-service",
+				"output": "manifest.yaml",
+			},
+			expectedError: false,
+			setupFunc: func(t *testing.T) string {
+				tempDir := t.TempDir()
+				err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module example.com/detected\n"), 0644)
+				require.NoError(t, err)
+				return tempDir
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+				assert.Contains(t, string(content), "template: go-service")
+			},
+		},
+		{
+			name: "init explicit template wins over detection",
+			flags: map[string]string{
+				"template": "node-app",
+				"name":     "frontend",
+				"output":   "manifest.yaml",
+			},
+			expectedError: false,
+			setupFunc: func(t *testing.T) string {
+				tempDir := t.TempDir()
+				err := os.WriteFile(filepath.Join(tempDir, "pyproject.toml"), []byte("[project]\n"), 0644)
+				require.NoError(t, err)
+				return tempDir
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+				assert.Contains(t, string(content), "template: node-app")
+			},
+		},
+		{
+			name: "init without project files falls back to node-app",
+			flags: map[string]string{
+				"name":   "blank",
+				"output": "manifest.yaml",
+			},
+			expectedError: false,
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+				assert.Contains(t, string(content), "template: node-app")
+			},
+		},
 		{
 			name: "init with invalid template",
 			args: []string{},
@@ -178,6 +313,164 @@ func TestInitCommand(t *testing.T) {
 				return t.TempDir()
 			},
 		},
+		{
+			name: "init without examples stays minimal",
+			flags: map[string]string{
+				"template": "go-service",
+				"name":     "minimal-service",
+				"output":   "manifest.yaml",
+			},
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+
+				assert.NotContains(t, string(content), "# container:")
+				assert.NotContains(t, string(content), "# security:")
+			},
+		},
+		{
+			name: "init with examples includes commented blocks",
+			flags: map[string]string{
+				"template": "go-service",
+				"name":     "example-service",
+				"output":   "manifest.yaml",
+			},
+			boolFlags: map[string]bool{
+				"with-examples": true,
+			},
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+
+				assert.Contains(t, string(content), "    # container:\n    #   enabled: true\n")
+				assert.Contains(t, string(content), "    # security:\n")
+				assert.Contains(t, string(content), "    #   gosec:\n")
+
+				// Uncommenting the examples must still give a valid manifest
+				uncommented := regexp.MustCompile(`(?m)^    # Uncomment.*\n`).ReplaceAllString(string(content), "")
+				uncommented = strings.ReplaceAll(uncommented, "    # ", "    ")
+				m, err := manifest.ParseManifest([]byte(uncommented))
+				require.NoError(t, err)
+				require.NoError(t, manifest.ValidateManifest(m))
+				assert.Contains(t, m.Spec.Inputs, "container")
+				assert.Contains(t, m.Spec.Inputs, "security")
+			},
+		},
+		{
+			name: "init scaffolds the template's default environments",
+			flags: map[string]string{
+				"template": "python-app",
+				"name":     "python-envs",
+				"output":   "manifest.yaml",
+			},
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+
+				m, err := manifest.ParseManifest(content)
+				require.NoError(t, err)
+				require.NoError(t, manifest.ValidateManifest(m))
+
+				tmpl, err := templates.NewTemplateManager("").LoadTemplate("python-app")
+				require.NoError(t, err)
+				require.Len(t, m.Spec.Environments, len(tmpl.DefaultEnvironments))
+				for env, inputs := range tmpl.DefaultEnvironments {
+					assert.Equal(t, inputs, m.Spec.Environments[env].Inputs, "environment %s", env)
+				}
+				assert.Less(t, strings.Index(string(content), "    staging:"), strings.Index(string(content), "    production:"))
+			},
+		},
+		{
+			name: "init with version",
+			flags: map[string]string{
+				"template": "go-service",
+				"name":     "versioned-service",
+				"output":   "manifest.yaml",
+				"version":  "1.24",
+			},
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+
+				assert.Contains(t, string(content), "    goVersion: \"1.24\"\n")
+				assert.NotContains(t, string(content), "goVersion: \"1.22\"", "environments should not pin an older version")
+
+				m, err := manifest.ParseManifest(content)
+				require.NoError(t, err)
+				require.NoError(t, manifest.ValidateManifest(m))
+			},
+		},
+		{
+			name: "init with package manager",
+			flags: map[string]string{
+				"template":        "node-app",
+				"name":            "pnpm-app",
+				"output":          "manifest.yaml",
+				"version":         "22",
+				"package-manager": "pnpm",
+			},
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+			validateFunc: func(t *testing.T, tempDir string) {
+				content, err := os.ReadFile(filepath.Join(tempDir, "manifest.yaml"))
+				require.NoError(t, err)
+
+				assert.Contains(t, string(content), "    nodeVersion: \"22\"\n")
+				assert.Contains(t, string(content), "    packageManager: pnpm\n")
+			},
+		},
+		{
+			name: "init with unsupported version",
+			flags: map[string]string{
+				"template": "go-service",
+				"name":     "old-service",
+				"output":   "manifest.yaml",
+				"version":  "1.12",
+			},
+			expectedError: true,
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+		},
+		{
+			name: "init with package manager for go-service",
+			flags: map[string]string{
+				"template":        "go-service",
+				"name":            "go-npm",
+				"output":          "manifest.yaml",
+				"package-manager": "npm",
+			},
+			expectedError: true,
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+		},
+		{
+			name: "init with unsupported package manager",
+			flags: map[string]string{
+				"template":        "python-app",
+				"name":            "conda-app",
+				"output":          "manifest.yaml",
+				"package-manager": "conda",
+			},
+			expectedError: true,
+			setupFunc: func(t *testing.T) string {
+				return t.TempDir()
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -208,6 +501,13 @@ func TestInitCommand(t *testing.T) {
 			cmd.Flags().StringVarP(&initName, "name", "n", "", "Name for the pipeline")
 			cmd.Flags().StringVarP(&initOutput, "output", "o", "manifest.yaml", "Output file path")
 			cmd.Flags().BoolVarP(&initForce, "force", "f", false, "Overwrite existing manifest file")
+			cmd.Flags().BoolVar(&initExamples, "with-examples", false, "Include commented-out examples")
+			cmd.Flags().StringVar(&initVersion, "version", "", "Language version for the template")
+			cmd.Flags().StringVar(&initPackageManager, "package-manager", "", "Package manager for the template")
+			defer func() {
+				initVersion = ""
+				initPackageManager = ""
+			}()
 
 			// Apply flag values
 			for flag, value := range tt.flags {
@@ -246,6 +546,9 @@ func TestInitCmdFlagsAndHelp(t *testing.T) {
 	assert.NotNil(t, initCmd.Flags().Lookup("name"))
 	assert.NotNil(t, initCmd.Flags().Lookup("output"))
 	assert.NotNil(t, initCmd.Flags().Lookup("force"))
+	assert.NotNil(t, initCmd.Flags().Lookup("with-examples"))
+	assert.NotNil(t, initCmd.Flags().Lookup("version"))
+	assert.NotNil(t, initCmd.Flags().Lookup("package-manager"))
 
 	// Test flag shortcuts
 	assert.NotNil(t, initCmd.Flags().ShorthandLookup("t"))
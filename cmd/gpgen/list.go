@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/templates"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List built-in templates and their inputs",
+	Long: `List the built-in templates, or the inputs a template accepts.
+Use --output json for machine-readable output.`,
+}
+
+var listTemplatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List the built-in templates",
+	Args:  cobra.NoArgs,
+	RunE:  runListTemplates,
+}
+
+var listInputsCmd = &cobra.Command{
+	Use:   "inputs <template>",
+	Short: "List the inputs a template accepts",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runListInputs,
+}
+
+var listOutput string
+
+// Output formats for list
+const (
+	listOutputText = "text"
+	listOutputJSON = "json"
+)
+
+func init() {
+	listCmd.PersistentFlags().StringVarP(&listOutput, "output", "o", listOutputText, "Output format: text or json")
+
+	listCmd.AddCommand(listTemplatesCmd)
+	listCmd.AddCommand(listInputsCmd)
+}
+
+// listedTemplate is a template as printed by list templates
+type listedTemplate struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// listedInput is a template input as printed by list inputs
+type listedInput struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Options     []string    `json:"options,omitempty"`
+}
+
+func runListTemplates(cmd *cobra.Command, args []string) error {
+	if err := validateListOutput(); err != nil {
+		return err
+	}
+
+	tm := templates.NewTemplateManager("")
+	var listed []listedTemplate
+	for _, name := range tm.ListTemplates() {
+		tmpl, err := tm.LoadTemplate(name)
+		if err != nil {
+			return fmt.Errorf("failed to load template %s: %w", name, err)
+		}
+		listed = append(listed, listedTemplate{Name: tmpl.Name, Description: tmpl.Description, Tags: tmpl.Tags})
+	}
+
+	if listOutput == listOutputJSON {
+		return printJSON(listed)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION\tTAGS")
+	for _, tmpl := range listed {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", tmpl.Name, tmpl.Description, strings.Join(tmpl.Tags, ", "))
+	}
+	return w.Flush()
+}
+
+func runListInputs(cmd *cobra.Command, args []string) error {
+	if err := validateListOutput(); err != nil {
+		return err
+	}
+
+	tmpl, err := templates.NewTemplateManager("").LoadTemplate(args[0])
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(tmpl.Inputs))
+	for name := range tmpl.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	listed := make([]listedInput, 0, len(names))
+	for _, name := range names {
+		input := tmpl.Inputs[name]
+		listed = append(listed, listedInput{
+			Name:        name,
+			Type:        string(input.Type),
+			Description: input.Description,
+			Required:    input.Required,
+			Default:     input.Default,
+			Options:     input.Options,
+		})
+	}
+
+	if listOutput == listOutputJSON {
+		return printJSON(listed)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tREQUIRED\tDEFAULT\tOPTIONS")
+	for _, input := range listed {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\n", input.Name, input.Type, input.Required, formatInputDefault(input.Default), strings.Join(input.Options, ", "))
+	}
+	return w.Flush()
+}
+
+// validateListOutput checks the --output flag
+func validateListOutput() error {
+	if listOutput != listOutputText && listOutput != listOutputJSON {
+		return fmt.Errorf("invalid output format %q, must be one of %s, %s", listOutput, listOutputText, listOutputJSON)
+	}
+	return nil
+}
+
+// printJSON writes value to stdout as indented JSON
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// formatInputDefault renders an input default for the text table. Object defaults are too
+// large for a column, so only scalars are shown.
+func formatInputDefault(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	case bool, int, int64, float64:
+		return fmt.Sprint(v)
+	default:
+		return "(object)"
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCommand(t *testing.T) {
+	defer func() { listOutput = listOutputText }()
+
+	run := func(t *testing.T, runE func(*cobra.Command, []string) error, output string, args ...string) (string, error) {
+		t.Helper()
+		listOutput = output
+
+		return captureStdout(t, func() error {
+			return runE(&cobra.Command{}, args)
+		})
+	}
+
+	t.Run("templates as text", func(t *testing.T) {
+		output, err := run(t, runListTemplates, listOutputText)
+		require.NoError(t, err)
+		assert.Contains(t, output, "NAME")
+		assert.Contains(t, output, "go-service")
+		assert.Contains(t, output, "Go service with testing, building, and cross-compilation")
+		assert.Contains(t, output, "golang")
+		assert.Contains(t, output, "node-app")
+		assert.Contains(t, output, "python-app")
+	})
+
+	t.Run("templates as json", func(t *testing.T) {
+		output, err := run(t, runListTemplates, listOutputJSON)
+		require.NoError(t, err)
+
+		var listed []listedTemplate
+		require.NoError(t, json.Unmarshal([]byte(output), &listed))
+		require.Len(t, listed, 5)
+		assert.Equal(t, "node-app", listed[0].Name)
+		assert.Contains(t, listed[0].Tags, "nodejs")
+	})
+
+	t.Run("inputs as text", func(t *testing.T) {
+		output, err := run(t, runListInputs, listOutputText, "go-service")
+		require.NoError(t, err)
+		assert.Contains(t, output, "REQUIRED")
+		assert.Regexp(t, `goVersion\s+string\s+true\s+1\.21\s+1\.21, 1\.22`, output)
+		assert.Regexp(t, `container\s+object\s+false\s+\(object\)`, output)
+	})
+
+	t.Run("inputs as json", func(t *testing.T) {
+		output, err := run(t, runListInputs, listOutputJSON, "node-app")
+		require.NoError(t, err)
+
+		var listed []listedInput
+		require.NoError(t, json.Unmarshal([]byte(output), &listed))
+
+		inputs := make(map[string]listedInput)
+		for _, input := range listed {
+			inputs[input.Name] = input
+		}
+		require.Contains(t, inputs, "packageManager")
+		assert.Equal(t, "string", inputs["packageManager"].Type)
+		assert.Equal(t, "npm", inputs["packageManager"].Default)
+		assert.Contains(t, inputs["packageManager"].Options, "pnpm")
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		_, err := run(t, runListInputs, listOutputText, "cobol-app")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid output format", func(t *testing.T) {
+		_, err := run(t, runListTemplates, "xml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid output format")
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/config"
+	"github.com/terrpan/gpgen/pkg/templates"
 )
 
 var version = "dev"
@@ -22,11 +24,96 @@ var rootCmd = &cobra.Command{
 	Long: `GPGen is a tool for generating GitHub Action workflows based on
 pre-defined templates and schemas. It enables teams to standardize their
 CI/CD pipelines while allowing customization through user-defined manifest files.`,
-	Version: version,
+	Version:           version,
+	PersistentPreRunE: loadConfigFile,
+	RunE:              runRoot,
 }
 
+// defaultConfigFile is read automatically when present in the working directory
+const defaultConfigFile = ".gpgen.yaml"
+
+var (
+	configFile    string
+	policyFile    string
+	printTemplate string
+)
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to a GPGen config file (default: .gpgen.yaml if present)")
+	rootCmd.PersistentFlags().StringVar(&policyFile, "policy", "", "Path to an organization policy file listing disallowed actions and required steps (replaces the config file's policy)")
+	rootCmd.Flags().StringVar(&printTemplate, "print-template", "", "Print the full definition of a built-in template as YAML, e.g. to fork it into a custom template")
+
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(explainCmd)
+}
+
+// runRoot prints a built-in template when --print-template is set and shows help otherwise
+func runRoot(cmd *cobra.Command, args []string) error {
+	if printTemplate == "" {
+		return cmd.Help()
+	}
+
+	content, err := templates.NewTemplateManager("").TemplateYAML(printTemplate)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(content)
+	return err
+}
+
+// loadConfigFile checks the built-in configuration and applies settings from the external
+// config file and policy file, if any
+func loadConfigFile(cmd *cobra.Command, args []string) error {
+	if err := config.Config.SelfValidate(); err != nil {
+		return fmt.Errorf("invalid built-in configuration: %w", err)
+	}
+
+	if err := applyConfigFile(); err != nil {
+		return err
+	}
+
+	if policyFile != "" {
+		policy, err := config.LoadPolicy(policyFile)
+		if err != nil {
+			return err
+		}
+		config.Config.Policy = *policy
+	}
+	return nil
+}
+
+// applyConfigFile applies settings from the external config file, if any
+func applyConfigFile() error {
+	path := configFile
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFile); os.IsNotExist(err) {
+			return nil
+		}
+		path = defaultConfigFile
+	}
+
+	fileConfig, err := config.LoadFileConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if err := templates.SetActionVersions(fileConfig.ActionVersions); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if fileConfig.DefaultJobTimeout != nil {
+		config.Config.Jobs.DefaultTimeout = *fileConfig.DefaultJobTimeout
+	}
+	if len(fileConfig.EnterpriseEnv) > 0 {
+		config.Config.Jobs.Env = fileConfig.EnterpriseEnv
+	}
+	if fileConfig.Policy != nil {
+		config.Config.Policy = *fileConfig.Policy
+	}
+	return nil
 }
@@ -2,14 +2,41 @@ package main
 
 import (
 	"bytes"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/config"
+	"github.com/terrpan/gpgen/pkg/templates"
+	"gopkg.in/yaml.v3"
 )
 
+// captureStdout runs fn with os.Stdout redirected to a pipe and returns what it printed
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	originalStdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = originalStdout }()
+
+	// Drain the pipe while fn runs so large output can't block it
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+
+	runErr := fn()
+	w.Close()
+	return string(<-output), runErr
+}
+
 func TestRootCommand(t *testing.T) {
 	// Test root command structure
 	assert.Equal(t, "gpgen", rootCmd.Use)
@@ -269,3 +296,110 @@ func TestCommandIntegration(t *testing.T) {
 	workflowPath := ".github/workflows/integration-test.yml"
 	assert.FileExists(t, workflowPath)
 }
+
+func TestLoadConfigFile(t *testing.T) {
+	original := templates.GitHubActionVersions
+	defer func() {
+		templates.GitHubActionVersions = original
+		configFile = ""
+	}()
+
+	t.Run("applies action version overrides", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("actionVersions:\n  checkout: v3\n"), 0644))
+		configFile = path
+
+		require.NoError(t, loadConfigFile(rootCmd, nil))
+		assert.Equal(t, "actions/checkout@v3", templates.GitHubActionVersions.Checkout)
+	})
+
+	t.Run("rejects unknown actions", func(t *testing.T) {
+		templates.GitHubActionVersions = original
+		path := filepath.Join(t.TempDir(), "gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("actionVersions:\n  nope: v1\n"), 0644))
+		configFile = path
+
+		err := loadConfigFile(rootCmd, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid config file")
+	})
+
+	t.Run("applies enterprise env", func(t *testing.T) {
+		originalEnv := config.Config.Jobs.Env
+		defer func() { config.Config.Jobs.Env = originalEnv }()
+
+		path := filepath.Join(t.TempDir(), "gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("enterpriseEnv:\n  HTTPS_PROXY: http://proxy.corp.example:3128\n"), 0644))
+		configFile = path
+
+		require.NoError(t, loadConfigFile(rootCmd, nil))
+		assert.Equal(t, map[string]string{"HTTPS_PROXY": "http://proxy.corp.example:3128"}, config.Config.Jobs.Env)
+	})
+
+	t.Run("applies policy from the config file and the policy flag", func(t *testing.T) {
+		originalPolicy := config.Config.Policy
+		defer func() {
+			config.Config.Policy = originalPolicy
+			policyFile = ""
+		}()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("policy:\n  requiredSteps:\n    - security-scan\n"), 0644))
+		configFile = path
+
+		require.NoError(t, loadConfigFile(rootCmd, nil))
+		assert.Equal(t, config.Policy{RequiredSteps: []string{"security-scan"}}, config.Config.Policy)
+
+		policyFile = filepath.Join(dir, "policy.yaml")
+		require.NoError(t, os.WriteFile(policyFile, []byte("disallowedActions:\n  - untrusted/*\n"), 0644))
+
+		require.NoError(t, loadConfigFile(rootCmd, nil))
+		assert.Equal(t, config.Policy{DisallowedActions: []string{"untrusted/*"}}, config.Config.Policy, "the policy flag replaces the config file's policy")
+	})
+
+	t.Run("explicit missing file errors", func(t *testing.T) {
+		configFile = filepath.Join(t.TempDir(), "missing.yaml")
+
+		err := loadConfigFile(rootCmd, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestPrintTemplate(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{
+		Use:  "gpgen",
+		RunE: runRoot,
+	}
+	cmd.Flags().StringVar(&printTemplate, "print-template", "", "Print a built-in template")
+	cmd.SetOut(buf)
+	require.NoError(t, cmd.Flags().Set("print-template", "go-service"))
+	defer func() { printTemplate = "" }()
+
+	require.NoError(t, cmd.RunE(cmd, nil))
+
+	// The dump must re-parse into a template with the built-in's steps
+	var dumped templates.Template
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &dumped))
+
+	builtin, err := templates.NewTemplateManager("").LoadTemplate("go-service")
+	require.NoError(t, err)
+
+	assert.Equal(t, "go-service", dumped.Name)
+	require.Len(t, dumped.Steps, len(builtin.Steps))
+	for i, step := range builtin.Steps {
+		assert.Equal(t, step.ID, dumped.Steps[i].ID)
+		assert.Equal(t, step.Run, dumped.Steps[i].Run)
+		assert.Equal(t, step.If, dumped.Steps[i].If)
+	}
+	assert.Contains(t, dumped.Inputs, "goVersion")
+	assert.Equal(t, builtin.Inputs["goVersion"].Default, dumped.Inputs["goVersion"].Default)
+
+	t.Run("unknown template", func(t *testing.T) {
+		require.NoError(t, cmd.Flags().Set("print-template", "kotlin-service"))
+		err := cmd.RunE(cmd, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown template")
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [manifest-file]",
+	Short: "Rewrite deprecated inputs in a GPGen manifest",
+	Long: `Rewrite deprecated flat inputs (e.g. trivyScanEnabled, containerEnabled) to their
+nested replacements (security.trivy.enabled, container.enabled) in place.
+Comments and key order in the manifest are preserved.
+If no file is specified, it will look for manifest.yaml in the current directory.`,
+	RunE: runMigrate,
+}
+
+var migrateDryRun bool
+
+func init() {
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Print the migrated manifest instead of writing it")
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	manifestPath := "manifest.yaml"
+	if len(args) > 0 {
+		manifestPath = args[0]
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("manifest file not found: %s", manifestPath)
+		}
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	editor, err := manifest.NewEditor(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse manifest %s: %w", manifestPath, err)
+	}
+
+	changes, err := manifest.MigrateLegacyInputs(editor)
+	if err != nil {
+		return fmt.Errorf("failed to migrate manifest %s: %w", manifestPath, err)
+	}
+	if len(changes) == 0 {
+		fmt.Printf("✅ %s uses no deprecated inputs\n", manifestPath)
+		return nil
+	}
+
+	migrated, err := editor.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if migrateDryRun {
+		fmt.Print(string(migrated))
+		return nil
+	}
+
+	if err := os.WriteFile(manifestPath, migrated, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	fmt.Printf("✅ Migrated %s\n", manifestPath)
+	for _, change := range changes {
+		fmt.Printf("  - %s\n", change)
+	}
+	return nil
+}
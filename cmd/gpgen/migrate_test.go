@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateCommand(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: migrate-test
+spec:
+  template: node-app
+  inputs:
+    # Block merges on critical findings
+    trivyScanEnabled: true
+    containerRegistry: ghcr.io # internal mirror
+`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	run := func(t *testing.T) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "migrate [manifest-file]",
+			RunE: runMigrate,
+		}
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	output, err := run(t)
+	require.NoError(t, err)
+	assert.Contains(t, output, "spec.inputs.trivyScanEnabled -> spec.inputs.security.trivy.enabled")
+	assert.Contains(t, output, "spec.inputs.containerRegistry -> spec.inputs.container.registry")
+
+	migrated, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(migrated), "# Block merges on critical findings")
+	assert.Contains(t, string(migrated), "registry: ghcr.io # internal mirror")
+	assert.NotContains(t, string(migrated), "trivyScanEnabled")
+
+	_, err = validateManifestFile(manifestPath)
+	assert.NoError(t, err)
+
+	output, err = run(t)
+	require.NoError(t, err)
+	assert.Contains(t, output, "uses no deprecated inputs")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ANSI escape sequences used for decorated output
+const (
+	ansiReset  = "\033[0m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// printer centralizes CLI status output so emoji and colors can be disabled for logs and pipes
+type printer struct {
+	out      io.Writer
+	decorate bool
+}
+
+// newPrinter creates a printer for stdout, decorating output only when stdout is a terminal
+// unless overridden by noColor or forceColor
+func newPrinter(noColor, forceColor bool) *printer {
+	return &printer{
+		out:      os.Stdout,
+		decorate: shouldDecorate(os.Stdout, noColor, forceColor),
+	}
+}
+
+// shouldDecorate determines whether output to f should use emoji and ANSI colors
+func shouldDecorate(f *os.File, noColor, forceColor bool) bool {
+	if forceColor {
+		return true
+	}
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal reports whether f is attached to a character device (TTY)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// status prints a line prefixed with emoji when decorated
+func (p *printer) status(emoji, format string, args ...interface{}) {
+	p.line(emoji, "", format, args...)
+}
+
+// success prints a green line prefixed with a check mark when decorated
+func (p *printer) success(format string, args ...interface{}) {
+	p.line("✅", ansiGreen, format, args...)
+}
+
+// warning prints a yellow line prefixed with a warning sign when decorated
+func (p *printer) warning(format string, args ...interface{}) {
+	p.line("⚠️ ", ansiYellow, format, args...)
+}
+
+// plain prints an undecorated line
+func (p *printer) plain(format string, args ...interface{}) {
+	fmt.Fprintf(p.out, format+"\n", args...)
+}
+
+// line prints a single line with optional emoji prefix and color
+func (p *printer) line(emoji, color, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if !p.decorate {
+		fmt.Fprintln(p.out, message)
+		return
+	}
+
+	if emoji != "" {
+		message = emoji + " " + message
+	}
+	if color != "" {
+		message = color + message + ansiReset
+	}
+	fmt.Fprintln(p.out, message)
+}
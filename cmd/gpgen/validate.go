@@ -1,33 +1,52 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/terrpan/gpgen/pkg/manifest"
 	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/config"
+	"github.com/terrpan/gpgen/pkg/generator"
+	"github.com/terrpan/gpgen/pkg/manifest"
 )
 
 var validateCmd = &cobra.Command{
 	Use:   "validate [manifest-file]",
 	Short: "Validate a GPGen manifest file",
 	Long: `Validate a GPGen manifest file against the schema and check for errors.
-If no file is specified, it will look for manifest.yaml in the current directory.`,
+If no file is specified, it will look for manifest.yaml in the current directory.
+Use --glob to validate every manifest matching a pattern, e.g. in a mono-repo.`,
 	RunE: runValidate,
 }
 
 var (
-	validateStrict bool
-	validateQuiet  bool
+	validateStrict        bool
+	validateQuiet         bool
+	validateExplain       bool
+	validateGlob          string
+	validateCheckActions  bool
+	validateActionTimeout time.Duration
 )
 
 func init() {
 	validateCmd.Flags().BoolVarP(&validateStrict, "strict", "s", false, "Use strict validation mode")
 	validateCmd.Flags().BoolVarP(&validateQuiet, "quiet", "q", false, "Only output errors, no success messages")
+	validateCmd.Flags().BoolVar(&validateExplain, "explain", false, "Show resolved triggers, permissions, job settings and features for each environment")
+	validateCmd.Flags().StringVar(&validateGlob, "glob", "", "Validate every manifest matching a glob pattern (supports **, e.g. 'services/**/manifest.yaml')")
+	validateCmd.Flags().BoolVar(&validateCheckActions, "check-actions", false, "Check on GitHub that every action the workflows use exists (skipped when GitHub is unreachable; set GITHUB_TOKEN to raise the rate limit)")
+	validateCmd.Flags().DurationVar(&validateActionTimeout, "check-actions-timeout", 30*time.Second, "Time allowed for --check-actions before it is skipped")
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
+	if validateGlob != "" {
+		return runValidateGlob(validateGlob)
+	}
+
 	// Determine manifest file path
 	manifestPath := "manifest.yaml"
 	if len(args) > 0 {
@@ -49,25 +68,11 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		fmt.Printf("🔍 Validating manifest: %s\n", absPath)
 	}
 
-	// Load and validate the manifest
-	m, err := manifest.LoadManifestFromFile(absPath)
+	m, err := validateManifestFile(absPath)
 	if err != nil {
 		return fmt.Errorf("❌ Validation failed: %w", err)
 	}
 
-	// Apply strict validation if requested
-	if validateStrict {
-		if m.Metadata.Annotations == nil {
-			m.Metadata.Annotations = make(map[string]string)
-		}
-		m.Metadata.Annotations["gpgen.dev/validation-mode"] = "strict"
-	}
-
-	// Validate the manifest
-	if err := manifest.ValidateManifest(m); err != nil {
-		return fmt.Errorf("❌ Validation failed: %w", err)
-	}
-
 	if !validateQuiet {
 		fmt.Printf("✅ Manifest is valid\n")
 		fmt.Printf("📋 Template: %s\n", m.Spec.Template)
@@ -99,5 +104,327 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if validateExplain {
+		if err := explainManifest(m); err != nil {
+			return fmt.Errorf("❌ Explain failed: %w", err)
+		}
+	}
+
 	return nil
 }
+
+// validateManifestFile loads and validates a single manifest, printing non-fatal warnings
+func validateManifestFile(absPath string) (*manifest.Manifest, error) {
+	// Load and validate the manifest
+	m, err := manifest.LoadManifestFromFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply strict validation if requested
+	if validateStrict {
+		if m.Metadata == nil {
+			m.Metadata = &manifest.ManifestMetadata{}
+		}
+		if m.Metadata.Annotations == nil {
+			m.Metadata.Annotations = make(map[string]string)
+		}
+		m.Metadata.Annotations["gpgen.dev/validation-mode"] = "strict"
+	}
+
+	// Validate the manifest
+	if err := manifest.ValidateManifest(m); err != nil {
+		return nil, err
+	}
+
+	// Check that every custom step's position target will exist at generation time
+	if err := generator.NewWorkflowGenerator("").ValidateCustomStepTargets(m); err != nil {
+		return nil, err
+	}
+
+	// Check the workflows against the organization policy, if any
+	if err := checkPolicy(generator.NewWorkflowGenerator(""), m, workflowEnvironments(m, "")); err != nil {
+		return nil, err
+	}
+
+	// Check that local files referenced by inputs exist (error in strict mode, warning otherwise)
+	for _, fileErr := range manifest.CheckRequirementsFiles(m, filepath.Dir(absPath)) {
+		if manifest.GetValidationMode(m) == manifest.ValidationModeStrict {
+			return nil, fileErr
+		}
+		fmt.Printf("⚠️  Warning: %v\n", fileErr)
+	}
+	for _, warning := range manifest.CheckDeploymentEnvironments(m) {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+	for _, warning := range manifest.CheckScriptStrictMode(m, false) {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+
+	if validateCheckActions {
+		missing, err := checkActions(m, validateActionTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check actions: %w", err)
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("actions not found on GitHub: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	return m, nil
+}
+
+// runValidateGlob validates every manifest matching pattern, reporting each file and
+// failing if any manifest is invalid
+func runValidateGlob(pattern string) error {
+	paths, err := globFiles(pattern)
+	if errors.Is(err, filepath.ErrBadPattern) {
+		return fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to match %s: %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no manifest files match: %s", pattern)
+	}
+
+	failed := 0
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+
+		if _, err := validateManifestFile(absPath); err != nil {
+			failed++
+			fmt.Printf("❌ %s: %v\n", path, err)
+			continue
+		}
+
+		if !validateQuiet {
+			fmt.Printf("✅ %s\n", path)
+		}
+	}
+
+	if !validateQuiet || failed > 0 {
+		fmt.Printf("\n📊 Validated %d manifest(s): %d passed, %d failed\n", len(paths), len(paths)-failed, failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("❌ Validation failed for %d of %d manifest(s)", failed, len(paths))
+	}
+	return nil
+}
+
+// globFiles returns the files matching pattern in sorted order. In addition to the
+// filepath.Match syntax, a "**" path segment matches any number of directories.
+func globFiles(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		return matches, nil
+	}
+
+	// Walk from the longest directory prefix without wildcards
+	segments := strings.Split(pattern, "/")
+	root := "."
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			if i > 0 {
+				root = strings.Join(segments[:i], "/")
+				if root == "" {
+					root = "/"
+				}
+			}
+			break
+		}
+	}
+
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		matched, err := matchGlobSegments(segments, strings.Split(filepath.ToSlash(path), "/"))
+		if err != nil {
+			return err
+		}
+		if matched {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchGlobSegments matches path segments against pattern segments, where "**" matches
+// zero or more segments
+func matchGlobSegments(pattern, path []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(path) == 0, nil
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			matched, err := matchGlobSegments(pattern[1:], path[i:])
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+
+	if len(path) == 0 {
+		return false, nil
+	}
+
+	matched, err := filepath.Match(pattern[0], path[0])
+	if err != nil || !matched {
+		return false, err
+	}
+	return matchGlobSegments(pattern[1:], path[1:])
+}
+
+// checkPolicy checks the workflows generated for environments against the configured
+// organization policy, reporting every violation at once
+func checkPolicy(gen *generator.WorkflowGenerator, m *manifest.Manifest, environments []string) error {
+	policy := config.Config.Policy
+	if len(policy.DisallowedActions) == 0 && len(policy.RequiredSteps) == 0 {
+		return nil
+	}
+
+	var violations []string
+	for _, env := range environments {
+		envViolations, err := gen.CheckPolicy(m, env, policy)
+		if err != nil {
+			return fmt.Errorf("failed to check policy for environment %s: %w", env, err)
+		}
+		for _, violation := range envViolations {
+			violations = append(violations, fmt.Sprintf("environment %s: %s", env, violation))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("manifest violates policy:\n  - %s", strings.Join(violations, "\n  - "))
+	}
+	return nil
+}
+
+// explainManifest prints the resolved triggers, permissions, job settings and features for every
+// environment
+func explainManifest(m *manifest.Manifest) error {
+	gen := generator.NewWorkflowGenerator("")
+
+	for _, env := range workflowEnvironments(m, "") {
+		explanation, err := gen.ExplainWorkflow(m, env)
+		if err != nil {
+			return fmt.Errorf("failed to explain environment %s: %w", env, err)
+		}
+
+		fmt.Printf("\n🔎 Environment: %s\n", env)
+
+		fmt.Printf("   Triggers:\n")
+		for _, name := range sortedKeys(explanation.Triggers) {
+			fmt.Printf("     %s: %s\n", name, formatTrigger(explanation.Triggers[name]))
+		}
+
+		fmt.Printf("   Permissions:\n")
+		if explanation.PermissionsShorthand != "" {
+			fmt.Printf("     %s\n", explanation.PermissionsShorthand)
+		} else if len(explanation.Permissions) == 0 {
+			fmt.Printf("     (none)\n")
+		}
+		for _, scope := range sortedKeys(explanation.Permissions) {
+			fmt.Printf("     %s: %s\n", scope, explanation.Permissions[scope])
+		}
+		for _, warning := range explanation.PermissionWarnings {
+			fmt.Printf("   ⚠️  %s\n", warning)
+		}
+
+		fmt.Printf("   Concurrency: %s\n", formatConcurrency(explanation.Concurrency))
+		fmt.Printf("   Timeout: %s\n", formatTimeout(explanation.TimeoutMinutes))
+		fmt.Printf("   Security scanning: %s\n", enabledString(explanation.SecurityEnabled))
+		fmt.Printf("   Container build: %s\n", enabledString(explanation.ContainerEnabled))
+		fmt.Printf("   Container push: %s\n", enabledString(explanation.ContainerPush))
+	}
+
+	return nil
+}
+
+// formatTrigger renders a trigger configuration as a compact, deterministic string
+func formatTrigger(value interface{}) string {
+	if schedules, ok := value.([]map[string]string); ok {
+		crons := make([]string, 0, len(schedules))
+		for _, schedule := range schedules {
+			crons = append(crons, schedule["cron"])
+		}
+		return fmt.Sprintf("cron [%s]", strings.Join(crons, ", "))
+	}
+
+	config, ok := value.(map[string]interface{})
+	if !ok || len(config) == 0 {
+		return "{}"
+	}
+
+	parts := make([]string, 0, len(config))
+	for _, key := range sortedKeys(config) {
+		switch v := config[key].(type) {
+		case []string:
+			parts = append(parts, fmt.Sprintf("%s [%s]", key, strings.Join(v, ", ")))
+		default:
+			parts = append(parts, fmt.Sprintf("%s %v", key, v))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sortedKeys returns the keys of a string-keyed map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatConcurrency renders a resolved concurrency block for human-readable output
+func formatConcurrency(concurrency *generator.Concurrency) string {
+	if concurrency == nil {
+		return "none"
+	}
+	return fmt.Sprintf("group %s, cancel-in-progress %t", concurrency.Group, concurrency.CancelInProgress)
+}
+
+// formatTimeout renders a job timeout for human-readable output
+func formatTimeout(minutes int) string {
+	if minutes == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// enabledString renders a feature flag for human-readable output
+func enabledString(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
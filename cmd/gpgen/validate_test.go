@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/config"
 )
 
 func TestValidateCommand(t *testing.T) {
@@ -48,6 +54,32 @@ spec:
 				assert.NoError(t, err)
 			},
 		},
+		{
+			name:          "validate manifest with unreachable custom step",
+			args:          []string{},
+			expectedError: true,
+			setupFunc: func(t *testing.T) string {
+				tempDir := t.TempDir()
+				manifestPath := filepath.Join(tempDir, "manifest.yaml")
+				unreachableManifest := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: unreachable-step
+spec:
+  template: node-app
+  customSteps:
+    - name: Notify
+      position: after:deploy
+      run: echo done`
+				err := os.WriteFile(manifestPath, []byte(unreachableManifest), 0644)
+				require.NoError(t, err)
+				return tempDir
+			},
+			validateFunc: func(t *testing.T, tempDir string, err error) {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "target step not found: deploy")
+			},
+		},
 		{
 			name:          "validate with custom manifest path",
 			args:          []string{"custom-manifest.yaml"},
@@ -255,6 +287,8 @@ func TestValidateCmdFlagsAndHelp(t *testing.T) {
 	// Test that all expected flags are present
 	assert.NotNil(t, validateCmd.Flags().Lookup("strict"))
 	assert.NotNil(t, validateCmd.Flags().Lookup("quiet"))
+	assert.NotNil(t, validateCmd.Flags().Lookup("explain"))
+	assert.NotNil(t, validateCmd.Flags().Lookup("glob"))
 
 	// Test flag shortcuts
 	assert.NotNil(t, validateCmd.Flags().ShorthandLookup("s"))
@@ -303,3 +337,489 @@ spec:
 	err = cmd.RunE(cmd, []string{})
 	assert.NoError(t, err)
 }
+
+func TestValidateExplain(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	explainManifest := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: explain-test
+spec:
+  template: go-service
+  inputs:
+    goVersion: "1.21"
+  environments:
+    production:
+      inputs:
+        container:
+          enabled: true`
+	err := os.WriteFile(manifestPath, []byte(explainManifest), 0644)
+	require.NoError(t, err)
+
+	cmd := &cobra.Command{
+		Use:  "validate [manifest-file]",
+		RunE: runValidate,
+	}
+	cmd.Flags().BoolVarP(&validateStrict, "strict", "s", false, "Use strict validation mode")
+	cmd.Flags().BoolVarP(&validateQuiet, "quiet", "q", false, "Only output errors")
+	cmd.Flags().BoolVar(&validateExplain, "explain", false, "Show resolved triggers and permissions")
+	require.NoError(t, cmd.Flags().Set("explain", "true"))
+	defer func() { validateExplain = false }()
+
+	output, err := captureStdout(t, func() error {
+		return cmd.RunE(cmd, []string{manifestPath})
+	})
+
+	require.NoError(t, err)
+
+	// Default environment uses branch triggers and no container permissions
+	defaultSection, productionSection, found := strings.Cut(output, "Environment: production")
+	require.True(t, found, "explain output should include the production environment")
+	assert.Contains(t, defaultSection, "Environment: default")
+	assert.Contains(t, defaultSection, "branches [main, develop]")
+	assert.NotContains(t, defaultSection, "packages: write")
+
+	// Production environment uses tag triggers and container permissions
+	assert.Contains(t, productionSection, "push: tags [v*]")
+	assert.Contains(t, productionSection, "release: types [published]")
+	assert.Contains(t, productionSection, "packages: write")
+	assert.Contains(t, productionSection, "security-events: write")
+	assert.Contains(t, productionSection, "Container build: enabled")
+}
+
+func TestValidateExplainJobSettings(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: explain-jobs
+spec:
+  template: node-app
+  timeoutMinutes: 45
+  concurrency:
+    group: deploy-${{ github.ref }}
+  environments:
+    production: {}`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	cmd := &cobra.Command{
+		Use:  "validate [manifest-file]",
+		RunE: runValidate,
+	}
+	cmd.Flags().BoolVarP(&validateStrict, "strict", "s", false, "Use strict validation mode")
+	cmd.Flags().BoolVarP(&validateQuiet, "quiet", "q", false, "Only output errors")
+	cmd.Flags().BoolVar(&validateExplain, "explain", false, "Show resolved triggers and permissions")
+	require.NoError(t, cmd.Flags().Set("explain", "true"))
+	defer func() { validateExplain = false }()
+
+	output, err := captureStdout(t, func() error {
+		return cmd.RunE(cmd, []string{manifestPath})
+	})
+
+	require.NoError(t, err)
+
+	defaultSection, productionSection, found := strings.Cut(output, "Environment: production")
+	require.True(t, found, "explain output should include the production environment")
+	assert.Contains(t, defaultSection, "Concurrency: group deploy-${{ github.ref }}, cancel-in-progress true")
+	assert.Contains(t, defaultSection, "Timeout: 45 minutes")
+	assert.Contains(t, productionSection, "Concurrency: group deploy-${{ github.ref }}, cancel-in-progress false")
+	assert.Contains(t, productionSection, "Timeout: 45 minutes")
+}
+
+func TestValidateRequirementsFile(t *testing.T) {
+	runValidateCapture := func(t *testing.T, manifestPath string) (string, error) {
+		t.Helper()
+
+		cmd := &cobra.Command{
+			Use:  "validate [manifest-file]",
+			RunE: runValidate,
+		}
+		cmd.Flags().BoolVarP(&validateStrict, "strict", "s", false, "Use strict validation mode")
+		cmd.Flags().BoolVarP(&validateQuiet, "quiet", "q", false, "Only output errors")
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	writeManifest := func(t *testing.T, dir, mode string) string {
+		t.Helper()
+		manifestPath := filepath.Join(dir, "manifest.yaml")
+		content := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: python-test
+  annotations:
+    gpgen.dev/validation-mode: ` + mode + `
+spec:
+  template: python-app
+  inputs:
+    requirements: "requirements.txt"`
+		require.NoError(t, os.WriteFile(manifestPath, []byte(content), 0644))
+		return manifestPath
+	}
+
+	t.Run("missing file warns in relaxed mode", func(t *testing.T) {
+		output, err := runValidateCapture(t, writeManifest(t, t.TempDir(), "relaxed"))
+		require.NoError(t, err)
+		assert.Contains(t, output, "Warning: requirements file not found: requirements.txt")
+	})
+
+	t.Run("missing file fails in strict mode", func(t *testing.T) {
+		_, err := runValidateCapture(t, writeManifest(t, t.TempDir(), "strict"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requirements file not found")
+	})
+
+	t.Run("present file is ok", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("pytest\n"), 0644))
+
+		output, err := runValidateCapture(t, writeManifest(t, dir, "strict"))
+		require.NoError(t, err)
+		assert.NotContains(t, output, "Warning")
+	})
+}
+
+func TestValidateGlob(t *testing.T) {
+	tempDir := t.TempDir()
+
+	goodManifest := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: good-service
+spec:
+  template: go-service`
+	badManifest := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: bad-service
+spec:
+  template: unknown-template`
+
+	goodPath := filepath.Join(tempDir, "services", "good", "manifest.yaml")
+	badPath := filepath.Join(tempDir, "services", "nested", "bad", "manifest.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(goodPath), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(badPath), 0755))
+	require.NoError(t, os.WriteFile(goodPath, []byte(goodManifest), 0644))
+	require.NoError(t, os.WriteFile(badPath, []byte(badManifest), 0644))
+
+	cmd := &cobra.Command{
+		Use:  "validate [manifest-file]",
+		RunE: runValidate,
+	}
+	cmd.Flags().BoolVarP(&validateStrict, "strict", "s", false, "Use strict validation mode")
+	cmd.Flags().BoolVarP(&validateQuiet, "quiet", "q", false, "Only output errors")
+	cmd.Flags().StringVar(&validateGlob, "glob", "", "Validate every manifest matching a glob pattern")
+	require.NoError(t, cmd.Flags().Set("glob", filepath.Join(tempDir, "services", "**", "manifest.yaml")))
+	defer func() { validateGlob = "" }()
+
+	output, err := captureStdout(t, func() error {
+		return cmd.RunE(cmd, []string{})
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Validation failed for 1 of 2 manifest(s)")
+	assert.Contains(t, output, "✅ "+goodPath)
+	assert.Contains(t, output, "❌ "+badPath)
+	assert.Contains(t, output, "invalid template")
+	assert.Contains(t, output, "2 manifest(s): 1 passed, 1 failed")
+}
+
+func TestGlobFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	manifestPath := filepath.Join(tempDir, "services", "api", "manifest.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(manifestPath), 0755))
+	require.NoError(t, os.WriteFile(manifestPath, []byte("kind: Pipeline"), 0644))
+	t.Chdir(tempDir)
+
+	t.Run("leading ./ is ignored", func(t *testing.T) {
+		matches, err := globFiles("./services/**/manifest.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join("services", "api", "manifest.yaml")}, matches)
+	})
+
+	t.Run("missing root reports the stat error", func(t *testing.T) {
+		_, err := globFiles("missing/**/manifest.yaml")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.NotErrorIs(t, err, filepath.ErrBadPattern)
+	})
+
+	t.Run("malformed pattern is a bad pattern", func(t *testing.T) {
+		_, err := globFiles("services/**/[manifest.yaml")
+		assert.ErrorIs(t, err, filepath.ErrBadPattern)
+	})
+}
+
+func TestMatchGlobSegments(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"services/**/manifest.yaml", "services/manifest.yaml", true},
+		{"services/**/manifest.yaml", "services/api/manifest.yaml", true},
+		{"services/**/manifest.yaml", "services/api/v2/manifest.yaml", true},
+		{"services/**/manifest.yaml", "other/api/manifest.yaml", false},
+		{"services/*/manifest.yaml", "services/api/v2/manifest.yaml", false},
+		{"**/*.yaml", "a/b/c.yaml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			matched, err := matchGlobSegments(strings.Split(tt.pattern, "/"), strings.Split(tt.path, "/"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestValidateExplainPermissionLint(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: broad-permissions
+spec:
+  template: go-service
+  permissions: write-all`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	cmd := &cobra.Command{
+		Use:  "validate [manifest-file]",
+		RunE: runValidate,
+	}
+	cmd.Flags().BoolVar(&validateExplain, "explain", false, "Show resolved triggers and permissions")
+	require.NoError(t, cmd.Flags().Set("explain", "true"))
+	defer func() { validateExplain = false }()
+
+	output, err := captureStdout(t, func() error {
+		return cmd.RunE(cmd, []string{manifestPath})
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, output, "     write-all")
+	assert.Contains(t, output, "⚠️  permissions: write-all grants write access to every scope")
+	assert.Contains(t, output, "contents: read, security-events: write")
+}
+
+func TestValidateDeploymentEnvironmentWarning(t *testing.T) {
+	validate := func(t *testing.T, manifestContent string) string {
+		t.Helper()
+		manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+		require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+		cmd := &cobra.Command{
+			Use:  "validate [manifest-file]",
+			RunE: runValidate,
+		}
+
+		out, err := captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("production without environment warns", func(t *testing.T) {
+		output := validate(t, `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: ungated-deploy
+spec:
+  template: go-service
+  environments:
+    production:
+      inputs:
+        goVersion: "1.22"`)
+
+		assert.Contains(t, output, "⚠️  Warning: environment production has no deployment environment")
+	})
+
+	t.Run("production with environment does not warn", func(t *testing.T) {
+		output := validate(t, `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: gated-deploy
+spec:
+  template: go-service
+  environments:
+    production:
+      environment:
+        name: production
+        url: https://example.com`)
+
+		assert.NotContains(t, output, "has no deployment environment")
+	})
+}
+
+// fakeGitHub answers GitHub API requests with the status code registered for their path
+type fakeGitHub struct {
+	statuses map[string]int
+	err      error
+	requests []string
+}
+
+func (f *fakeGitHub) Do(req *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, req.URL.Path)
+	if f.err != nil {
+		return nil, f.err
+	}
+	status, ok := f.statuses[req.URL.Path]
+	if !ok {
+		status = http.StatusOK
+	}
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func TestValidateCheckActions(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: checked-actions
+spec:
+  template: node-app
+  customSteps:
+    - name: Lint PR title
+      position: after:test
+      uses: acme/no-such-action@v1`), 0644))
+
+	validate := func(t *testing.T, client httpDoer) (string, error) {
+		t.Helper()
+		originalClient := actionsHTTPClient
+		actionsHTTPClient = client
+		defer func() { actionsHTTPClient = originalClient }()
+
+		cmd := &cobra.Command{
+			Use:  "validate [manifest-file]",
+			RunE: runValidate,
+		}
+		cmd.Flags().BoolVar(&validateCheckActions, "check-actions", false, "Check that actions exist")
+		cmd.Flags().DurationVar(&validateActionTimeout, "check-actions-timeout", 30*time.Second, "Timeout")
+		require.NoError(t, cmd.Flags().Set("check-actions", "true"))
+		defer func() { validateCheckActions = false }()
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	t.Run("nonexistent action is flagged and known ones pass", func(t *testing.T) {
+		github := &fakeGitHub{statuses: map[string]int{
+			"/repos/acme/no-such-action/commits/v1": http.StatusNotFound,
+		}}
+		_, err := validate(t, github)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "actions not found on GitHub: acme/no-such-action@v1")
+		assert.NotContains(t, err.Error(), "actions/checkout")
+		assert.Contains(t, github.requests, "/repos/actions/checkout/commits/v4")
+		assert.Contains(t, github.requests, "/repos/actions/setup-node/commits/v4")
+	})
+
+	t.Run("a ref the repository doesn't have is flagged", func(t *testing.T) {
+		github := &fakeGitHub{statuses: map[string]int{
+			"/repos/actions/checkout/commits/v4":    http.StatusUnprocessableEntity,
+			"/repos/acme/no-such-action/commits/v1": http.StatusOK,
+		}}
+		_, err := validate(t, github)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "actions not found on GitHub: actions/checkout@v4")
+	})
+
+	t.Run("unreachable GitHub skips the check", func(t *testing.T) {
+		output, err := validate(t, &fakeGitHub{err: errors.New("dial tcp: no route to host")})
+
+		require.NoError(t, err)
+		assert.Contains(t, output, "⚠️  Warning: skipping action checks, GitHub is unreachable: dial tcp: no route to host")
+	})
+
+	t.Run("rate limiting skips the check", func(t *testing.T) {
+		output, err := validate(t, &fakeGitHub{statuses: map[string]int{
+			"/repos/acme/no-such-action/commits/v1": http.StatusForbidden,
+		}})
+
+		require.NoError(t, err)
+		assert.Contains(t, output, "skipping action checks, GitHub is unreachable: Forbidden")
+	})
+}
+
+func TestValidatePolicy(t *testing.T) {
+	tempDir := t.TempDir()
+
+	policyPath := filepath.Join(tempDir, "policy.yaml")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`disallowedActions:
+  - untrusted/*
+requiredSteps:
+  - security-scan
+`), 0644))
+
+	originalPolicy := config.Config.Policy
+	defer func() {
+		config.Config.Policy = originalPolicy
+		policyFile = ""
+	}()
+	policyFile = policyPath
+	require.NoError(t, loadConfigFile(rootCmd, nil))
+
+	validate := func(t *testing.T, manifestContent string) error {
+		t.Helper()
+		manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+		require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+		cmd := &cobra.Command{
+			Use:  "validate [manifest-file]",
+			RunE: runValidate,
+		}
+
+		_, err := captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+		return err
+	}
+
+	t.Run("banned action fails policy", func(t *testing.T) {
+		err := validate(t, `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: banned-action
+spec:
+  template: node-app
+  environments:
+    production:
+      customSteps:
+        - name: Deploy
+          position: after:test
+          uses: untrusted/deploy@v1`)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "manifest violates policy")
+		assert.Contains(t, err.Error(), `environment production: step "Deploy" (customSteps) in job build uses disallowed action untrusted/deploy@v1`)
+		assert.NotContains(t, err.Error(), "environment default")
+	})
+
+	t.Run("compliant manifest passes", func(t *testing.T) {
+		err := validate(t, `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: compliant
+spec:
+  template: node-app
+  customSteps:
+    - name: Lint
+      position: after:test
+      uses: acme/lint@v1`)
+
+		assert.NoError(t, err)
+	})
+}
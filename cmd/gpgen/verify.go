@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [manifest-file]",
+	Short: "Lint generated workflows with actionlint",
+	Long: `Generate workflows from a GPGen manifest in memory and check them with actionlint,
+catching GitHub Actions errors (e.g. broken expressions) that gpgen doesn't model.
+Findings are mapped back to the step, and custom step, they come from.
+If actionlint is not installed, verification is skipped.`,
+	RunE: runVerify,
+}
+
+var (
+	verifyEnv        string
+	verifyActionlint string
+	verifyNoColor    bool
+	verifyForceColor bool
+	verifyOptions    generatorOptions
+)
+
+func init() {
+	verifyCmd.Flags().StringVarP(&verifyEnv, "environment", "e", "", "Verify a specific environment (default: all environments)")
+	verifyCmd.Flags().StringVar(&verifyActionlint, "actionlint", "actionlint", "Name or path of the actionlint binary")
+	verifyCmd.Flags().BoolVar(&verifyNoColor, "no-color", false, "Disable emoji and colored output")
+	verifyCmd.Flags().BoolVar(&verifyForceColor, "force-color", false, "Force emoji and colored output even when stdout is not a terminal")
+	verifyOptions.addFlags(verifyCmd)
+}
+
+// lintFinding is a single problem reported by actionlint
+type lintFinding struct {
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Kind    string `json:"kind"`
+}
+
+// errLinterNotFound is returned by a workflowLinter when the linter isn't installed
+var errLinterNotFound = errors.New("linter not found")
+
+// workflowLinter lints the content of a workflow file
+type workflowLinter func(binary, fileName, content string) ([]lintFinding, error)
+
+// lintWorkflow is the linter used by verify, replaceable in tests
+var lintWorkflow workflowLinter = runActionlint
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	out := newPrinter(verifyNoColor, verifyForceColor)
+
+	// Determine manifest file path
+	manifestPath := "manifest.yaml"
+	if len(args) > 0 {
+		manifestPath = args[0]
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		return fmt.Errorf("manifest file not found: %s", manifestPath)
+	}
+
+	m, err := manifest.LoadManifestFromFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if err := manifest.ValidateManifest(m); err != nil {
+		return fmt.Errorf("manifest validation failed: %w", err)
+	}
+
+	gen := verifyOptions.newGenerator()
+	findings := 0
+	for _, env := range workflowEnvironments(m, verifyEnv) {
+		fileName := workflowFileName(m, env, layoutFlat)
+
+		workflowContent, err := gen.GenerateWorkflow(m, env)
+		if err != nil {
+			return fmt.Errorf("failed to generate workflow for %s: %w", env, err)
+		}
+
+		envFindings, err := lintWorkflow(verifyActionlint, fileName, workflowContent)
+		if errors.Is(err, errLinterNotFound) {
+			out.warning("actionlint not found (%s), skipping verification. Install it from https://github.com/rhysd/actionlint", verifyActionlint)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lint workflow for %s: %w", env, err)
+		}
+
+		if len(envFindings) == 0 {
+			out.success("No findings: %s", fileName)
+			continue
+		}
+		for _, finding := range envFindings {
+			out.warning("%s:%d:%d: %s [%s]%s", fileName, finding.Line, finding.Column, finding.Message, finding.Kind, findingSource(m, workflowContent, finding.Line))
+		}
+		findings += len(envFindings)
+	}
+
+	if findings > 0 {
+		return fmt.Errorf("actionlint reported %d finding(s)", findings)
+	}
+	return nil
+}
+
+// runActionlint lints content with the actionlint binary, reading the workflow from stdin
+func runActionlint(binary, fileName, content string) ([]lintFinding, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, errLinterNotFound
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, "-format", "{{json .}}", "-stdin-filename", filepath.Join(".github", "workflows", fileName), "-")
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// actionlint exits with 1 when it reports findings
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, fmt.Errorf("actionlint failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	var findings []lintFinding
+	if err := json.Unmarshal(stdout.Bytes(), &findings); err != nil {
+		return nil, fmt.Errorf("failed to parse actionlint output: %w", err)
+	}
+	return findings, nil
+}
+
+// findingSource describes the step a finding on line of a generated workflow belongs to,
+// noting when it comes from one of the manifest's custom steps
+func findingSource(m *manifest.Manifest, content string, line int) string {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	for i := line - 1; i >= 0; i-- {
+		name, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "- name: ")
+		if !ok {
+			continue
+		}
+		name = strings.Trim(name, `"'`)
+		if isCustomStep(m, name) {
+			return fmt.Sprintf(" in step %q (customSteps)", name)
+		}
+		return fmt.Sprintf(" in step %q", name)
+	}
+	return ""
+}
+
+// isCustomStep reports whether name is the name of a manifest custom step
+func isCustomStep(m *manifest.Manifest, name string) bool {
+	for _, step := range m.Spec.CustomSteps {
+		if step.EffectiveName() == name {
+			return true
+		}
+	}
+	for _, envConfig := range m.Spec.Environments {
+		for _, step := range envConfig.CustomSteps {
+			if step.EffectiveName() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
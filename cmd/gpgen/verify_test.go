@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCommand(t *testing.T) {
+	tempDir := t.TempDir()
+
+	manifestPath := filepath.Join(tempDir, "manifest.yaml")
+	manifestContent := `apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: verify-test
+spec:
+  template: node-app
+  customSteps:
+    - name: notify
+      position: after:test
+      run: echo done
+      if: ${{ github.event.pull_request.number( }}`
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestContent), 0644))
+
+	// fakeActionlint reports every line containing a call on a context property
+	fakeActionlint := func(binary, fileName, content string) ([]lintFinding, error) {
+		var findings []lintFinding
+		for i, line := range strings.Split(content, "\n") {
+			if column := strings.Index(line, "number("); column >= 0 {
+				findings = append(findings, lintFinding{
+					Message: "unexpected token \"(\" while parsing expression",
+					Line:    i + 1,
+					Column:  column + 1,
+					Kind:    "expression",
+				})
+			}
+		}
+		return findings, nil
+	}
+
+	run := func(t *testing.T, linter workflowLinter) (string, error) {
+		t.Helper()
+
+		originalLinter := lintWorkflow
+		lintWorkflow = linter
+		defer func() { lintWorkflow = originalLinter }()
+
+		cmd := &cobra.Command{
+			Use:  "verify [manifest-file]",
+			RunE: runVerify,
+		}
+
+		return captureStdout(t, func() error {
+			return cmd.RunE(cmd, []string{manifestPath})
+		})
+	}
+
+	t.Run("bad expression is reported against its custom step", func(t *testing.T) {
+		output, err := run(t, fakeActionlint)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "actionlint reported 1 finding(s)")
+		assert.Contains(t, output, "verify-test.yml:")
+		assert.Contains(t, output, "while parsing expression [expression]")
+		assert.Contains(t, output, `in step "notify" (customSteps)`)
+	})
+
+	t.Run("clean workflow passes", func(t *testing.T) {
+		output, err := run(t, func(binary, fileName, content string) ([]lintFinding, error) {
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Contains(t, output, "No findings: verify-test.yml")
+	})
+
+	t.Run("missing actionlint is skipped", func(t *testing.T) {
+		output, err := run(t, func(binary, fileName, content string) ([]lintFinding, error) {
+			return nil, errLinterNotFound
+		})
+		require.NoError(t, err)
+		assert.Contains(t, output, "actionlint not found")
+	})
+}
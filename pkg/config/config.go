@@ -1,6 +1,16 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 // InputField represents a template input field name (type-safe alternative to string constants)
 type InputField string
@@ -9,6 +19,8 @@ const (
 	InputFieldGoVersion      InputField = "goVersion"
 	InputFieldNodeVersion    InputField = "nodeVersion"
 	InputFieldPythonVersion  InputField = "pythonVersion"
+	InputFieldRustVersion    InputField = "rustVersion"
+	InputFieldJavaVersion    InputField = "javaVersion"
 	InputFieldPackageManager InputField = "packageManager"
 	InputFieldTestCommand    InputField = "testCommand"
 	InputFieldBuildCommand   InputField = "buildCommand"
@@ -21,6 +33,17 @@ var LanguageInputFields = map[Language][]InputField{
 	LanguageGo:     {InputFieldGoVersion, InputFieldTestCommand, InputFieldBuildCommand},
 	LanguageNode:   {InputFieldNodeVersion, InputFieldPackageManager, InputFieldTestCommand, InputFieldBuildCommand},
 	LanguagePython: {InputFieldPythonVersion, InputFieldPackageManager, InputFieldTestCommand, InputFieldLintCommand, InputFieldRequirements},
+	LanguageRust:   {InputFieldRustVersion, InputFieldTestCommand, InputFieldBuildCommand},
+	LanguageJava:   {InputFieldJavaVersion, InputFieldPackageManager, InputFieldTestCommand, InputFieldBuildCommand},
+}
+
+// LanguageVersionFields maps languages to the input field selecting their version
+var LanguageVersionFields = map[Language]InputField{
+	LanguageGo:     InputFieldGoVersion,
+	LanguageNode:   InputFieldNodeVersion,
+	LanguagePython: InputFieldPythonVersion,
+	LanguageRust:   InputFieldRustVersion,
+	LanguageJava:   InputFieldJavaVersion,
 }
 
 // Language represents a supported programming language
@@ -30,8 +53,19 @@ const (
 	LanguageGo     Language = "go"
 	LanguageNode   Language = "node"
 	LanguagePython Language = "python"
+	LanguageRust   Language = "rust"
+	LanguageJava   Language = "java"
 )
 
+// TemplateLanguages maps built-in template names to the language they build
+var TemplateLanguages = map[string]Language{
+	"node-app":     LanguageNode,
+	"go-service":   LanguageGo,
+	"python-app":   LanguagePython,
+	"rust-service": LanguageRust,
+	"java-app":     LanguageJava,
+}
+
 // PackageManager represents a supported package manager
 type PackageManager string
 
@@ -42,6 +76,8 @@ const (
 	PackageManagerPip    PackageManager = "pip"
 	PackageManagerPoetry PackageManager = "poetry"
 	PackageManagerPipenv PackageManager = "pipenv"
+	PackageManagerMaven  PackageManager = "maven"
+	PackageManagerGradle PackageManager = "gradle"
 )
 
 // SecuritySeverity represents Trivy security severity levels
@@ -56,6 +92,15 @@ const (
 	SeverityCriticalHighMedium SecuritySeverity = "CRITICAL,HIGH,MEDIUM"
 )
 
+// SecurityScanner represents a security scanner gpgen can add to a workflow
+type SecurityScanner string
+
+const (
+	ScannerTrivy  SecurityScanner = "trivy"
+	ScannerGosec  SecurityScanner = "gosec"
+	ScannerBandit SecurityScanner = "bandit"
+)
+
 // LanguageConfig defines configuration for a specific programming language
 type LanguageConfig struct {
 	Versions        []string
@@ -66,18 +111,102 @@ type LanguageConfig struct {
 	DefaultBuildCmd string
 	DefaultLintCmd  string
 	DefaultReqFile  string
+
+	// Default step timeouts in minutes (0 means no timeout)
+	DefaultTestTimeout  int
+	DefaultBuildTimeout int
+
+	// Security scanners enabled by default for the language's templates
+	DefaultScanners []SecurityScanner
+
+	// Runner label jobs run on when the manifest doesn't set runsOn (empty means FallbackRunner)
+	DefaultRunner string
+}
+
+// FallbackRunner is the runner label used for languages without a default runner
+const FallbackRunner = "ubuntu-latest"
+
+// ActionNames are the logical names of the actions built-in templates use, the keys of
+// actionVersions in configuration files and manifests
+var ActionNames = []string{
+	"checkout", "setupNode", "setupGo", "setupPython", "setupJava", "rustToolchain",
+	"dockerSetupQemu", "dockerSetupBuildx", "dockerLogin", "dockerBuildPush",
+	"codeqlUploadSarif", "trivyAction", "gosec", "uploadArtifact", "slackGithubAction",
+}
+
+// ActionAliases are the short names actionVersions also accepts for an action name
+var ActionAliases = map[string]string{
+	"trivy":  "trivyAction",
+	"slack":  "slackGithubAction",
+	"codeql": "codeqlUploadSarif",
+	"qemu":   "dockerSetupQemu",
+	"buildx": "dockerSetupBuildx",
+	"rust":   "rustToolchain",
+}
+
+// ResolveActionNames re-keys actionVersions entries by action name, resolving aliases. It
+// rejects unknown keys, listing the accepted ones, and an action pinned under two keys.
+func ResolveActionNames(actionVersions map[string]string) (map[string]string, error) {
+	keys := make([]string, 0, len(actionVersions))
+	for key := range actionVersions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resolved := make(map[string]string, len(actionVersions))
+	pinnedBy := make(map[string]string, len(actionVersions))
+	for _, key := range keys {
+		name := key
+		if alias, ok := ActionAliases[key]; ok {
+			name = alias
+		}
+		if !slices.Contains(ActionNames, name) {
+			return nil, fmt.Errorf("unknown action %q in actionVersions, must be one of %s", key, strings.Join(actionKeys(), ", "))
+		}
+		if other, ok := pinnedBy[name]; ok {
+			return nil, fmt.Errorf("action %s is pinned twice in actionVersions, as %q and %q", name, other, key)
+		}
+		pinnedBy[name] = key
+		resolved[name] = actionVersions[key]
+	}
+	return resolved, nil
+}
+
+// actionKeys returns the accepted actionVersions keys: the action names, then the aliases
+func actionKeys() []string {
+	aliases := make([]string, 0, len(ActionAliases))
+	for alias := range ActionAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return append(slices.Clone(ActionNames), aliases...)
 }
 
 // Configuration holds all typed configuration values
 type Configuration struct {
 	Languages map[Language]LanguageConfig
 	Security  SecurityConfig
+	Jobs      JobConfig
+
+	// Policy is the organization policy manifests must comply with; empty by default
+	Policy Policy
+}
+
+// JobConfig holds job-level defaults applied when a manifest does not set them
+type JobConfig struct {
+	// Default job timeouts in minutes
+	DefaultTimeout    int
+	ProductionTimeout int
+
+	// Env is added to every generated job, e.g. proxy and mirror settings for enterprise runners
+	Env map[string]string
 }
 
 // SecurityConfig holds security-related configuration
 type SecurityConfig struct {
 	SeverityLevels []SecuritySeverity
 	DefaultLevel   SecuritySeverity
+	DefaultTimeout int
 }
 
 // Config is the global configuration instance
@@ -89,6 +218,12 @@ var Config = Configuration{
 			DefaultVersion:  "1.21",
 			DefaultTestCmd:  "go test ./...",
 			DefaultBuildCmd: "go build -o bin/service ./cmd/service",
+
+			DefaultTestTimeout:  15,
+			DefaultBuildTimeout: 10,
+
+			DefaultScanners: []SecurityScanner{ScannerTrivy, ScannerGosec},
+			DefaultRunner:   "ubuntu-latest",
 		},
 		LanguageNode: {
 			Versions:        []string{"16", "18", "20", "22"},
@@ -97,6 +232,12 @@ var Config = Configuration{
 			DefaultManager:  PackageManagerNpm,
 			DefaultTestCmd:  "npm test",
 			DefaultBuildCmd: "npm run build",
+
+			DefaultTestTimeout:  15,
+			DefaultBuildTimeout: 10,
+
+			DefaultScanners: []SecurityScanner{ScannerTrivy},
+			DefaultRunner:   "ubuntu-latest",
 		},
 		LanguagePython: {
 			Versions:        []string{"3.9", "3.10", "3.11", "3.12"},
@@ -106,6 +247,38 @@ var Config = Configuration{
 			DefaultTestCmd:  "pytest",
 			DefaultLintCmd:  "flake8",
 			DefaultReqFile:  "requirements.txt",
+
+			DefaultTestTimeout: 20,
+
+			DefaultScanners: []SecurityScanner{ScannerTrivy, ScannerBandit},
+			DefaultRunner:   "ubuntu-latest",
+		},
+		LanguageRust: {
+			Versions:        []string{"stable", "beta", "nightly"},
+			PackageManagers: []PackageManager{}, // Cargo is the only package manager
+			DefaultVersion:  "stable",
+			DefaultTestCmd:  "cargo test",
+			DefaultBuildCmd: "cargo build --release",
+
+			DefaultTestTimeout:  20,
+			DefaultBuildTimeout: 20,
+
+			DefaultScanners: []SecurityScanner{ScannerTrivy},
+			DefaultRunner:   "ubuntu-latest",
+		},
+		LanguageJava: {
+			Versions:        []string{"11", "17", "21"},
+			PackageManagers: []PackageManager{PackageManagerMaven, PackageManagerGradle},
+			DefaultVersion:  "21",
+			DefaultManager:  PackageManagerMaven,
+			DefaultTestCmd:  "mvn test",
+			DefaultBuildCmd: "mvn package",
+
+			DefaultTestTimeout:  20,
+			DefaultBuildTimeout: 20,
+
+			DefaultScanners: []SecurityScanner{ScannerTrivy},
+			DefaultRunner:   "ubuntu-latest",
 		},
 	},
 	Security: SecurityConfig{
@@ -117,7 +290,12 @@ var Config = Configuration{
 			SeverityCriticalHigh,
 			SeverityCriticalHighMedium,
 		},
-		DefaultLevel: SeverityCriticalHigh,
+		DefaultLevel:   SeverityCriticalHigh,
+		DefaultTimeout: 10,
+	},
+	Jobs: JobConfig{
+		DefaultTimeout:    30,
+		ProductionTimeout: 60,
 	},
 }
 
@@ -181,6 +359,29 @@ func (c *Configuration) IsValidPackageManager(lang Language, manager PackageMana
 	return false
 }
 
+// GetDefaultRunner returns the runner label jobs for the given language run on by default
+func (c *Configuration) GetDefaultRunner(lang Language) string {
+	if config, exists := c.Languages[lang]; exists && config.DefaultRunner != "" {
+		return config.DefaultRunner
+	}
+	return FallbackRunner
+}
+
+// HasDefaultScanner reports whether a scanner is enabled by default for the given language
+func (c *Configuration) HasDefaultScanner(lang Language, scanner SecurityScanner) bool {
+	config, exists := c.Languages[lang]
+	if !exists {
+		return false
+	}
+
+	for _, s := range config.DefaultScanners {
+		if s == scanner {
+			return true
+		}
+	}
+	return false
+}
+
 // IsValidSecuritySeverity checks if a security severity level is valid
 func (c *Configuration) IsValidSecuritySeverity(severity SecuritySeverity) bool {
 	for _, s := range c.Security.SeverityLevels {
@@ -225,16 +426,10 @@ func (c *Configuration) GetDefaults(lang Language) map[string]interface{} {
 
 // getVersionField returns the appropriate version field for a language
 func getVersionField(lang Language) InputField {
-	switch lang {
-	case LanguageGo:
-		return InputFieldGoVersion
-	case LanguageNode:
-		return InputFieldNodeVersion
-	case LanguagePython:
-		return InputFieldPythonVersion
-	default:
-		return InputFieldGoVersion // fallback
+	if field, exists := LanguageVersionFields[lang]; exists {
+		return field
 	}
+	return InputFieldGoVersion // fallback
 }
 
 // GetPackageManagerOptions returns package manager options as strings for a language
@@ -288,6 +483,14 @@ func (c *Configuration) GetDefaultValue(inputField InputField, lang Language) (i
 		if lang == LanguagePython {
 			return config.DefaultVersion, nil
 		}
+	case InputFieldRustVersion:
+		if lang == LanguageRust {
+			return config.DefaultVersion, nil
+		}
+	case InputFieldJavaVersion:
+		if lang == LanguageJava {
+			return config.DefaultVersion, nil
+		}
 	case InputFieldPackageManager:
 		if config.DefaultManager != "" {
 			return string(config.DefaultManager), nil
@@ -328,7 +531,7 @@ func (c *Configuration) ValidateTemplateInput(inputField InputField, value inter
 
 	// Validate the input value based on field type
 	switch inputField {
-	case InputFieldNodeVersion, InputFieldGoVersion, InputFieldPythonVersion:
+	case InputFieldNodeVersion, InputFieldGoVersion, InputFieldPythonVersion, InputFieldRustVersion, InputFieldJavaVersion:
 		if strVal, ok := value.(string); ok {
 			if strVal == "" {
 				return fmt.Errorf("%s version cannot be empty", lang)
@@ -383,6 +586,10 @@ func (c *Configuration) ValidateTemplateInputLegacy(inputName string, value inte
 		inputField = InputFieldGoVersion
 	case "pythonVersion":
 		inputField = InputFieldPythonVersion
+	case "rustVersion":
+		inputField = InputFieldRustVersion
+	case "javaVersion":
+		inputField = InputFieldJavaVersion
 	case "packageManager":
 		inputField = InputFieldPackageManager
 	case "testCommand":
@@ -429,6 +636,147 @@ func (c *Configuration) ValidateAllInputs(inputs map[InputField]interface{}, lan
 	return nil
 }
 
+// SelfValidate checks the hand-maintained configuration for mistakes that would silently
+// break input validation: languages without versions, duplicate versions or package
+// managers, and defaults missing from their lists
+func (c *Configuration) SelfValidate() error {
+	languages := make([]string, 0, len(c.Languages))
+	for lang := range c.Languages {
+		languages = append(languages, string(lang))
+	}
+	sort.Strings(languages)
+
+	for _, name := range languages {
+		lang := c.Languages[Language(name)]
+
+		if len(lang.Versions) == 0 {
+			return fmt.Errorf("language %s has no versions", name)
+		}
+		if duplicate, found := findDuplicate(lang.Versions); found {
+			return fmt.Errorf("language %s lists version %s more than once", name, duplicate)
+		}
+		if !c.IsValidVersion(Language(name), lang.DefaultVersion) {
+			return fmt.Errorf("language %s default version %q is not one of its versions %v", name, lang.DefaultVersion, lang.Versions)
+		}
+
+		managers := c.GetPackageManagerOptions(Language(name))
+		if duplicate, found := findDuplicate(managers); found {
+			return fmt.Errorf("language %s lists package manager %s more than once", name, duplicate)
+		}
+		if len(managers) > 0 && !c.IsValidPackageManager(Language(name), lang.DefaultManager) {
+			return fmt.Errorf("language %s default package manager %q is not one of its package managers %v", name, lang.DefaultManager, managers)
+		}
+		if len(managers) == 0 && lang.DefaultManager != "" {
+			return fmt.Errorf("language %s sets default package manager %q but supports none", name, lang.DefaultManager)
+		}
+	}
+
+	if !c.IsValidSecuritySeverity(c.Security.DefaultLevel) {
+		return fmt.Errorf("default security severity %q is not one of %v", c.Security.DefaultLevel, c.GetSecuritySeverityOptions())
+	}
+
+	return nil
+}
+
+// findDuplicate returns the first value that appears more than once in values
+func findDuplicate(values []string) (string, bool) {
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if seen[value] {
+			return value, true
+		}
+		seen[value] = true
+	}
+	return "", false
+}
+
+// GetTemplateLanguage returns the language a built-in template builds
+func (c *Configuration) GetTemplateLanguage(templateName string) (Language, bool) {
+	lang, exists := TemplateLanguages[templateName]
+	return lang, exists
+}
+
+// GetLanguageTemplate returns the built-in template that builds a language
+func (c *Configuration) GetLanguageTemplate(lang Language) (string, bool) {
+	for templateName, templateLang := range TemplateLanguages {
+		if templateLang == lang {
+			return templateName, true
+		}
+	}
+	return "", false
+}
+
+// languageMarkers lists the files that identify a project's language, in detection order
+var languageMarkers = []struct {
+	file string
+	lang Language
+}{
+	{"go.mod", LanguageGo},
+	{"package.json", LanguageNode},
+	{"pyproject.toml", LanguagePython},
+	{"requirements.txt", LanguagePython},
+	{"Cargo.toml", LanguageRust},
+	{"pom.xml", LanguageJava},
+	{"build.gradle", LanguageJava},
+	{"build.gradle.kts", LanguageJava},
+}
+
+// DetectLanguage guesses a project's language from the marker files in dir (go.mod,
+// package.json, pyproject.toml, ...). The first marker found wins.
+func DetectLanguage(dir string) (Language, bool) {
+	for _, marker := range languageMarkers {
+		if info, err := os.Stat(filepath.Join(dir, marker.file)); err == nil && !info.IsDir() {
+			return marker.lang, true
+		}
+	}
+	return "", false
+}
+
+// packageManagerMarkers lists the files that identify a project's package manager, for
+// languages whose build files differ by manager, in detection order
+var packageManagerMarkers = []struct {
+	file    string
+	lang    Language
+	manager PackageManager
+}{
+	{"build.gradle", LanguageJava, PackageManagerGradle},
+	{"build.gradle.kts", LanguageJava, PackageManagerGradle},
+	{"pom.xml", LanguageJava, PackageManagerMaven},
+}
+
+// DetectPackageManager guesses the package manager of a project in lang from the build files
+// in dir (e.g. build.gradle for Gradle). The first marker found wins.
+func DetectPackageManager(dir string, lang Language) (PackageManager, bool) {
+	for _, marker := range packageManagerMarkers {
+		if marker.lang != lang {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, marker.file)); err == nil && !info.IsDir() {
+			return marker.manager, true
+		}
+	}
+	return "", false
+}
+
+// ValidateManifestInputs runs the typed language validation over a template's manifest inputs.
+// Language defaults fill in fields the manifest leaves unset, and inputs that aren't
+// language fields (container, security, ...) are ignored.
+func (c *Configuration) ValidateManifestInputs(templateName string, inputs map[string]interface{}) error {
+	lang, exists := c.GetTemplateLanguage(templateName)
+	if !exists {
+		return fmt.Errorf("unknown template: %s", templateName)
+	}
+
+	typedInputs := c.GetAllDefaults(lang)
+	for _, field := range c.GetValidInputFields(lang) {
+		if value, exists := inputs[string(field)]; exists {
+			typedInputs[field] = value
+		}
+	}
+
+	return c.ValidateAllInputs(typedInputs, lang)
+}
+
 // getRequiredFields returns the required input fields for a language
 func (c *Configuration) getRequiredFields(lang Language) []InputField {
 	switch lang {
@@ -438,6 +786,10 @@ func (c *Configuration) getRequiredFields(lang Language) []InputField {
 		return []InputField{InputFieldNodeVersion, InputFieldPackageManager}
 	case LanguagePython:
 		return []InputField{InputFieldPythonVersion, InputFieldPackageManager}
+	case LanguageRust:
+		return []InputField{InputFieldRustVersion}
+	case LanguageJava:
+		return []InputField{InputFieldJavaVersion, InputFieldPackageManager}
 	default:
 		return []InputField{}
 	}
@@ -456,6 +808,10 @@ func (c *Configuration) ValidateAllInputsLegacy(inputs map[string]interface{}, l
 			typedInputs[InputFieldGoVersion] = value
 		case "pythonVersion":
 			typedInputs[InputFieldPythonVersion] = value
+		case "rustVersion":
+			typedInputs[InputFieldRustVersion] = value
+		case "javaVersion":
+			typedInputs[InputFieldJavaVersion] = value
 		case "packageManager":
 			typedInputs[InputFieldPackageManager] = value
 		case "testCommand":
@@ -491,6 +847,16 @@ func (c *Configuration) GetPythonVersions() []string {
 	return c.Languages[LanguagePython].Versions
 }
 
+// GetRustVersions returns all supported Rust toolchains
+func (c *Configuration) GetRustVersions() []string {
+	return c.Languages[LanguageRust].Versions
+}
+
+// GetJavaVersions returns all supported Java versions
+func (c *Configuration) GetJavaVersions() []string {
+	return c.Languages[LanguageJava].Versions
+}
+
 // GetVersionsForLanguage returns all supported versions for a given language
 func (c *Configuration) GetVersionsForLanguage(lang Language) ([]string, error) {
 	config, exists := c.Languages[lang]
@@ -551,6 +917,16 @@ func (td *TypedDefaults) GetPythonVersion() string {
 	return td.config.Languages[LanguagePython].DefaultVersion
 }
 
+// GetRustVersion returns the default Rust toolchain
+func (td *TypedDefaults) GetRustVersion() string {
+	return td.config.Languages[LanguageRust].DefaultVersion
+}
+
+// GetJavaVersion returns the default Java version
+func (td *TypedDefaults) GetJavaVersion() string {
+	return td.config.Languages[LanguageJava].DefaultVersion
+}
+
 // GetDefaultPackageManager returns the default package manager for a language
 func (td *TypedDefaults) GetDefaultPackageManager(lang Language) (PackageManager, error) {
 	if config, exists := td.config.Languages[lang]; exists {
@@ -644,3 +1020,120 @@ func (td *TypedDefaults) GetAllSecuritySeverities() []SecuritySeverity {
 
 // Defaults is the global typed defaults instance (replaces legacy DefaultValues)
 var Defaults = NewTypedDefaults()
+
+// FileConfig holds settings loaded from an external GPGen configuration file
+type FileConfig struct {
+	// ActionVersions overrides built-in action references, keyed by action name (e.g. "checkout")
+	ActionVersions map[string]string `yaml:"actionVersions"`
+
+	// DefaultJobTimeout overrides the job timeout in minutes applied when a manifest sets none
+	DefaultJobTimeout *int `yaml:"defaultJobTimeout"`
+
+	// EnterpriseEnv is set on every generated job so setup steps download through
+	// enterprise proxies and mirrors (e.g. HTTPS_PROXY, NO_PROXY, ACTIONS_RUNNER_HOOK_JOB_STARTED)
+	EnterpriseEnv map[string]string `yaml:"enterpriseEnv"`
+
+	// Policy is the organization policy manifests must comply with
+	Policy *Policy `yaml:"policy"`
+}
+
+// envNameRegex matches valid environment variable names
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadFileConfig reads and parses an external configuration file
+func LoadFileConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var fileConfig FileConfig
+	if err := yaml.Unmarshal(data, &fileConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if t := fileConfig.DefaultJobTimeout; t != nil && (*t < 1 || *t > 360) {
+		return nil, fmt.Errorf("invalid config file %s: defaultJobTimeout must be between 1 and 360", path)
+	}
+
+	for name := range fileConfig.EnterpriseEnv {
+		if !envNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid config file %s: invalid enterpriseEnv variable name: %s", path, name)
+		}
+	}
+
+	if fileConfig.Policy != nil {
+		if err := fileConfig.Policy.validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	return &fileConfig, nil
+}
+
+// Policy is an organization policy, e.g. maintained by a platform team, that every manifest's
+// generated workflows must comply with
+type Policy struct {
+	// DisallowedActions lists actions no step may use: owner/repo[/path] bans every ref,
+	// owner/repo[/path]@ref bans one ref, and owner/* bans every action of an owner
+	DisallowedActions []string `yaml:"disallowedActions"`
+
+	// RequiredSteps lists the IDs of steps, e.g. security-scan, that every generated build
+	// job must keep and that must not be disabled
+	RequiredSteps []string `yaml:"requiredSteps"`
+}
+
+// LoadPolicy reads and parses a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+// validate checks that every policy entry is well formed
+func (p *Policy) validate() error {
+	for _, action := range p.DisallowedActions {
+		if action == "" || strings.ContainsAny(action, " \t") || !strings.Contains(action, "/") {
+			return fmt.Errorf("invalid disallowedActions entry %q, must be 'owner/repo', 'owner/repo@ref' or 'owner/*'", action)
+		}
+	}
+	for _, step := range p.RequiredSteps {
+		if step == "" {
+			return fmt.Errorf("requiredSteps entries must not be empty")
+		}
+	}
+	return nil
+}
+
+// DisallowedAction returns the DisallowedActions entry that bans uses, if any
+func (p *Policy) DisallowedAction(uses string) (string, bool) {
+	action, _, _ := strings.Cut(uses, "@")
+	for _, banned := range p.DisallowedActions {
+		switch {
+		case strings.HasSuffix(banned, "/*"):
+			if strings.HasPrefix(action, strings.TrimSuffix(banned, "*")) {
+				return banned, true
+			}
+		case strings.Contains(banned, "@"):
+			if uses == banned {
+				return banned, true
+			}
+		default:
+			if action == banned || strings.HasPrefix(action, banned+"/") {
+				return banned, true
+			}
+		}
+	}
+	return "", false
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,6 +30,16 @@ func TestLanguageConstants(t *testing.T) {
 			language: LanguagePython,
 			expected: "python",
 		},
+		{
+			name:     "Rust language constant",
+			language: LanguageRust,
+			expected: "rust",
+		},
+		{
+			name:     "Java language constant",
+			language: LanguageJava,
+			expected: "java",
+		},
 	}
 
 	for _, tt := range tests {
@@ -153,6 +165,16 @@ func TestConfiguration_GetLanguageConfig(t *testing.T) {
 			language:     LanguagePython,
 			expectExists: true,
 		},
+		{
+			name:         "get Rust config",
+			language:     LanguageRust,
+			expectExists: true,
+		},
+		{
+			name:         "get Java config",
+			language:     LanguageJava,
+			expectExists: true,
+		},
 		{
 			name:         "get unknown language",
 			language:     Language("unknown"),
@@ -173,6 +195,42 @@ func TestConfiguration_GetLanguageConfig(t *testing.T) {
 	}
 }
 
+func TestConfiguration_DefaultScanners(t *testing.T) {
+	goConfig, _ := Config.GetLanguageConfig(LanguageGo)
+	assert.Equal(t, []SecurityScanner{ScannerTrivy, ScannerGosec}, goConfig.DefaultScanners)
+
+	pythonConfig, _ := Config.GetLanguageConfig(LanguagePython)
+	assert.Equal(t, []SecurityScanner{ScannerTrivy, ScannerBandit}, pythonConfig.DefaultScanners)
+
+	assert.True(t, Config.HasDefaultScanner(LanguageGo, ScannerGosec))
+	assert.False(t, Config.HasDefaultScanner(LanguageGo, ScannerBandit))
+	assert.True(t, Config.HasDefaultScanner(LanguagePython, ScannerBandit))
+	assert.False(t, Config.HasDefaultScanner(LanguagePython, ScannerGosec))
+	assert.True(t, Config.HasDefaultScanner(LanguageNode, ScannerTrivy))
+	assert.True(t, Config.HasDefaultScanner(LanguageRust, ScannerTrivy))
+	assert.False(t, Config.HasDefaultScanner(LanguageRust, ScannerGosec))
+	assert.False(t, Config.HasDefaultScanner(Language("unknown"), ScannerTrivy))
+}
+
+func TestConfiguration_GetDefaultRunner(t *testing.T) {
+	assert.Equal(t, "ubuntu-latest", Config.GetDefaultRunner(LanguageGo))
+	assert.Equal(t, "ubuntu-latest", Config.GetDefaultRunner(LanguageNode))
+	assert.Equal(t, "ubuntu-latest", Config.GetDefaultRunner(LanguagePython))
+	assert.Equal(t, "ubuntu-latest", Config.GetDefaultRunner(LanguageRust))
+	assert.True(t, Config.HasDefaultScanner(LanguageJava, ScannerTrivy))
+	assert.False(t, Config.HasDefaultScanner(LanguageJava, ScannerBandit))
+	assert.Equal(t, FallbackRunner, Config.GetDefaultRunner(Language("unknown")))
+
+	custom := Configuration{
+		Languages: map[Language]LanguageConfig{
+			Language("swift"): {Versions: []string{"5.10"}, DefaultVersion: "5.10", DefaultRunner: "macos-latest"},
+			LanguageGo:        {Versions: []string{"1.24"}, DefaultVersion: "1.24"},
+		},
+	}
+	assert.Equal(t, "macos-latest", custom.GetDefaultRunner(Language("swift")))
+	assert.Equal(t, FallbackRunner, custom.GetDefaultRunner(LanguageGo), "languages without a runner fall back")
+}
+
 func TestConfiguration_IsValidVersion(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -204,6 +262,30 @@ func TestConfiguration_IsValidVersion(t *testing.T) {
 			version:  "14",
 			expected: false,
 		},
+		{
+			name:     "valid Rust toolchain",
+			language: LanguageRust,
+			version:  "nightly",
+			expected: true,
+		},
+		{
+			name:     "invalid Rust toolchain",
+			language: LanguageRust,
+			version:  "1.0",
+			expected: false,
+		},
+		{
+			name:     "valid Java version",
+			language: LanguageJava,
+			version:  "17",
+			expected: true,
+		},
+		{
+			name:     "invalid Java version",
+			language: LanguageJava,
+			version:  "8",
+			expected: false,
+		},
 		{
 			name:     "unknown language",
 			language: Language("unknown"),
@@ -526,6 +608,25 @@ func TestConfiguration_GetValidInputFields(t *testing.T) {
 				InputFieldBuildCommand,
 			},
 		},
+		{
+			name:     "Rust input fields",
+			language: LanguageRust,
+			expectFields: []InputField{
+				InputFieldRustVersion,
+				InputFieldTestCommand,
+				InputFieldBuildCommand,
+			},
+		},
+		{
+			name:     "Java input fields",
+			language: LanguageJava,
+			expectFields: []InputField{
+				InputFieldJavaVersion,
+				InputFieldPackageManager,
+				InputFieldTestCommand,
+				InputFieldBuildCommand,
+			},
+		},
 		{
 			name:         "unknown language",
 			language:     Language("unknown"),
@@ -556,6 +657,34 @@ func TestConfiguration_GetDefaultValue(t *testing.T) {
 			expectError: false,
 			expectValue: "1.21",
 		},
+		{
+			name:        "Rust version default",
+			inputField:  InputFieldRustVersion,
+			language:    LanguageRust,
+			expectError: false,
+			expectValue: "stable",
+		},
+		{
+			name:        "Rust build command default",
+			inputField:  InputFieldBuildCommand,
+			language:    LanguageRust,
+			expectError: false,
+			expectValue: "cargo build --release",
+		},
+		{
+			name:        "Java version default",
+			inputField:  InputFieldJavaVersion,
+			language:    LanguageJava,
+			expectError: false,
+			expectValue: "21",
+		},
+		{
+			name:        "Java package manager default",
+			inputField:  InputFieldPackageManager,
+			language:    LanguageJava,
+			expectError: false,
+			expectValue: "maven",
+		},
 		{
 			name:        "Node version for Go language (invalid)",
 			inputField:  InputFieldNodeVersion,
@@ -757,6 +886,16 @@ func TestGetVersionField(t *testing.T) {
 			language: LanguagePython,
 			expected: InputFieldPythonVersion,
 		},
+		{
+			name:     "Rust language version field",
+			language: LanguageRust,
+			expected: InputFieldRustVersion,
+		},
+		{
+			name:     "Java language version field",
+			language: LanguageJava,
+			expected: InputFieldJavaVersion,
+		},
 		{
 			name:     "Unknown language fallback",
 			language: Language("unknown"),
@@ -1070,30 +1209,224 @@ func TestConfigValidateAllInputs(t *testing.T) {
 	}
 }
 
+func TestConfigValidateManifestInputs(t *testing.T) {
+	tests := []struct {
+		name         string
+		templateName string
+		inputs       map[string]interface{}
+		errorMsg     string
+	}{
+		{
+			name:         "node-app with defaults only",
+			templateName: "node-app",
+			inputs:       map[string]interface{}{},
+		},
+		{
+			name:         "go-service ignores non-language inputs",
+			templateName: "go-service",
+			inputs: map[string]interface{}{
+				"goVersion": "1.22",
+				"container": map[string]interface{}{"enabled": true},
+			},
+		},
+		{
+			name:         "rust-service with toolchain",
+			templateName: "rust-service",
+			inputs: map[string]interface{}{
+				"rustVersion": "nightly",
+			},
+		},
+		{
+			name:         "rust-service with invalid toolchain",
+			templateName: "rust-service",
+			inputs: map[string]interface{}{
+				"rustVersion": "1.50",
+			},
+			errorMsg: "invalid rust version: 1.50",
+		},
+		{
+			name:         "java-app with gradle",
+			templateName: "java-app",
+			inputs: map[string]interface{}{
+				"javaVersion":    "17",
+				"packageManager": "gradle",
+			},
+		},
+		{
+			name:         "java-app with invalid package manager",
+			templateName: "java-app",
+			inputs: map[string]interface{}{
+				"packageManager": "ant",
+			},
+			errorMsg: "invalid package manager for java: ant",
+		},
+		{
+			name:         "node-app with invalid nodeVersion",
+			templateName: "node-app",
+			inputs: map[string]interface{}{
+				"nodeVersion": "12",
+			},
+			errorMsg: "invalid node version: 12",
+		},
+		{
+			name:         "python-app with invalid package manager",
+			templateName: "python-app",
+			inputs: map[string]interface{}{
+				"packageManager": "npm",
+			},
+			errorMsg: "invalid package manager for python: npm",
+		},
+		{
+			name:         "unknown template",
+			templateName: "rust-app",
+			inputs:       map[string]interface{}{},
+			errorMsg:     "unknown template: rust-app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Config.ValidateManifestInputs(tt.templateName, tt.inputs)
+			if tt.errorMsg != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	for templateName := range TemplateLanguages {
+		lang, exists := Config.GetTemplateLanguage(templateName)
+		assert.True(t, exists)
+		assert.True(t, Config.IsValidLanguage(lang), "template %s maps to unsupported language %s", templateName, lang)
+
+		languageTemplate, exists := Config.GetLanguageTemplate(lang)
+		assert.True(t, exists)
+		assert.Equal(t, templateName, languageTemplate)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected Language
+		detected bool
+	}{
+		{name: "go module", files: []string{"go.mod"}, expected: LanguageGo, detected: true},
+		{name: "node package", files: []string{"package.json"}, expected: LanguageNode, detected: true},
+		{name: "pyproject", files: []string{"pyproject.toml"}, expected: LanguagePython, detected: true},
+		{name: "requirements file", files: []string{"requirements.txt"}, expected: LanguagePython, detected: true},
+		{name: "cargo crate", files: []string{"Cargo.toml"}, expected: LanguageRust, detected: true},
+		{name: "maven project", files: []string{"pom.xml"}, expected: LanguageJava, detected: true},
+		{name: "gradle kotlin dsl", files: []string{"build.gradle.kts"}, expected: LanguageJava, detected: true},
+		{name: "go module wins over package.json", files: []string{"package.json", "go.mod"}, expected: LanguageGo, detected: true},
+		{name: "no markers", files: []string{"README.md"}, detected: false},
+		{name: "empty directory", detected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte{}, 0644))
+			}
+
+			lang, detected := DetectLanguage(dir)
+			assert.Equal(t, tt.detected, detected)
+			assert.Equal(t, tt.expected, lang)
+		})
+	}
+
+	t.Run("marker directory is ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "go.mod"), 0755))
+
+		_, detected := DetectLanguage(dir)
+		assert.False(t, detected)
+	})
+}
+
+func TestResolveActionNames(t *testing.T) {
+	t.Run("names and aliases", func(t *testing.T) {
+		resolved, err := ResolveActionNames(map[string]string{"checkout": "v5", "trivy": "0.28.0"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"checkout": "v5", "trivyAction": "0.28.0"}, resolved)
+	})
+
+	t.Run("unknown key lists the accepted keys", func(t *testing.T) {
+		_, err := ResolveActionNames(map[string]string{"checkot": "v5"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown action "checkot" in actionVersions, must be one of checkout, setupNode,`)
+		assert.Contains(t, err.Error(), "slackGithubAction, buildx, codeql,")
+	})
+
+	t.Run("action pinned under its name and alias", func(t *testing.T) {
+		_, err := ResolveActionNames(map[string]string{"trivy": "v1", "trivyAction": "v2"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `action trivyAction is pinned twice in actionVersions, as "trivy" and "trivyAction"`)
+	})
+}
+
+func TestDetectPackageManager(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		lang     Language
+		expected PackageManager
+		detected bool
+	}{
+		{name: "gradle", files: []string{"build.gradle"}, lang: LanguageJava, expected: PackageManagerGradle, detected: true},
+		{name: "gradle kotlin dsl", files: []string{"build.gradle.kts"}, lang: LanguageJava, expected: PackageManagerGradle, detected: true},
+		{name: "maven", files: []string{"pom.xml"}, lang: LanguageJava, expected: PackageManagerMaven, detected: true},
+		{name: "other language", files: []string{"build.gradle"}, lang: LanguageGo, detected: false},
+		{name: "no markers", files: []string{"README.md"}, lang: LanguageJava, detected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte{}, 0644))
+			}
+
+			manager, detected := DetectPackageManager(dir, tt.lang)
+			assert.Equal(t, tt.detected, detected)
+			assert.Equal(t, tt.expected, manager)
+		})
+	}
+}
+
 func TestTypedDefaultsComprehensive(t *testing.T) {
 	td := NewTypedDefaults()
 
 	t.Run("GetSupportedLanguages", func(t *testing.T) {
 		languages := td.GetSupportedLanguages()
-		assert.Len(t, languages, 3)
+		assert.Len(t, languages, 5)
 		assert.Contains(t, languages, LanguageGo)
 		assert.Contains(t, languages, LanguageNode)
 		assert.Contains(t, languages, LanguagePython)
+		assert.Contains(t, languages, LanguageRust)
+		assert.Contains(t, languages, LanguageJava)
 	})
 
 	t.Run("GetAllVersions", func(t *testing.T) {
 		versions := td.GetAllVersions()
-		assert.Len(t, versions, 3)
+		assert.Len(t, versions, 5)
 		assert.Equal(t, []string{"1.21", "1.22", "1.23", "1.24"}, versions[LanguageGo])
 		assert.Equal(t, []string{"16", "18", "20", "22"}, versions[LanguageNode])
 		assert.Equal(t, []string{"3.9", "3.10", "3.11", "3.12"}, versions[LanguagePython])
+		assert.Equal(t, []string{"stable", "beta", "nightly"}, versions[LanguageRust])
+		assert.Equal(t, []string{"11", "17", "21"}, versions[LanguageJava])
 	})
 
 	t.Run("GetAllPackageManagers", func(t *testing.T) {
 		managers := td.GetAllPackageManagers()
-		assert.Len(t, managers, 2) // Go has no package managers
+		assert.Len(t, managers, 3) // Go and Rust have no package managers
 		assert.Equal(t, []PackageManager{PackageManagerNpm, PackageManagerYarn, PackageManagerPnpm}, managers[LanguageNode])
 		assert.Equal(t, []PackageManager{PackageManagerPip, PackageManagerPoetry, PackageManagerPipenv}, managers[LanguagePython])
+		assert.Equal(t, []PackageManager{PackageManagerMaven, PackageManagerGradle}, managers[LanguageJava])
 	})
 
 	t.Run("GetDefaultSecuritySeverity", func(t *testing.T) {
@@ -1108,3 +1441,266 @@ func TestTypedDefaultsComprehensive(t *testing.T) {
 		assert.Contains(t, severities, SeverityCriticalHigh)
 	})
 }
+
+func TestLoadFileConfig(t *testing.T) {
+	t.Run("reads action version overrides", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		content := `actionVersions:
+  checkout: actions/checkout@v3
+  setupGo: v5
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		fileConfig, err := LoadFileConfig(path)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"checkout": "actions/checkout@v3",
+			"setupGo":  "v5",
+		}, fileConfig.ActionVersions)
+	})
+
+	t.Run("reads default job timeout", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("defaultJobTimeout: 45\n"), 0644))
+
+		fileConfig, err := LoadFileConfig(path)
+		require.NoError(t, err)
+		require.NotNil(t, fileConfig.DefaultJobTimeout)
+		assert.Equal(t, 45, *fileConfig.DefaultJobTimeout)
+	})
+
+	t.Run("rejects out of range job timeout", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("defaultJobTimeout: 0\n"), 0644))
+
+		_, err := LoadFileConfig(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "defaultJobTimeout must be between 1 and 360")
+	})
+
+	t.Run("reads enterprise env", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		content := `enterpriseEnv:
+  HTTPS_PROXY: http://proxy.corp.example:3128
+  NO_PROXY: .corp.example
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		fileConfig, err := LoadFileConfig(path)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"HTTPS_PROXY": "http://proxy.corp.example:3128",
+			"NO_PROXY":    ".corp.example",
+		}, fileConfig.EnterpriseEnv)
+	})
+
+	t.Run("rejects invalid enterprise env names", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("enterpriseEnv:\n  HTTPS-PROXY: http://proxy\n"), 0644))
+
+		_, err := LoadFileConfig(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid enterpriseEnv variable name: HTTPS-PROXY")
+	})
+
+	t.Run("reads policy", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		content := `policy:
+  disallowedActions:
+    - untrusted/*
+  requiredSteps:
+    - security-scan
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		fileConfig, err := LoadFileConfig(path)
+		require.NoError(t, err)
+		require.NotNil(t, fileConfig.Policy)
+		assert.Equal(t, []string{"untrusted/*"}, fileConfig.Policy.DisallowedActions)
+		assert.Equal(t, []string{"security-scan"}, fileConfig.Policy.RequiredSteps)
+	})
+
+	t.Run("rejects invalid policy", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("policy:\n  disallowedActions:\n    - checkout\n"), 0644))
+
+		_, err := LoadFileConfig(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid disallowedActions entry "checkout"`)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadFileConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read config file")
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gpgen.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("actionVersions: [unclosed"), 0644))
+
+		_, err := LoadFileConfig(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse config file")
+	})
+}
+
+func TestConfiguration_SelfValidate(t *testing.T) {
+	assert.NoError(t, Config.SelfValidate())
+
+	newConfig := func() *Configuration {
+		return &Configuration{
+			Languages: map[Language]LanguageConfig{
+				LanguageGo: {
+					Versions:       []string{"1.23", "1.24"},
+					DefaultVersion: "1.24",
+				},
+				LanguageNode: {
+					Versions:        []string{"20", "22"},
+					PackageManagers: []PackageManager{PackageManagerNpm, PackageManagerYarn},
+					DefaultVersion:  "20",
+					DefaultManager:  PackageManagerNpm,
+				},
+			},
+			Security: SecurityConfig{
+				SeverityLevels: []SecuritySeverity{SeverityCritical, SeverityCriticalHigh},
+				DefaultLevel:   SeverityCriticalHigh,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		corrupt  func(c *Configuration)
+		errorMsg string
+	}{
+		{
+			name: "default version not in versions",
+			corrupt: func(c *Configuration) {
+				goConfig := c.Languages[LanguageGo]
+				goConfig.DefaultVersion = "1.21"
+				c.Languages[LanguageGo] = goConfig
+			},
+			errorMsg: `language go default version "1.21" is not one of its versions`,
+		},
+		{
+			name: "empty versions",
+			corrupt: func(c *Configuration) {
+				c.Languages[LanguageGo] = LanguageConfig{}
+			},
+			errorMsg: "language go has no versions",
+		},
+		{
+			name: "duplicate version",
+			corrupt: func(c *Configuration) {
+				nodeConfig := c.Languages[LanguageNode]
+				nodeConfig.Versions = []string{"20", "22", "20"}
+				c.Languages[LanguageNode] = nodeConfig
+			},
+			errorMsg: "language node lists version 20 more than once",
+		},
+		{
+			name: "default package manager not in package managers",
+			corrupt: func(c *Configuration) {
+				nodeConfig := c.Languages[LanguageNode]
+				nodeConfig.DefaultManager = PackageManagerPnpm
+				c.Languages[LanguageNode] = nodeConfig
+			},
+			errorMsg: `language node default package manager "pnpm" is not one of its package managers`,
+		},
+		{
+			name: "default package manager without package managers",
+			corrupt: func(c *Configuration) {
+				goConfig := c.Languages[LanguageGo]
+				goConfig.DefaultManager = PackageManagerPip
+				c.Languages[LanguageGo] = goConfig
+			},
+			errorMsg: `language go sets default package manager "pip" but supports none`,
+		},
+		{
+			name: "default severity not in severity levels",
+			corrupt: func(c *Configuration) {
+				c.Security.DefaultLevel = SeverityLow
+			},
+			errorMsg: `default security severity "LOW" is not one of`,
+		},
+	}
+
+	require.NoError(t, newConfig().SelfValidate())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConfig()
+			tt.corrupt(c)
+
+			err := c.SelfValidate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Run("reads a policy file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		content := `disallowedActions:
+  - aquasecurity/trivy-action@master
+requiredSteps:
+  - security-scan
+  - upload-sarif
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		policy, err := LoadPolicy(path)
+		require.NoError(t, err)
+		assert.Equal(t, &Policy{
+			DisallowedActions: []string{"aquasecurity/trivy-action@master"},
+			RequiredSteps:     []string{"security-scan", "upload-sarif"},
+		}, policy)
+	})
+
+	t.Run("rejects empty required steps", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("requiredSteps:\n  - \"\"\n"), 0644))
+
+		_, err := LoadPolicy(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid policy file")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read policy file")
+	})
+}
+
+func TestPolicy_DisallowedAction(t *testing.T) {
+	policy := Policy{DisallowedActions: []string{
+		"untrusted/*",
+		"acme/lint",
+		"aquasecurity/trivy-action@master",
+	}}
+
+	tests := []struct {
+		uses   string
+		banned string
+	}{
+		{"untrusted/deploy@v1", "untrusted/*"},
+		{"untrusted/tools/sub@main", "untrusted/*"},
+		{"acme/lint@v2", "acme/lint"},
+		{"acme/lint/sub@v2", "acme/lint"},
+		{"acme/linter@v2", ""},
+		{"aquasecurity/trivy-action@master", "aquasecurity/trivy-action@master"},
+		{"aquasecurity/trivy-action@0.28.0", ""},
+		{"actions/checkout@v4", ""},
+		{"./.github/actions/setup", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uses, func(t *testing.T) {
+			banned, disallowed := policy.DisallowedAction(tt.uses)
+			assert.Equal(t, tt.banned != "", disallowed)
+			assert.Equal(t, tt.banned, banned)
+		})
+	}
+}
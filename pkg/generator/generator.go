@@ -2,10 +2,22 @@ package generator
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 
+	"github.com/terrpan/gpgen/pkg/config"
 	"github.com/terrpan/gpgen/pkg/manifest"
 	"github.com/terrpan/gpgen/pkg/models"
 	"github.com/terrpan/gpgen/pkg/templates"
@@ -16,6 +28,22 @@ import (
 type WorkflowGenerator struct {
 	templateManager *templates.TemplateManager
 	inputProcessor  *models.InputProcessor
+
+	// noDefaultJobTimeout leaves the job timeout to GitHub's default when the manifest sets none
+	noDefaultJobTimeout bool
+
+	// prune drops steps whose condition is false for every run
+	prune bool
+
+	// hardenScripts prepends bash strict mode to multi-line run scripts that lack it
+	hardenScripts bool
+
+	// noManifestHash leaves the manifest hash out of the workflow env
+	noManifestHash bool
+
+	// parsedTemplates caches step templates by their source string
+	parsedTemplatesMu sync.RWMutex
+	parsedTemplates   map[string]*template.Template
 }
 
 // NewWorkflowGenerator creates a new workflow generator
@@ -23,71 +51,436 @@ func NewWorkflowGenerator(templatesDir string) *WorkflowGenerator {
 	return &WorkflowGenerator{
 		templateManager: templates.NewTemplateManager(templatesDir),
 		inputProcessor:  models.NewInputProcessor(),
+		parsedTemplates: make(map[string]*template.Template),
 	}
 }
 
+// DisableDefaultJobTimeout stops applying a default job timeout when the manifest sets none
+func (g *WorkflowGenerator) DisableDefaultJobTimeout() {
+	g.noDefaultJobTimeout = true
+}
+
+// EnablePruning omits steps whose if condition is statically false given the effective
+// inputs, such as the container steps when container.enabled is false
+func (g *WorkflowGenerator) EnablePruning() {
+	g.prune = true
+}
+
+// EnableScriptHardening prepends set -euo pipefail to every multi-line bash run step that
+// doesn't already enable strict mode
+func (g *WorkflowGenerator) EnableScriptHardening() {
+	g.hardenScripts = true
+}
+
+// DisableManifestHash stops recording the manifest hash in the ManifestHashEnv workflow env variable
+func (g *WorkflowGenerator) DisableManifestHash() {
+	g.noManifestHash = true
+}
+
 // GitHubActionsWorkflow represents a GitHub Actions workflow
 type GitHubActionsWorkflow struct {
-	Name string                 `yaml:"name"`
-	On   map[string]interface{} `yaml:"on"`
-	Jobs map[string]Job         `yaml:"jobs"`
+	Name        string                 `yaml:"name"`
+	On          map[string]interface{} `yaml:"on"`
+	Env         map[string]string      `yaml:"env,omitempty"`
+	Concurrency *Concurrency           `yaml:"concurrency,omitempty"`
+	Jobs        WorkflowJobs           `yaml:"jobs"`
+}
+
+// WorkflowJobs holds a workflow's jobs by id. It marshals every job after the jobs it needs,
+// and otherwise in id order, so the file reads in the order the jobs run.
+type WorkflowJobs map[string]Job
+
+// MarshalYAML renders the jobs as a mapping in dependency order
+func (j WorkflowJobs) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, id := range j.order() {
+		var key, value yaml.Node
+		if err := key.Encode(id); err != nil {
+			return nil, err
+		}
+		if err := value.Encode(j[id]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &key, &value)
+	}
+	return node, nil
+}
+
+// order returns the job ids with each job after the jobs it needs, picking the lowest id
+// whenever several jobs are ready. Jobs caught in a needs cycle come last, in id order.
+func (j WorkflowJobs) order() []string {
+	ids := make([]string, 0, len(j))
+	for id := range j {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	placed := make(map[string]bool, len(ids))
+	order := make([]string, 0, len(ids))
+	for len(order) < len(ids) {
+		next := ""
+		for _, id := range ids {
+			if placed[id] {
+				continue
+			}
+			ready := true
+			for _, need := range j[id].Needs {
+				if _, exists := j[need]; exists && !placed[need] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = id
+				break
+			}
+		}
+		if next == "" {
+			for _, id := range ids {
+				if !placed[id] {
+					order = append(order, id)
+				}
+			}
+			break
+		}
+		placed[next] = true
+		order = append(order, next)
+	}
+	return order
+}
+
+// Concurrency represents a GitHub Actions concurrency block
+type Concurrency struct {
+	Group            string `yaml:"group"`
+	CancelInProgress bool   `yaml:"cancel-in-progress"`
 }
 
 // Job represents a GitHub Actions job
 type Job struct {
-	RunsOn      string            `yaml:"runs-on"`
-	Permissions map[string]string `yaml:"permissions,omitempty"`
+	If          string            `yaml:"if,omitempty"`
+	Needs       []string          `yaml:"needs,omitempty"`
+	RunsOn      interface{}       `yaml:"runs-on"`
+	Environment *JobEnvironment   `yaml:"environment,omitempty"`
+	Permissions interface{}       `yaml:"permissions,omitempty"`
+	TimeoutMins int               `yaml:"timeout-minutes,omitempty"`
+	Strategy    *Strategy         `yaml:"strategy,omitempty"`
+	Defaults    *JobDefaults      `yaml:"defaults,omitempty"`
+	Env         map[string]string `yaml:"env,omitempty"`
 	Steps       []WorkflowStep    `yaml:"steps"`
 }
 
+// JobEnvironment represents the GitHub deployment environment a job runs in
+type JobEnvironment struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url,omitempty"`
+}
+
+// JobDefaults represents a GitHub Actions job defaults block
+type JobDefaults struct {
+	Run RunDefaults `yaml:"run"`
+}
+
+// RunDefaults represents default settings for a job's run steps
+type RunDefaults struct {
+	Shell            string `yaml:"shell,omitempty"`
+	WorkingDirectory string `yaml:"working-directory,omitempty"`
+}
+
+// Strategy represents a GitHub Actions job strategy
+type Strategy struct {
+	Matrix StrategyMatrix `yaml:"matrix"`
+}
+
+// StrategyMatrix represents matrix dimensions plus include entries that attach extra
+// variables to matching combinations
+type StrategyMatrix struct {
+	Dimensions map[string][]string `yaml:",inline"`
+	Include    []map[string]string `yaml:"include,omitempty"`
+}
+
+// ManagedJobID is the id of the job gpgen generates from the template's steps
+const ManagedJobID = manifest.BuildJobID
+
+// crossCompileMatrixKey is the matrix dimension holding GOOS/GOARCH platform pairs
+const crossCompileMatrixKey = "platform"
+
+// runsOnInput is the input holding the job's runner label, or list of labels for self-hosted runners
+const runsOnInput = "runsOn"
+
 // WorkflowStep represents a GitHub Actions workflow step
 type WorkflowStep struct {
-	Name        string            `yaml:"name,omitempty"`
-	Uses        string            `yaml:"uses,omitempty"`
-	Run         string            `yaml:"run,omitempty"`
-	With        map[string]string `yaml:"with,omitempty"`
-	Env         map[string]string `yaml:"env,omitempty"`
-	If          string            `yaml:"if,omitempty"`
-	TimeoutMins int               `yaml:"timeout-minutes,omitempty"`
+	ID               string            `yaml:"id,omitempty"`
+	Name             string            `yaml:"name,omitempty"`
+	Uses             string            `yaml:"uses,omitempty"`
+	Run              string            `yaml:"run,omitempty"`
+	Shell            string            `yaml:"shell,omitempty"`
+	WorkingDirectory string            `yaml:"working-directory,omitempty"`
+	With             map[string]string `yaml:"with,omitempty"`
+	Env              map[string]string `yaml:"env,omitempty"`
+	If               string            `yaml:"if,omitempty"`
+	TimeoutMins      int               `yaml:"timeout-minutes,omitempty"`
+	ContinueOnError  *bool             `yaml:"continue-on-error,omitempty"`
+
+	// templateID is the ID of the template step the step was rendered from, if any
+	templateID string
+	// action is the logical name of the template action the step uses, if any
+	action string
+	// anchor is the template step a custom step was positioned against, directly or through
+	// other custom steps, so the custom step follows it when it moves to another job
+	anchor string
+	// inputs lists the input paths the template step reads, in order of first use
+	inputs []string
 }
 
-// GenerateWorkflow generates a GitHub Actions workflow from a manifest
-func (g *WorkflowGenerator) GenerateWorkflow(m *manifest.Manifest, environment string) (string, error) {
+// WorkflowExplanation describes the triggers, permissions, job settings and feature state resolved
+// for an environment
+type WorkflowExplanation struct {
+	Environment string
+	Triggers    map[string]interface{}
+	// Permissions holds the effective per-scope permissions; PermissionsShorthand is set instead
+	// when the manifest declares read-all or write-all
+	Permissions          map[string]string
+	PermissionsShorthand string
+	// PermissionWarnings lists least-privilege findings for explicitly declared permissions
+	PermissionWarnings []string
+	SecurityEnabled    bool
+	ContainerEnabled   bool
+	ContainerPush      bool
+	// Concurrency is nil when the workflow has no concurrency block
+	Concurrency *Concurrency
+	// TimeoutMinutes is the job timeout, or 0 when the job has none
+	TimeoutMinutes int
+}
+
+// ExplainWorkflow resolves what a manifest yields for an environment without rendering the workflow
+func (g *WorkflowGenerator) ExplainWorkflow(m *manifest.Manifest, environment string) (*WorkflowExplanation, error) {
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
+	inputs := g.getEffectiveInputs(m, environment)
+
+	processedInputs, err := g.inputProcessor.ProcessInputs(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process inputs: %w", err)
+	}
+
+	required := g.getRequiredPermissions(tmpl, inputs)
+	explanation := &WorkflowExplanation{
+		Environment:        environment,
+		Triggers:           g.getWorkflowTriggers(m, environment),
+		Permissions:        getDefaultedPermissions(required),
+		PermissionWarnings: LintPermissions(m.Spec.Permissions, required),
+		SecurityEnabled:    hasSecurityInputs(tmpl) && processedInputs.Security.Trivy.Enabled,
+		ContainerEnabled:   hasContainerInputs(tmpl) && processedInputs.Container.Enabled,
+		ContainerPush:      hasContainerInputs(tmpl) && processedInputs.Container.Enabled && processedInputs.Container.Push.Enabled,
+		Concurrency:        g.getConcurrency(m, environment),
+		TimeoutMinutes:     g.getJobTimeout(m, environment),
+	}
+
+	if declared := m.Spec.Permissions; declared != nil {
+		explanation.Permissions = declared.Scopes
+		explanation.PermissionsShorthand = declared.Shorthand
+	}
+
+	return explanation, nil
+}
+
+// Step sources reported by ExplainSteps
+const (
+	StepSourceTemplate = "template"
+	StepSourceCustom   = "custom"
+)
+
+// StepExplanation describes where a generated step came from, when it runs and which inputs
+// shaped it
+type StepExplanation struct {
+	Name string
+	// ID is the template step ID or the custom step's id, if any
+	ID     string
+	Source string
+	// If is the step's effective condition, empty when the step always runs
+	If string
+	// Inputs maps each input the template step reads to its effective value
+	Inputs map[string]interface{}
+}
+
+// ExplainSteps generates the steps of an environment's build job and describes each of them
+func (g *WorkflowGenerator) ExplainSteps(m *manifest.Manifest, environment string) ([]StepExplanation, error) {
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
+	inputs := g.getEffectiveInputs(m, environment)
+	if err := g.validateInputs(tmpl, m, inputs); err != nil {
+		return nil, fmt.Errorf("input validation failed: %w", err)
+	}
+
+	steps, err := g.generateSteps(tmpl, m, environment, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate steps: %w", err)
+	}
+	if g.prune {
+		steps = pruneSteps(steps)
+	}
+
+	explanations := make([]StepExplanation, 0, len(steps))
+	for _, step := range steps {
+		explanation := StepExplanation{
+			Name:   step.Name,
+			ID:     step.ID,
+			Source: StepSourceCustom,
+			If:     step.If,
+		}
+		if step.templateID != "" {
+			explanation.ID = step.templateID
+			explanation.Source = StepSourceTemplate
+			explanation.Inputs = make(map[string]interface{}, len(step.inputs))
+			for _, path := range step.inputs {
+				explanation.Inputs[path] = lookupInput(inputs, path)
+			}
+		}
+		explanations = append(explanations, explanation)
+	}
+
+	return explanations, nil
+}
+
+// inputRefRegex matches the input paths a template string reads, e.g. {{ .Inputs.security.trivy.enabled }}
+var inputRefRegex = regexp.MustCompile(`\.Inputs\.([A-Za-z0-9_]+(?:\.[A-Za-z0-9_]+)*)`)
+
+// templateStepInputs returns the input paths a template step reads, in order of first use
+func templateStepInputs(step templates.Step) []string {
+	fields := []string{step.If, step.Uses, step.Run, step.ContinueOnError}
+	for _, key := range sortedStringKeys(step.With) {
+		fields = append(fields, step.With[key])
+	}
+	for _, key := range sortedStringKeys(step.Env) {
+		fields = append(fields, step.Env[key])
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, field := range fields {
+		for _, match := range inputRefRegex.FindAllStringSubmatch(field, -1) {
+			add(match[1])
+		}
+	}
+	add(step.TimeoutInput)
+	return paths
+}
+
+// sortedStringKeys returns the keys of a string map in sorted order
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookupInput returns the value at a dotted input path, or nil when it isn't set
+func lookupInput(inputs map[string]interface{}, path string) interface{} {
+	var value interface{} = inputs
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// GenerateWorkflowStruct assembles the GitHub Actions workflow a manifest yields for an
+// environment, for callers that inspect the jobs and steps rather than the YAML
+func (g *WorkflowGenerator) GenerateWorkflowStruct(m *manifest.Manifest, environment string) (*GitHubActionsWorkflow, error) {
 	// Load the template
 	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
 	if err != nil {
-		return "", fmt.Errorf("failed to load template: %w", err)
+		return nil, fmt.Errorf("failed to load template: %w", err)
 	}
 
 	// Get effective inputs for the environment
 	inputs := g.getEffectiveInputs(m, environment)
 
 	// Validate inputs against template
-	if err := g.templateManager.ValidateInputs(m.Spec.Template, inputs); err != nil {
-		return "", fmt.Errorf("input validation failed: %w", err)
+	if err := g.validateInputs(tmpl, m, inputs); err != nil {
+		return nil, fmt.Errorf("input validation failed: %w", err)
 	}
 
 	// Generate workflow steps
 	steps, err := g.generateSteps(tmpl, m, environment, inputs)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate steps: %w", err)
+		return nil, fmt.Errorf("failed to generate steps: %w", err)
+	}
+	if g.prune {
+		steps = pruneSteps(steps)
+	}
+	if g.hardenScripts {
+		hardenRunScripts(steps, m.Spec.Defaults)
+	}
+
+	workflowName, err := g.getWorkflowName(m, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	buildJob := Job{
+		If:          g.getJobIf(m),
+		RunsOn:      g.getRunsOn(m, inputs),
+		Environment: g.getJobEnvironment(m, environment),
+		Permissions: g.getJobPermissions(tmpl, m, inputs),
+		TimeoutMins: g.getJobTimeout(m, environment),
+		Strategy:    g.getStrategy(m, inputs),
+		Defaults:    g.getJobDefaults(m),
+		Env:         g.getJobEnv(m, ManagedJobID),
+		Steps:       steps,
+	}
+	jobs, err := g.generateJobs(tmpl, m, inputs, buildJob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate jobs: %w", err)
+	}
+
+	workflowEnv, err := g.getWorkflowEnv(m)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create workflow
-	workflow := &GitHubActionsWorkflow{
-		Name: g.getWorkflowName(m, environment),
-		On:   g.getWorkflowTriggers(m, environment),
-		Jobs: map[string]Job{
-			"build": {
-				RunsOn:      "ubuntu-latest",
-				Permissions: g.getRequiredPermissions(tmpl, inputs),
-				Steps:       steps,
-			},
-		},
+	return &GitHubActionsWorkflow{
+		Name:        workflowName,
+		On:          g.getWorkflowTriggers(m, environment),
+		Env:         workflowEnv,
+		Concurrency: g.getConcurrency(m, environment),
+		Jobs:        jobs,
+	}, nil
+}
+
+// GenerateWorkflow generates a GitHub Actions workflow from a manifest
+func (g *WorkflowGenerator) GenerateWorkflow(m *manifest.Manifest, environment string) (string, error) {
+	workflow, err := g.GenerateWorkflowStruct(m, environment)
+	if err != nil {
+		return "", err
 	}
 
-	// Convert to YAML
+	templateHash, err := g.TemplateHash(m.Spec.Template)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash template: %w", err)
+	}
+
+	// Convert to YAML, behind a header recording the template it was generated from
 	var buf bytes.Buffer
+	buf.WriteString(workflowHeader(m.Spec.Template, templateHash, workflow.Jobs.order()))
 	encoder := yaml.NewEncoder(&buf)
 	encoder.SetIndent(2)
 
@@ -98,6 +491,192 @@ func (g *WorkflowGenerator) GenerateWorkflow(m *manifest.Manifest, environment s
 	return buf.String(), nil
 }
 
+// templateHashMarker prefixes the header comment line that records the template hash
+const templateHashMarker = "# gpgen-template-hash: "
+
+// jobsMarker prefixes the header comment line that lists the generated jobs, so a later
+// --merge can tell which of the file's jobs gpgen wrote
+const jobsMarker = "# gpgen-jobs: "
+
+// generatedHeaderPrefix starts the first line of the header of generated workflows
+const generatedHeaderPrefix = "# Generated by gpgen from template "
+
+// workflowHeader returns the comment block written at the top of generated workflows
+func workflowHeader(templateName, templateHash string, jobIDs []string) string {
+	return fmt.Sprintf("%s%s. DO NOT EDIT.\n%s%s\n%s%s\n",
+		generatedHeaderPrefix, templateName, templateHashMarker, templateHash, jobsMarker, strings.Join(jobIDs, ", "))
+}
+
+// MergeWorkflow updates the gpgen-managed jobs and top-level settings of an existing workflow
+// with those of a freshly generated one, keeping every other job and setting of the existing
+// file. Jobs the existing header lists as generated but the new workflow no longer has are
+// removed. The result starts with the generated header so --check can tell which template it
+// came from, followed by the existing file's own leading comments.
+func MergeWorkflow(existing, generated string) (string, error) {
+	generatedDoc, err := parseWorkflowDocument(generated)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse generated workflow: %w", err)
+	}
+	existingDoc, err := parseWorkflowDocument(existing)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse existing workflow: %w", err)
+	}
+
+	generatedJobs := mappingValue(generatedDoc, "jobs")
+	if mappingValue(generatedJobs, ManagedJobID) == nil {
+		return "", fmt.Errorf("generated workflow has no %s job", ManagedJobID)
+	}
+
+	existingJobs := mappingValue(existingDoc, "jobs")
+	if existingJobs == nil || existingJobs.Kind != yaml.MappingNode {
+		return "", fmt.Errorf("existing workflow has no jobs mapping to merge into")
+	}
+	for i := 0; i+1 < len(generatedJobs.Content); i += 2 {
+		jobID, generatedJob := generatedJobs.Content[i].Value, generatedJobs.Content[i+1]
+		if job := mappingValue(existingJobs, jobID); job != nil {
+			*job = *generatedJob
+		} else {
+			existingJobs.Content = append(existingJobs.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: jobID}, generatedJob)
+		}
+	}
+
+	// Generated settings replace the existing ones, and settings gpgen no longer generates go
+	jobsIndex := mappingIndex(existingDoc, "jobs")
+	for _, key := range managedWorkflowKeys {
+		value := mappingValue(generatedDoc, key)
+		i := mappingIndex(existingDoc, key)
+		switch {
+		case value != nil && i >= 0:
+			*existingDoc.Content[i+1] = *value
+		case value != nil:
+			entry := []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value}
+			existingDoc.Content = append(existingDoc.Content[:jobsIndex], append(entry, existingDoc.Content[jobsIndex:]...)...)
+			jobsIndex += 2
+		case i >= 0:
+			existingDoc.Content = append(existingDoc.Content[:i], existingDoc.Content[i+2:]...)
+			if i < jobsIndex {
+				jobsIndex -= 2
+			}
+		}
+	}
+
+	// Drop jobs an earlier run generated that the manifest no longer declares
+	for _, jobID := range generatedJobsOf(existing) {
+		if mappingValue(generatedJobs, jobID) != nil {
+			continue
+		}
+		for i := 0; i+1 < len(existingJobs.Content); i += 2 {
+			if existingJobs.Content[i].Value == jobID {
+				existingJobs.Content = append(existingJobs.Content[:i], existingJobs.Content[i+2:]...)
+				break
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(workflowHeaderOf(generated))
+	for _, line := range strings.SplitAfter(workflowHeaderOf(existing), "\n") {
+		if !isGeneratedHeaderLine(line) {
+			buf.WriteString(line)
+		}
+	}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(existingDoc); err != nil {
+		return "", fmt.Errorf("failed to encode merged workflow: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// managedWorkflowKeys are the top-level workflow settings gpgen generates
+var managedWorkflowKeys = []string{"name", "on", "permissions", "env", "concurrency"}
+
+// isGeneratedHeaderLine reports whether a header comment line is one gpgen writes
+func isGeneratedHeaderLine(line string) bool {
+	return strings.HasPrefix(line, generatedHeaderPrefix) ||
+		strings.HasPrefix(line, templateHashMarker) ||
+		strings.HasPrefix(line, jobsMarker)
+}
+
+// mappingIndex returns the index of key's key node in a mapping node, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseWorkflowDocument parses workflow content, minus its header comments, into its root mapping
+func parseWorkflowDocument(content string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.TrimPrefix(content, workflowHeaderOf(content))), &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("workflow is not a mapping")
+	}
+	return doc.Content[0], nil
+}
+
+// workflowHeaderOf returns the leading comment lines of workflow content
+func workflowHeaderOf(content string) string {
+	end := 0
+	for end < len(content) && content[end] == '#' {
+		newline := strings.IndexByte(content[end:], '\n')
+		if newline < 0 {
+			return content
+		}
+		end += newline + 1
+	}
+	return content[:end]
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// TemplateHash returns the content hash of the named template as recorded in workflow headers
+func (g *WorkflowGenerator) TemplateHash(templateName string) (string, error) {
+	return g.templateManager.TemplateHash(templateName)
+}
+
+// generatedJobsOf returns the job IDs listed in a generated workflow's header, or nil for
+// workflows written before the header listed them
+func generatedJobsOf(content string) []string {
+	for _, line := range strings.Split(workflowHeaderOf(content), "\n") {
+		if strings.HasPrefix(line, jobsMarker) {
+			return strings.Split(strings.TrimSpace(strings.TrimPrefix(line, jobsMarker)), ", ")
+		}
+	}
+	return nil
+}
+
+// TemplateHashFromWorkflow extracts the template hash from a generated workflow's header,
+// returning "" when the workflow has none
+func TemplateHashFromWorkflow(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if strings.HasPrefix(line, templateHashMarker) {
+			return strings.TrimSpace(strings.TrimPrefix(line, templateHashMarker))
+		}
+	}
+	return ""
+}
+
 // getEffectiveInputs merges template defaults, base inputs, environment-specific overrides and event context
 func (g *WorkflowGenerator) getEffectiveInputs(m *manifest.Manifest, environment string) map[string]interface{} {
 	rawInputs := make(map[string]interface{})
@@ -118,11 +697,30 @@ func (g *WorkflowGenerator) getEffectiveInputs(m *manifest.Manifest, environment
 		rawInputs[k] = v
 	}
 
-	// Apply environment-specific overrides
+	// Apply environment-specific overrides. Nested inputs merge key by key, so an
+	// environment can override container.dockerfile without restating container.enabled.
 	if environment != "default" {
-		if envConfig, exists := m.Spec.Environments[environment]; exists {
+		if envConfig, exists := g.getEnvironmentConfig(m, environment); exists {
 			for k, v := range envConfig.Inputs {
-				rawInputs[k] = v
+				rawInputs[k] = mergeInputValue(rawInputs[k], v)
+			}
+		}
+	}
+
+	// Coerce scalar values to the template's declared input types
+	if tmpl != nil {
+		for k, v := range rawInputs {
+			if inputDef, isInput := tmpl.Inputs[k]; isInput {
+				rawInputs[k] = templates.CoerceInputValue(v, inputDef)
+			}
+		}
+	}
+
+	// Matrix dimensions that name a template input resolve to the matrix value at runtime
+	if tmpl != nil {
+		for k := range m.Spec.Matrix {
+			if _, isInput := tmpl.Inputs[k]; isInput {
+				rawInputs[k] = matrixExpression(k)
 			}
 		}
 	}
@@ -141,77 +739,982 @@ func (g *WorkflowGenerator) getEffectiveInputs(m *manifest.Manifest, environment
 	return g.inputProcessor.ToMap(processedInputs)
 }
 
-// addEventDrivenContext adds context-aware settings based on environment and triggers
+// getEnvironmentConfig returns the manifest's configuration for an environment, falling back
+// to the template's default environment of the same name when the manifest doesn't set one.
+// A template default only supplies inputs the manifest's spec.inputs leave unset, so it
+// suggests values without overriding the user's own.
+func (g *WorkflowGenerator) getEnvironmentConfig(m *manifest.Manifest, environment string) (manifest.EnvironmentConfig, bool) {
+	if envConfig, exists := m.Spec.Environments[environment]; exists {
+		return envConfig, true
+	}
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
+	if err != nil {
+		return manifest.EnvironmentConfig{}, false
+	}
+	packageManager, _ := m.Spec.Inputs["packageManager"].(string)
+	envConfig, exists := templates.DefaultEnvironment(tmpl, environment, packageManager)
+	if !exists {
+		return envConfig, false
+	}
+
+	inputs := make(map[string]interface{}, len(envConfig.Inputs))
+	for k, v := range envConfig.Inputs {
+		if _, isSet := m.Spec.Inputs[k]; !isSet {
+			inputs[k] = v
+		}
+	}
+	envConfig.Inputs = inputs
+	return envConfig, true
+}
+
+// addEventDrivenContext adds context-aware settings based on environment and triggers.
+// Environment defaults only replace values the manifest did not set explicitly, so the
+// processor's defaults and the user's container settings reach the template unchanged.
 func (g *WorkflowGenerator) addEventDrivenContext(inputs *models.WorkflowInputs, environment string) {
+	explicit := g.inputProcessor.HasInput
+
 	// Set default event-driven behavior based on environment
 	switch environment {
 	case "default", "staging":
 		// Default/staging: Build on PRs for validation, but strategic pushing
-		if !inputs.Container.Build.OnPR {
+		if !explicit("container", "build", "onPR") {
 			inputs.Container.Build.OnPR = true
 		}
-		if inputs.Container.Push.OnProduction {
+		if !explicit("container", "push", "onProduction") {
 			inputs.Container.Push.OnProduction = false // Don't push on production events in staging
 		}
 	case "production":
 		// Production: Build and push on production events
-		if inputs.Container.Build.OnPR {
+		if !explicit("container", "build", "onPR") {
 			inputs.Container.Build.OnPR = false // Don't build on PRs in production env
 		}
-		if !inputs.Container.Build.OnProduction {
+		if !explicit("container", "build", "onProduction") {
 			inputs.Container.Build.OnProduction = true
 		}
-		if !inputs.Container.Push.OnProduction {
+		if !explicit("container", "push", "onProduction") {
 			inputs.Container.Push.OnProduction = true
 		}
 	}
 }
 
-// getValue returns obj[key] if present (even if nil), otherwise defaultValue
-func getValue(obj map[string]interface{}, key string, defaultValue interface{}) interface{} {
-	if val, exists := obj[key]; exists {
-		return val
+// validateInputs validates effective inputs against the template, checking every value of
+// matrix dimensions in place of the runtime matrix expression
+func (g *WorkflowGenerator) validateInputs(tmpl *templates.Template, m *manifest.Manifest, inputs map[string]interface{}) error {
+	resolved := make(map[string]interface{}, len(inputs))
+	for k, v := range inputs {
+		resolved[k] = v
 	}
-	return defaultValue
-}
-
-// generateSteps generates workflow steps by merging template steps with custom steps
-func (g *WorkflowGenerator) generateSteps(tmpl *templates.Template, m *manifest.Manifest, environment string, inputs map[string]interface{}) ([]WorkflowStep, error) {
-	var steps []WorkflowStep
 
-	// Process template steps
-	for _, templateStep := range tmpl.Steps {
-		step, err := g.processTemplateStep(templateStep, inputs)
-		if err != nil {
-			return nil, fmt.Errorf("failed to process template step %s: %w", templateStep.ID, err)
+	for _, k := range sortedMatrixKeys(m.Spec.Matrix) {
+		inputDef, isInput := tmpl.Inputs[k]
+		if !isInput {
+			continue
 		}
-		steps = append(steps, step)
+		values := m.Spec.Matrix[k]
+		for _, value := range values {
+			if err := g.templateManager.ValidateInputValue(k, value, inputDef); err != nil {
+				return fmt.Errorf("invalid matrix value: %w", err)
+			}
+		}
+		resolved[k] = values[0]
 	}
 
-	// Apply custom steps
-	steps, err := g.applyCustomSteps(steps, m.Spec.CustomSteps, environment, m)
+	if _, err := getCrossCompilePlatforms(inputs); err != nil {
+		return err
+	}
+	if _, err := getTrivyScans(inputs); err != nil {
+		return err
+	}
+	if _, err := getTrivyFormat(inputs); err != nil {
+		return err
+	}
+	if err := validateArtifacts(inputs); err != nil {
+		return err
+	}
+	if err := validateNotifications(inputs); err != nil {
+		return err
+	}
+	if err := validateContainerPlatforms(inputs); err != nil {
+		return err
+	}
+	runsOn, err := getRunnerLabels(inputs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to apply custom steps: %w", err)
+		return err
+	}
+	if _, hasOSMatrix := m.Spec.Matrix[manifest.MatrixKeyOS]; runsOn != nil && hasOSMatrix {
+		return fmt.Errorf("input '%s' cannot be combined with an %s matrix dimension", runsOnInput, manifest.MatrixKeyOS)
 	}
 
-	return steps, nil
+	if err := g.templateManager.ValidateInputs(tmpl.Name, resolved); err != nil {
+		return err
+	}
+
+	// Built-in templates also get the typed language checks
+	if _, isBuiltin := config.Config.GetTemplateLanguage(tmpl.Name); isBuiltin {
+		return config.Config.ValidateManifestInputs(tmpl.Name, resolved)
+	}
+	return nil
 }
 
-// processTemplateStep processes a template step with input substitution
-func (g *WorkflowGenerator) processTemplateStep(templateStep templates.Step, inputs map[string]interface{}) (WorkflowStep, error) {
-	step := WorkflowStep{
-		Name:        templateStep.Name,
-		Uses:        templateStep.Uses,
-		TimeoutMins: templateStep.TimeoutMins,
+// getStrategy generates the job strategy from the manifest matrix and cross-compilation platforms
+func (g *WorkflowGenerator) getStrategy(m *manifest.Manifest, inputs map[string]interface{}) *Strategy {
+	matrix := g.getMatrix(m, inputs)
+	if len(matrix) == 0 {
+		return nil
 	}
 
-	// Process run command with template substitution
-	if templateStep.Run != "" {
-		run, err := g.substituteTemplate(templateStep.Run, inputs)
-		if err != nil {
-			return step, fmt.Errorf("failed to substitute run command: %w", err)
-		}
-		// Replace GitHub Actions placeholders
+	strategy := &Strategy{Matrix: StrategyMatrix{Dimensions: matrix}}
+
+	// Attach GOOS/GOARCH to each platform leg
+	platforms, _ := getCrossCompilePlatforms(inputs)
+	for _, platform := range platforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		strategy.Matrix.Include = append(strategy.Matrix.Include, map[string]string{
+			crossCompileMatrixKey: platform,
+			"goos":                goos,
+			"goarch":              goarch,
+		})
+	}
+
+	return strategy
+}
+
+// getMatrix returns the manifest matrix extended with a platform dimension when cross-compiling
+func (g *WorkflowGenerator) getMatrix(m *manifest.Manifest, inputs map[string]interface{}) map[string][]string {
+	platforms, _ := getCrossCompilePlatforms(inputs)
+	if len(platforms) == 0 {
+		return m.Spec.Matrix
+	}
+
+	matrix := make(map[string][]string, len(m.Spec.Matrix)+1)
+	for k, v := range m.Spec.Matrix {
+		matrix[k] = v
+	}
+	matrix[crossCompileMatrixKey] = platforms
+	return matrix
+}
+
+// getCrossCompilePlatforms returns the GOOS/GOARCH pairs to build when crossCompile is enabled
+func getCrossCompilePlatforms(inputs map[string]interface{}) ([]string, error) {
+	if enabled, _ := inputs["crossCompile"].(bool); !enabled {
+		return nil, nil
+	}
+
+	value, _ := inputs["platforms"].(string)
+	var platforms []string
+	for _, platform := range strings.Split(value, ",") {
+		platform = strings.TrimSpace(platform)
+		if platform == "" {
+			continue
+		}
+		goos, goarch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+			return nil, fmt.Errorf("invalid platform %q, must be in GOOS/GOARCH form (e.g. linux/amd64)", platform)
+		}
+		platforms = append(platforms, platform)
+	}
+
+	if len(platforms) == 0 {
+		return nil, fmt.Errorf("crossCompile requires at least one platform")
+	}
+	return platforms, nil
+}
+
+// matrixExpression returns the GitHub Actions expression for a matrix value
+func matrixExpression(key string) string {
+	return fmt.Sprintf("${{ matrix.%s }}", key)
+}
+
+// sortedMatrixKeys returns matrix dimension names in deterministic order
+func sortedMatrixKeys(matrix map[string][]string) []string {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// defaultArtifactName is the name upload-artifact uses when a step doesn't set one
+const defaultArtifactName = "artifact"
+
+// applyMatrixArtifactNames suffixes the artifact name of every upload-artifact step with the
+// matrix values it doesn't already reference. upload-artifact@v4 rejects a second upload
+// of the same name, so matrix legs would otherwise collide.
+func applyMatrixArtifactNames(steps []WorkflowStep, matrix map[string][]string) {
+	if len(matrix) == 0 {
+		return
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		action, _, _ := strings.Cut(step.Uses, "@")
+		if action != "actions/upload-artifact" {
+			continue
+		}
+
+		name := step.With["name"]
+		if name == "" {
+			name = defaultArtifactName
+		}
+		for _, k := range sortedMatrixKeys(matrix) {
+			if strings.Contains(name, "matrix."+k) {
+				continue
+			}
+			// Platform pairs contain a slash, which artifact names can't, so use the GOOS
+			// and GOARCH attached to each platform leg instead
+			if k == crossCompileMatrixKey {
+				for _, part := range []string{"goos", "goarch"} {
+					if !strings.Contains(name, "matrix."+part) {
+						name += "-" + matrixExpression(part)
+					}
+				}
+				continue
+			}
+			name += "-" + matrixExpression(k)
+		}
+		if step.With == nil {
+			step.With = make(map[string]string)
+		}
+		step.With["name"] = name
+	}
+}
+
+// getMatrixPushGate returns a condition that lets a single matrix leg push each unique image tag.
+// Dimensions referenced by the tags already make the tag unique per leg; for the remaining
+// dimensions only the leg with the first value pushes. Returns "" when no gating is needed.
+func getMatrixPushGate(matrix map[string][]string, tags string) string {
+	var conditions []string
+	for _, k := range sortedMatrixKeys(matrix) {
+		if strings.Contains(tags, "matrix."+k) {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("matrix.%s == '%s'", k, matrix[k][0]))
+	}
+	return strings.Join(conditions, " && ")
+}
+
+// applyMatrixPushGate restricts container pushes to one matrix leg per unique image tag
+func (g *WorkflowGenerator) applyMatrixPushGate(step *WorkflowStep, matrix map[string][]string) {
+	if len(matrix) == 0 || step.With["push"] != "true" {
+		return
+	}
+
+	if gate := getMatrixPushGate(matrix, step.With["tags"]); gate != "" {
+		step.With["push"] = fmt.Sprintf("${{ %s }}", gate)
+	}
+}
+
+// getValue returns obj[key] if present (even if nil), otherwise defaultValue
+func getValue(obj map[string]interface{}, key string, defaultValue interface{}) interface{} {
+	if val, exists := obj[key]; exists {
+		return val
+	}
+	return defaultValue
+}
+
+// trivyScan is a configured Trivy scan with the key naming its steps
+type trivyScan struct {
+	models.TrivyScan
+	key string
+}
+
+var validTrivyScanTypes = []string{
+	models.TrivyScanFilesystem, models.TrivyScanImage, models.TrivyScanRepository, models.TrivyScanConfig,
+}
+
+// getTrivyScans reads security.trivy.scans from the effective inputs. Each scan is keyed by
+// its type, with its 1-based position appended when a type repeats.
+func getTrivyScans(inputs map[string]interface{}) ([]trivyScan, error) {
+	security, ok := getValue(inputs, "security", nil).(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	trivy, ok := security["trivy"].(map[string]interface{})
+	if !ok || trivy["scans"] == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(trivy["scans"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid security.trivy.scans: %w", err)
+	}
+	var configured []models.TrivyScan
+	if err := json.Unmarshal(data, &configured); err != nil {
+		return nil, fmt.Errorf("invalid security.trivy.scans: %w", err)
+	}
+
+	scans := make([]trivyScan, 0, len(configured))
+	seen := make(map[string]bool)
+	for i, scan := range configured {
+		if !slices.Contains(validTrivyScanTypes, scan.ScanType) {
+			return nil, fmt.Errorf("invalid security.trivy.scans[%d].scanType %q, must be one of %v", i, scan.ScanType, validTrivyScanTypes)
+		}
+		key := scan.ScanType
+		if seen[key] {
+			key = fmt.Sprintf("%s-%d", scan.ScanType, i+1)
+		}
+		seen[scan.ScanType] = true
+		scans = append(scans, trivyScan{TrivyScan: scan, key: key})
+	}
+	return scans, nil
+}
+
+// getTrivyFormat reads security.trivy.format from the effective inputs, defaulting to sarif
+func getTrivyFormat(inputs map[string]interface{}) (string, error) {
+	security, ok := getValue(inputs, "security", nil).(map[string]interface{})
+	if !ok {
+		return models.TrivyFormatSARIF, nil
+	}
+	trivy, ok := security["trivy"].(map[string]interface{})
+	if !ok || trivy["format"] == nil {
+		return models.TrivyFormatSARIF, nil
+	}
+
+	format, ok := trivy["format"].(string)
+	if !ok || !slices.Contains(models.TrivyFormats, format) {
+		return "", fmt.Errorf("invalid security.trivy.format %v, must be one of %v", trivy["format"], models.TrivyFormats)
+	}
+	return format, nil
+}
+
+// validateArtifacts checks the artifacts input: paths must be a list of paths and
+// retentionDays, when set, within GitHub's limit
+func validateArtifacts(inputs map[string]interface{}) error {
+	value := getValue(inputs, "artifacts", nil)
+	if value == nil {
+		return nil
+	}
+	artifacts, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid artifacts: must be an object with paths, name and retentionDays")
+	}
+
+	if artifacts["paths"] != nil {
+		paths, ok := artifacts["paths"].([]interface{})
+		if !ok {
+			return fmt.Errorf("invalid artifacts.paths: must be a list of paths")
+		}
+		for i, path := range paths {
+			if s, ok := path.(string); !ok || strings.TrimSpace(s) == "" {
+				return fmt.Errorf("invalid artifacts.paths[%d]: must be a non-empty path", i)
+			}
+		}
+	}
+	if name, ok := artifacts["name"]; ok && name != nil {
+		if _, isString := name.(string); !isString {
+			return fmt.Errorf("invalid artifacts.name: must be a string")
+		}
+	}
+	if days, ok := artifacts["retentionDays"]; ok && days != nil {
+		n, isNumber := days.(float64)
+		if !isNumber {
+			if i, isInt := days.(int); isInt {
+				n, isNumber = float64(i), true
+			}
+		}
+		if !isNumber || n != float64(int(n)) || n < 1 || n > models.MaxArtifactRetentionDays {
+			return fmt.Errorf("invalid artifacts.retentionDays %v: must be a whole number of days from 1 to %d", days, models.MaxArtifactRetentionDays)
+		}
+	}
+	return nil
+}
+
+// secretNameRegex matches the names GitHub allows for secrets
+var secretNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateNotifications checks the notifications input: a Slack notification needs the name
+// of the secret holding its webhook URL
+func validateNotifications(inputs map[string]interface{}) error {
+	value := getValue(inputs, "notifications", nil)
+	if value == nil {
+		return nil
+	}
+	notifications, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid notifications: must be an object with slack")
+	}
+	if notifications["slack"] == nil {
+		return nil
+	}
+	slack, ok := notifications["slack"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid notifications.slack: must be an object with webhookSecret and channel")
+	}
+
+	channel, hasChannel := slack["channel"]
+	if hasChannel && channel != nil {
+		if _, isString := channel.(string); !isString {
+			return fmt.Errorf("invalid notifications.slack.channel: must be a string")
+		}
+	}
+
+	secret, hasSecret := slack["webhookSecret"]
+	if secret == nil || secret == "" {
+		if hasSecret || (hasChannel && channel != "") {
+			return fmt.Errorf("notifications.slack.webhookSecret is required: the name of the secret holding the webhook URL")
+		}
+		return nil
+	}
+	name, isString := secret.(string)
+	if !isString || !secretNameRegex.MatchString(name) || strings.HasPrefix(strings.ToUpper(name), "GITHUB_") {
+		return fmt.Errorf("invalid notifications.slack.webhookSecret %v: must be a secret name such as SLACK_WEBHOOK_URL", secret)
+	}
+	return nil
+}
+
+// containerPlatformRegex matches a Docker platform: os/arch with an optional variant, e.g. linux/arm/v7
+var containerPlatformRegex = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
+// validateContainerPlatforms checks container.platforms, the comma-separated platforms the
+// image is built for. Unset, the image is built for the runner's platform only.
+func validateContainerPlatforms(inputs map[string]interface{}) error {
+	container, _ := getValue(inputs, "container", nil).(map[string]interface{})
+	value, exists := container["platforms"]
+	if !exists || value == nil {
+		return nil
+	}
+
+	platforms, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("invalid container.platforms: must be a comma-separated string such as linux/amd64,linux/arm64")
+	}
+	if platforms == "" {
+		return nil
+	}
+	for _, platform := range strings.Split(platforms, ",") {
+		platform = strings.TrimSpace(platform)
+		if !containerPlatformRegex.MatchString(platform) {
+			return fmt.Errorf("invalid container.platforms entry %q, must be in os/arch form (e.g. linux/amd64 or linux/arm/v7)", platform)
+		}
+	}
+	return nil
+}
+
+// runnerArch is the architecture of GitHub-hosted Linux runners
+const runnerArch = "amd64"
+
+// needsEmulation reports whether container.platforms lists an architecture other than the
+// runner's, whose RUN instructions Buildx can only execute under QEMU
+func needsEmulation(inputs map[string]interface{}) bool {
+	container, _ := getValue(inputs, "container", nil).(map[string]interface{})
+	platforms, _ := container["platforms"].(string)
+	for _, platform := range strings.Split(platforms, ",") {
+		parts := strings.Split(strings.TrimSpace(platform), "/")
+		if len(parts) > 1 && parts[1] != runnerArch {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrivyStep reports whether a step ID is the template's Trivy step with the given base ID
+// or one generated from security.trivy.scans
+func isTrivyStep(id, base string) bool {
+	return id == base || strings.HasPrefix(id, base+"-")
+}
+
+// applyTrivyFormat switches a Trivy scan step from SARIF to another report format, renaming
+// a .sarif output file to match
+func applyTrivyFormat(step *WorkflowStep, format string) {
+	if step.With == nil {
+		return
+	}
+	step.With["format"] = format
+	if output, ok := step.With["output"]; ok && strings.HasSuffix(output, ".sarif") {
+		step.With["output"] = strings.TrimSuffix(output, ".sarif") + "." + models.TrivyOutputExtension(format)
+	}
+}
+
+// getStepTimeout returns the timeouts input override for a step ID, otherwise defaultValue
+func getStepTimeout(inputs map[string]interface{}, stepID string, defaultValue int) int {
+	timeouts, ok := getValue(inputs, "timeouts", nil).(map[string]interface{})
+	if !ok {
+		return defaultValue
+	}
+
+	switch v := timeouts[stepID].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	default:
+		return defaultValue
+	}
+}
+
+// generateSteps generates workflow steps by merging template steps with custom steps
+func (g *WorkflowGenerator) generateSteps(tmpl *templates.Template, m *manifest.Manifest, environment string, inputs map[string]interface{}) ([]WorkflowStep, error) {
+	steps, err := g.renderTemplateSteps(tmpl, m, environment, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply custom steps
+	steps, err = g.applyCustomSteps(steps, m.Spec.CustomSteps, environment, m, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply custom steps: %w", err)
+	}
+
+	applyStepDefaults(steps, m.Spec.StepDefaults)
+	applyMatrixArtifactNames(steps, g.getMatrix(m, inputs))
+
+	// Pin actions last, once overrides have replaced the actions they change
+	actionVersions, err := templates.ResolveActionVersions(m.Spec.ActionVersions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec.actionVersions: %w", err)
+	}
+	applyActionVersions(steps, actionVersions)
+
+	return steps, nil
+}
+
+// renderTemplateSteps renders the template steps an environment runs, with its overrides
+// applied: steps its inputs leave out are dropped and configured Trivy scans replace the
+// template's scan
+func (g *WorkflowGenerator) renderTemplateSteps(tmpl *templates.Template, m *manifest.Manifest, environment string, inputs map[string]interface{}) ([]WorkflowStep, error) {
+	var steps []WorkflowStep
+
+	// Configured Trivy scans replace the template's single scan; image scans follow the build
+	scans, err := getTrivyScans(inputs)
+	if err != nil {
+		return nil, err
+	}
+	var sourceScanSteps, imageScanSteps []templates.Step
+	for i, scan := range scans {
+		scanSteps := templates.CreateTrivyScanSteps(scan.key, scan.TrivyScan)
+		// The first scan keeps the default scan's IDs, so timeouts, overrides, positions and
+		// policies naming security-scan or upload-sarif still find it
+		if i == 0 {
+			scanSteps[0].ID, scanSteps[1].ID = "security-scan", "upload-sarif"
+		}
+		if scan.ScanType == models.TrivyScanImage {
+			imageScanSteps = append(imageScanSteps, scanSteps...)
+		} else {
+			sourceScanSteps = append(sourceScanSteps, scanSteps...)
+		}
+	}
+
+	// Only SARIF reports can be uploaded to the Security tab
+	trivyFormat, err := getTrivyFormat(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := g.getStepOverrides(m, environment)
+	overridden := make(map[string]bool, len(overrides))
+	var notifySteps []templates.Step
+
+	// renderStep renders a template step with the Trivy format and the step's override
+	// applied and appends it; SARIF uploads are dropped for other formats
+	renderStep := func(templateStep templates.Step) error {
+		if trivyFormat != models.TrivyFormatSARIF && isTrivyStep(templateStep.ID, "upload-sarif") {
+			return nil
+		}
+		step, err := g.processTemplateStep(templateStep, inputs)
+		if err != nil {
+			return fmt.Errorf("failed to process template step %s: %w", templateStep.ID, err)
+		}
+		if trivyFormat != models.TrivyFormatSARIF && isTrivyStep(templateStep.ID, "security-scan") {
+			applyTrivyFormat(&step, trivyFormat)
+		}
+		if override, exists := overrides[templateStep.ID]; exists {
+			if err := applyStepOverride(&step, override); err != nil {
+				return fmt.Errorf("failed to apply override for step %s: %w", templateStep.ID, err)
+			}
+			overridden[templateStep.ID] = true
+		}
+		if templateStep.ID == "build-and-push" {
+			g.applyMatrixPushGate(&step, g.getMatrix(m, inputs))
+		}
+		steps = append(steps, step)
+		return nil
+	}
+
+	// Process template steps
+	for _, templateStep := range tmpl.Steps {
+		if trivyFormat != models.TrivyFormatSARIF && isTrivyStep(templateStep.ID, "upload-sarif") {
+			continue
+		}
+		// Without artifact paths there is nothing to upload
+		if templateStep.ID == templates.ArtifactsStepID && !inputBool(inputs, "artifacts", "enabled") {
+			continue
+		}
+		// Images for the runner's own architecture build without emulation
+		if templateStep.ID == templates.QEMUStepID && !needsEmulation(inputs) {
+			continue
+		}
+		// Per-platform binaries only exist in the cross-compilation matrix
+		if templateStep.ID == templates.CrossCompileArtifactsStepID && !inputBool(inputs, "crossCompile") {
+			continue
+		}
+		// The failure notification is added last, after any image scans
+		if templateStep.ID == templates.SlackNotificationStepID {
+			if inputBool(inputs, "notifications", "slack", "enabled") {
+				notifySteps = append(notifySteps, templateStep)
+			}
+			continue
+		}
+		stepGroup := []templates.Step{templateStep}
+		if len(scans) > 0 {
+			switch templateStep.ID {
+			case "security-scan":
+				stepGroup = sourceScanSteps
+			case "upload-sarif":
+				continue
+			case "build-and-push":
+				stepGroup = append(stepGroup, imageScanSteps...)
+				imageScanSteps = nil
+			}
+		}
+
+		for _, groupStep := range stepGroup {
+			if err := renderStep(groupStep); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Templates without a container build still get their image scans, and the failure
+	// notification follows every other template step
+	for _, scanStep := range append(imageScanSteps, notifySteps...) {
+		if err := renderStep(scanStep); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range sortedStepOverrideIDs(overrides) {
+		if !overridden[id] {
+			return nil, fmt.Errorf("override for unknown step: %s", id)
+		}
+	}
+
+	return steps, nil
+}
+
+// applyActionVersions replaces the actions of template steps with the manifest's pinned
+// versions, matched by action name. Custom steps and overridden actions name their action
+// explicitly and are left alone.
+func applyActionVersions(steps []WorkflowStep, actionVersions map[string]string) {
+	for i := range steps {
+		if steps[i].action == "" {
+			continue
+		}
+		if pinned, ok := actionVersions[steps[i].action]; ok {
+			steps[i].Uses = pinned
+		}
+	}
+}
+
+// generateJobs splits the manifest's additional jobs off the build job. Each job takes the
+// template steps it lists out of the build job, along with the custom steps positioned
+// against them, repeating the workspace steps before them, and then its own steps. Jobs
+// running template steps share the build job's runner and matrix; jobs with only their own
+// steps run on a single runner after the checkout. Every job runs in the build job's
+// deployment environment.
+func (g *WorkflowGenerator) generateJobs(tmpl *templates.Template, m *manifest.Manifest, inputs map[string]interface{}, build Job) (WorkflowJobs, error) {
+	jobs := WorkflowJobs{}
+	if len(m.Spec.Jobs) == 0 {
+		jobs[ManagedJobID] = build
+		return jobs, nil
+	}
+
+	known := make(map[string]bool, len(tmpl.Steps))
+	for _, step := range tmpl.Steps {
+		known[step.ID] = true
+	}
+	for _, step := range build.Steps {
+		if step.templateID != "" {
+			known[step.templateID] = true
+		}
+	}
+	workspace := make(map[string]bool, len(templates.WorkspaceStepIDs))
+	for _, id := range templates.WorkspaceStepIDs {
+		workspace[id] = true
+	}
+
+	jobIDs := make([]string, 0, len(m.Spec.Jobs))
+	movedTo := make(map[string]string)
+	for jobID, spec := range m.Spec.Jobs {
+		if jobID == ManagedJobID {
+			continue
+		}
+		jobIDs = append(jobIDs, jobID)
+		for _, stepID := range spec.TemplateSteps {
+			if !known[stepID] {
+				return nil, fmt.Errorf("job %s: unknown template step: %s", jobID, stepID)
+			}
+			if workspace[stepID] {
+				return nil, fmt.Errorf("job %s: template step %s is repeated in every job and cannot be moved", jobID, stepID)
+			}
+			movedTo[stepID] = jobID
+		}
+	}
+	sort.Strings(jobIDs)
+
+	matrix := g.getMatrix(m, inputs)
+	for _, jobID := range jobIDs {
+		spec := m.Spec.Jobs[jobID]
+		job := Job{
+			If:          build.If,
+			Needs:       spec.Needs,
+			Permissions: build.Permissions,
+			TimeoutMins: build.TimeoutMins,
+			Defaults:    build.Defaults,
+			Environment: build.Environment,
+			Env:         g.getJobEnv(m, jobID),
+		}
+
+		var steps []WorkflowStep
+		if len(spec.TemplateSteps) > 0 {
+			job.RunsOn = build.RunsOn
+			job.Strategy = build.Strategy
+			for _, step := range build.Steps {
+				if workspace[step.templateID] || movedTo[anchorOf(step)] == jobID {
+					steps = append(steps, step)
+				}
+			}
+		} else {
+			job.RunsOn = g.getJobRunner(m, inputs)
+			if i := findStep(build.Steps, "checkout"); i >= 0 {
+				steps = append(steps, build.Steps[i])
+			}
+		}
+		if spec.RunsOn != "" {
+			job.RunsOn = spec.RunsOn
+		}
+
+		for _, customStep := range spec.Steps {
+			var err error
+			steps, err = g.applyCustomStep(steps, customStep, inputs)
+			if err != nil {
+				return nil, fmt.Errorf("job %s: failed to apply step %s: %w", jobID, customStep.EffectiveName(), err)
+			}
+		}
+		applyStepDefaults(steps, m.Spec.StepDefaults)
+		if job.Strategy != nil {
+			applyMatrixArtifactNames(steps, matrix)
+		}
+		if g.hardenScripts {
+			hardenRunScripts(steps, m.Spec.Defaults)
+		}
+		job.Steps = steps
+		jobs[jobID] = job
+	}
+
+	buildSteps := make([]WorkflowStep, 0, len(build.Steps))
+	for _, step := range build.Steps {
+		if _, moved := movedTo[anchorOf(step)]; !moved {
+			buildSteps = append(buildSteps, step)
+		}
+	}
+	build.Steps = buildSteps
+	build.Needs = m.Spec.Jobs[ManagedJobID].Needs
+	jobs[ManagedJobID] = build
+
+	return jobs, nil
+}
+
+// getStepOverrides returns the manifest's step overrides for an environment, keyed by template
+// step ID, with the environment's overrides layered field by field over the base overrides
+func (g *WorkflowGenerator) getStepOverrides(m *manifest.Manifest, environment string) map[string]manifest.StepOverride {
+	overrides := make(map[string]manifest.StepOverride, len(m.Spec.Overrides))
+	for id, override := range m.Spec.Overrides {
+		overrides[id] = override
+	}
+
+	if environment == "default" {
+		return overrides
+	}
+	envConfig, _ := g.getEnvironmentConfig(m, environment)
+	for id, envOverride := range envConfig.Overrides {
+		overrides[id] = mergeStepOverride(overrides[id], envOverride)
+	}
+	return overrides
+}
+
+// mergeStepOverride layers top over base: set fields replace, with and env are merged by key
+func mergeStepOverride(base, top manifest.StepOverride) manifest.StepOverride {
+	merged := base
+	if top.Name != "" {
+		merged.Name = top.Name
+	}
+	if top.Uses != "" {
+		merged.Uses, merged.Run = top.Uses, ""
+	}
+	if top.Run != "" {
+		merged.Run, merged.Uses = top.Run, ""
+	}
+	if top.If != "" {
+		merged.If = top.If
+	}
+	if top.TimeoutMinutes != nil {
+		merged.TimeoutMinutes = top.TimeoutMinutes
+	}
+	if top.ContinueOnError != nil {
+		merged.ContinueOnError = top.ContinueOnError
+	}
+	merged.With = mergeStringMaps(base.With, top.With)
+	merged.Env = mergeStringMaps(base.Env, top.Env)
+	return merged
+}
+
+// mergeInputValue overlays top onto base. Nested objects merge recursively; any other value
+// in top replaces base.
+func mergeInputValue(base, top interface{}) interface{} {
+	baseMap, baseIsMap := base.(map[string]interface{})
+	topMap, topIsMap := top.(map[string]interface{})
+	if !baseIsMap || !topIsMap {
+		return top
+	}
+
+	merged := make(map[string]interface{}, len(baseMap)+len(topMap))
+	for k, v := range baseMap {
+		merged[k] = v
+	}
+	for k, v := range topMap {
+		merged[k] = mergeInputValue(merged[k], v)
+	}
+	return merged
+}
+
+// mergeStringMaps returns a new map holding base's entries overlaid with top's, or nil when both are empty
+func mergeStringMaps(base, top map[string]string) map[string]string {
+	if len(base) == 0 && len(top) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(top))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range top {
+		merged[k] = v
+	}
+	return merged
+}
+
+// sortedStepOverrideIDs returns the overridden step IDs in deterministic order
+func sortedStepOverrideIDs(overrides map[string]manifest.StepOverride) []string {
+	ids := make([]string, 0, len(overrides))
+	for id := range overrides {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// applyStepOverride applies a manifest override to a rendered template step. Overriding
+// uses turns the step into an action step and overriding run turns it into a run step;
+// with and env entries are merged into the template's.
+func applyStepOverride(step *WorkflowStep, override manifest.StepOverride) error {
+	if override.Uses != "" && override.Run != "" {
+		return fmt.Errorf("override cannot have both 'uses' and 'run'")
+	}
+
+	if override.Name != "" {
+		step.Name = override.Name
+	}
+	if override.Uses != "" {
+		if err := validateActionRef(override.Uses); err != nil {
+			return err
+		}
+		step.Uses = override.Uses
+		step.action = ""
+		step.Run = ""
+		step.Shell = ""
+		step.WorkingDirectory = ""
+	}
+	if override.Run != "" {
+		step.Run = override.Run
+		step.Uses = ""
+		step.action = ""
+		step.With = nil
+	}
+	if len(override.With) > 0 {
+		if step.Uses == "" {
+			return fmt.Errorf("override cannot set 'with' on a run step")
+		}
+		if step.With == nil {
+			step.With = make(map[string]string, len(override.With))
+		}
+		for k, v := range override.With {
+			step.With[k] = v
+		}
+	}
+	if len(override.Env) > 0 {
+		step.Env = mergeStringMaps(step.Env, override.Env)
+	}
+	if override.If != "" {
+		step.If = override.If
+	}
+	if override.TimeoutMinutes != nil {
+		step.TimeoutMins = *override.TimeoutMinutes
+	}
+	if override.ContinueOnError != nil {
+		continueOnError := *override.ContinueOnError
+		step.ContinueOnError = &continueOnError
+	}
+	return nil
+}
+
+// applyStepDefaults fills in the manifest's step defaults on every step that doesn't set its
+// own value. Shell and working directory only apply to run steps.
+func applyStepDefaults(steps []WorkflowStep, defaults *manifest.StepDefaults) {
+	if defaults == nil {
+		return
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		if step.TimeoutMins == 0 && defaults.TimeoutMinutes != nil {
+			step.TimeoutMins = *defaults.TimeoutMinutes
+		}
+		if step.ContinueOnError == nil && defaults.ContinueOnError != nil {
+			continueOnError := *defaults.ContinueOnError
+			step.ContinueOnError = &continueOnError
+		}
+		if step.Run == "" {
+			continue
+		}
+		if step.Shell == "" {
+			step.Shell = defaults.Shell
+		}
+		if step.WorkingDirectory == "" {
+			step.WorkingDirectory = defaults.WorkingDirectory
+		}
+	}
+}
+
+// processTemplateStep processes a template step with input substitution
+func (g *WorkflowGenerator) processTemplateStep(templateStep templates.Step, inputs map[string]interface{}) (WorkflowStep, error) {
+	timeout := templateStep.TimeoutMins
+	if templateStep.TimeoutInput != "" {
+		if minutes, err := templates.ParseDurationMinutes(getValue(inputs, templateStep.TimeoutInput, nil)); err == nil {
+			timeout = minutes
+		}
+	}
+
+	uses, err := g.substituteUses(templateStep.Uses, inputs)
+	if err != nil {
+		return WorkflowStep{}, err
+	}
+
+	step := WorkflowStep{
+		Name:        templateStep.Name,
+		Uses:        uses,
+		TimeoutMins: getStepTimeout(inputs, templateStep.ID, timeout),
+		templateID:  templateStep.ID,
+		action:      templateStep.Action,
+		inputs:      templateStepInputs(templateStep),
+	}
+
+	// Process run command with template substitution
+	if templateStep.Run != "" {
+		run, err := g.substituteTemplate(templateStep.Run, inputs)
+		if err != nil {
+			return step, fmt.Errorf("failed to substitute run command: %w", err)
+		}
+		// Replace GitHub Actions placeholders
 		run = g.replaceGitHubActionsPlaceholders(run)
 		step.Run = run
 	}
@@ -224,6 +1727,10 @@ func (g *WorkflowGenerator) processTemplateStep(templateStep templates.Step, inp
 			if err != nil {
 				return step, fmt.Errorf("failed to substitute with parameter %s: %w", k, err)
 			}
+			// Omit optional parameters that render empty so the action's default applies
+			if value == "" {
+				continue
+			}
 			// Replace GitHub Actions placeholders
 			value = g.replaceGitHubActionsPlaceholders(value)
 			step.With[k] = value
@@ -238,60 +1745,298 @@ func (g *WorkflowGenerator) processTemplateStep(templateStep templates.Step, inp
 			if err != nil {
 				return step, fmt.Errorf("failed to substitute env variable %s: %w", k, err)
 			}
+			// Omit variables that render empty
+			if value == "" {
+				continue
+			}
 			// Replace GitHub Actions placeholders
 			value = g.replaceGitHubActionsPlaceholders(value)
 			step.Env[k] = value
 		}
 	}
 
-	// Process if condition
-	if templateStep.If != "" {
-		ifCondition, err := g.substituteTemplate(templateStep.If, inputs)
-		if err != nil {
-			return step, fmt.Errorf("failed to substitute if condition: %w", err)
+	// Process if condition
+	if templateStep.If != "" {
+		ifCondition, err := g.substituteTemplate(templateStep.If, inputs)
+		if err != nil {
+			return step, fmt.Errorf("failed to substitute if condition: %w", err)
+		}
+		// Replace GitHub Actions placeholders
+		ifCondition = g.replaceGitHubActionsPlaceholders(ifCondition)
+		step.If = ifCondition
+	}
+
+	// Process continue-on-error; a step that renders false keeps the default so the
+	// manifest's step defaults still apply
+	if templateStep.ContinueOnError != "" {
+		value, err := g.substituteTemplate(templateStep.ContinueOnError, inputs)
+		if err != nil {
+			return step, fmt.Errorf("failed to substitute continue-on-error: %w", err)
+		}
+		continueOnError, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return step, fmt.Errorf("continue-on-error of step %s must render true or false, got %q", templateStep.ID, value)
+		}
+		if continueOnError {
+			step.ContinueOnError = &continueOnError
+		}
+	}
+
+	return step, nil
+}
+
+// substituteTemplate performs template substitution on a string
+func (g *WorkflowGenerator) substituteTemplate(templateStr string, inputs map[string]interface{}) (string, error) {
+	tmpl, err := g.parseTemplate(templateStr)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]interface{}{
+		"Inputs": inputs,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// actionRefRegex matches remote action references: owner/repo[/path]@ref
+var actionRefRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+(/[^@\s]+)?@[^@\s]+$`)
+
+// substituteUses renders input references in a step's uses (e.g. an action version taken
+// from an input) and checks that the result is a well-formed action reference
+func (g *WorkflowGenerator) substituteUses(uses string, inputs map[string]interface{}) (string, error) {
+	if uses == "" {
+		return "", nil
+	}
+
+	rendered, err := g.substituteTemplate(uses, inputs)
+	if err != nil {
+		return "", fmt.Errorf("failed to substitute uses: %w", err)
+	}
+	if err := validateActionRef(rendered); err != nil {
+		return "", err
+	}
+	return rendered, nil
+}
+
+// validateActionRef checks that uses references a remote action (owner/repo@ref), a local
+// action (./path) or a Docker image (docker://image)
+func validateActionRef(uses string) error {
+	if manifest.IsLocalAction(uses) || strings.HasPrefix(uses, "docker://") || actionRefRegex.MatchString(uses) {
+		return nil
+	}
+	return fmt.Errorf("invalid action reference %q, must be 'owner/repo@ref', './path' or 'docker://image'", uses)
+}
+
+// parseTemplate returns the parsed template for templateStr, parsing it only on first use
+func (g *WorkflowGenerator) parseTemplate(templateStr string) (*template.Template, error) {
+	g.parsedTemplatesMu.RLock()
+	tmpl, ok := g.parsedTemplates[templateStr]
+	g.parsedTemplatesMu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := template.New("step").Parse(templateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	g.parsedTemplatesMu.Lock()
+	if g.parsedTemplates == nil {
+		g.parsedTemplates = make(map[string]*template.Template)
+	}
+	g.parsedTemplates[templateStr] = tmpl
+	g.parsedTemplatesMu.Unlock()
+
+	return tmpl, nil
+}
+
+// LocalActions returns the local actions (uses: ./path) referenced by the steps generated for
+// the given environments, mapped to the sorted input names passed to them
+func (g *WorkflowGenerator) LocalActions(m *manifest.Manifest, environments []string) (map[string][]string, error) {
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
+	actionInputs := make(map[string]map[string]bool)
+	for _, env := range environments {
+		steps, err := g.generateSteps(tmpl, m, env, g.getEffectiveInputs(m, env))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate steps for %s: %w", env, err)
+		}
+		for _, step := range steps {
+			if !manifest.IsLocalAction(step.Uses) {
+				continue
+			}
+			if actionInputs[step.Uses] == nil {
+				actionInputs[step.Uses] = make(map[string]bool)
+			}
+			for name := range step.With {
+				actionInputs[step.Uses][name] = true
+			}
+		}
+	}
+
+	actions := make(map[string][]string, len(actionInputs))
+	for action, inputs := range actionInputs {
+		names := make([]string, 0, len(inputs))
+		for name := range inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		actions[action] = names
+	}
+	return actions, nil
+}
+
+// RemoteActions returns the sorted remote action references (owner/repo[/path]@ref) used by
+// the steps generated for the given environments
+func (g *WorkflowGenerator) RemoteActions(m *manifest.Manifest, environments []string) ([]string, error) {
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, env := range environments {
+		steps, err := g.generateSteps(tmpl, m, env, g.getEffectiveInputs(m, env))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate steps for %s: %w", env, err)
+		}
+		for _, step := range steps {
+			if !manifest.IsLocalAction(step.Uses) && actionRefRegex.MatchString(step.Uses) {
+				seen[step.Uses] = true
+			}
+		}
+	}
+
+	actions := make([]string, 0, len(seen))
+	for action := range seen {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions, nil
+}
+
+// ScaffoldLocalActions writes a starter composite action.yml under rootDir for every local
+// action referenced by the generated steps that doesn't have one yet, returning the written paths
+func (g *WorkflowGenerator) ScaffoldLocalActions(m *manifest.Manifest, environments []string, rootDir string) ([]string, error) {
+	actions, err := g.LocalActions(m, environments)
+	if err != nil {
+		return nil, err
+	}
+
+	uses := make([]string, 0, len(actions))
+	for action := range actions {
+		uses = append(uses, action)
+	}
+	sort.Strings(uses)
+
+	var written []string
+	for _, action := range uses {
+		actionDir := filepath.Join(rootDir, filepath.FromSlash(strings.TrimPrefix(action, "./")))
+		if actionFileExists(actionDir) {
+			continue
+		}
+
+		if err := os.MkdirAll(actionDir, 0755); err != nil {
+			return written, fmt.Errorf("failed to create action directory: %w", err)
+		}
+		actionFile := filepath.Join(actionDir, "action.yml")
+		if err := os.WriteFile(actionFile, []byte(compositeActionScaffold(action, actions[action])), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", actionFile, err)
+		}
+		written = append(written, actionFile)
+	}
+	return written, nil
+}
+
+// actionFileExists reports whether dir already holds an action.yml or action.yaml
+func actionFileExists(dir string) bool {
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
 		}
-		// Replace GitHub Actions placeholders
-		ifCondition = g.replaceGitHubActionsPlaceholders(ifCondition)
-		step.If = ifCondition
 	}
+	return false
+}
 
-	return step, nil
+// compositeActionScaffold returns a starter composite action declaring the given inputs
+func compositeActionScaffold(action string, inputs []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s\n", path.Base(action))
+	fmt.Fprintf(&b, "description: Composite action referenced by gpgen workflows as %s\n", action)
+	if len(inputs) > 0 {
+		b.WriteString("inputs:\n")
+		for _, input := range inputs {
+			fmt.Fprintf(&b, "  %s:\n    description: TODO describe %s\n    required: false\n", input, input)
+		}
+	}
+	b.WriteString("runs:\n")
+	b.WriteString("  using: composite\n")
+	b.WriteString("  steps:\n")
+	b.WriteString("    - name: TODO\n")
+	b.WriteString("      shell: bash\n")
+	b.WriteString("      run: echo \"Replace this step with the shared logic\"\n")
+	return b.String()
 }
 
-// substituteTemplate performs template substitution on a string
-func (g *WorkflowGenerator) substituteTemplate(templateStr string, inputs map[string]interface{}) (string, error) {
-	tmpl, err := template.New("step").Parse(templateStr)
+// ValidateCustomStepTargets checks, for the default and every named environment, that each
+// custom step's position target still exists when it is applied (e.g. it has not been
+// replaced by an earlier custom step, left out by the environment's inputs or renamed by an
+// override, and exists in the template)
+func (g *WorkflowGenerator) ValidateCustomStepTargets(m *manifest.Manifest) error {
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+		return fmt.Errorf("failed to load template: %w", err)
 	}
 
-	data := map[string]interface{}{
-		"Inputs": inputs,
+	envNames := make([]string, 0, len(m.Spec.Environments))
+	for envName := range m.Spec.Environments {
+		envNames = append(envNames, envName)
 	}
+	sort.Strings(envNames)
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+	for _, environment := range append([]string{"default"}, envNames...) {
+		// Targets resolve against the steps generation renders, so build them the same way
+		inputs := g.getEffectiveInputs(m, environment)
+		if err := g.validateInputs(tmpl, m, inputs); err != nil {
+			return fmt.Errorf("environment %s: %w", environment, err)
+		}
+		steps, err := g.renderTemplateSteps(tmpl, m, environment, inputs)
+		if err != nil {
+			return fmt.Errorf("environment %s: %w", environment, err)
+		}
+		if _, err := g.applyCustomSteps(steps, m.Spec.CustomSteps, environment, m, inputs); err != nil {
+			return fmt.Errorf("unreachable custom step in environment %s: %w", environment, err)
+		}
 	}
 
-	return buf.String(), nil
+	return nil
 }
 
 // applyCustomSteps applies custom steps according to their position directives
-func (g *WorkflowGenerator) applyCustomSteps(steps []WorkflowStep, customSteps []manifest.CustomStep, environment string, m *manifest.Manifest) ([]WorkflowStep, error) {
+func (g *WorkflowGenerator) applyCustomSteps(steps []WorkflowStep, customSteps []manifest.CustomStep, environment string, m *manifest.Manifest, inputs map[string]interface{}) ([]WorkflowStep, error) {
 	// Get environment-specific custom steps
 	allCustomSteps := customSteps
 	if environment != "default" {
-		if envConfig, exists := m.Spec.Environments[environment]; exists {
+		if envConfig, exists := g.getEnvironmentConfig(m, environment); exists {
 			allCustomSteps = append(allCustomSteps, envConfig.CustomSteps...)
 		}
 	}
 
 	for _, customStep := range allCustomSteps {
 		var err error
-		steps, err = g.applyCustomStep(steps, customStep)
+		steps, err = g.applyCustomStep(steps, customStep, inputs)
 		if err != nil {
-			return nil, fmt.Errorf("failed to apply custom step %s: %w", customStep.Name, err)
+			return nil, fmt.Errorf("failed to apply custom step %s: %w", customStep.EffectiveName(), err)
 		}
 	}
 
@@ -299,16 +2044,26 @@ func (g *WorkflowGenerator) applyCustomSteps(steps []WorkflowStep, customSteps [
 }
 
 // applyCustomStep applies a single custom step at the specified position
-func (g *WorkflowGenerator) applyCustomStep(steps []WorkflowStep, customStep manifest.CustomStep) ([]WorkflowStep, error) {
+func (g *WorkflowGenerator) applyCustomStep(steps []WorkflowStep, customStep manifest.CustomStep, inputs map[string]interface{}) ([]WorkflowStep, error) {
+	uses, err := g.substituteUses(customStep.Uses, inputs)
+	if err != nil {
+		return nil, err
+	}
+
 	newStep := WorkflowStep{
-		Name: customStep.Name,
-		Uses: customStep.Uses,
+		ID:   customStep.ID,
+		Name: customStep.EffectiveName(),
+		Uses: uses,
 		Run:  customStep.Run,
 	}
 
 	if customStep.TimeoutMinutes != nil {
 		newStep.TimeoutMins = *customStep.TimeoutMinutes
 	}
+	if customStep.ContinueOnError != nil {
+		continueOnError := *customStep.ContinueOnError
+		newStep.ContinueOnError = &continueOnError
+	}
 
 	if len(customStep.With) > 0 {
 		newStep.With = customStep.With
@@ -319,6 +2074,19 @@ func (g *WorkflowGenerator) applyCustomStep(steps []WorkflowStep, customStep man
 	if customStep.If != "" {
 		newStep.If = customStep.If
 	}
+	if customStep.OnEnvironment != "" {
+		envCondition, err := templates.EnvironmentCond.ForEnvironment(customStep.OnEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		if newStep.If != "" {
+			envCondition = templates.NewConditionBuilder().
+				WithCustomCondition(envCondition).
+				WithCustomCondition("(" + unwrapExpression(newStep.If) + ")").
+				And()
+		}
+		newStep.If = envCondition
+	}
 
 	// Parse position directive
 	position := customStep.Position
@@ -347,98 +2115,155 @@ func (g *WorkflowGenerator) applyCustomStep(steps []WorkflowStep, customStep man
 	}
 }
 
+// unwrapExpression strips the optional ${{ }} wrapper from an if expression so it can be combined
+func unwrapExpression(expression string) string {
+	trimmed := strings.TrimSpace(expression)
+	if strings.HasPrefix(trimmed, "${{") && strings.HasSuffix(trimmed, "}}") {
+		return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(trimmed, "${{"), "}}"))
+	}
+	return trimmed
+}
+
 // insertStepBefore inserts a step before the target step
 func (g *WorkflowGenerator) insertStepBefore(steps []WorkflowStep, newStep WorkflowStep, targetStep string) ([]WorkflowStep, error) {
-	for i, step := range steps {
-		if g.matchesStep(step, targetStep) {
-			result := make([]WorkflowStep, 0, len(steps)+1)
-			result = append(result, steps[:i]...)
-			result = append(result, newStep)
-			result = append(result, steps[i:]...)
-			return result, nil
-		}
+	i := findStep(steps, targetStep)
+	if i < 0 {
+		return nil, stepNotFoundError(steps, targetStep)
 	}
-	return nil, fmt.Errorf("target step not found: %s", targetStep)
+	newStep.anchor = anchorOf(steps[i])
+	result := make([]WorkflowStep, 0, len(steps)+1)
+	result = append(result, steps[:i]...)
+	result = append(result, newStep)
+	result = append(result, steps[i:]...)
+	return result, nil
 }
 
 // insertStepAfter inserts a step after the target step
 func (g *WorkflowGenerator) insertStepAfter(steps []WorkflowStep, newStep WorkflowStep, targetStep string) ([]WorkflowStep, error) {
-	for i, step := range steps {
-		if g.matchesStep(step, targetStep) {
-			result := make([]WorkflowStep, 0, len(steps)+1)
-			result = append(result, steps[:i+1]...)
-			result = append(result, newStep)
-			result = append(result, steps[i+1:]...)
-			return result, nil
-		}
+	i := findStep(steps, targetStep)
+	if i < 0 {
+		return nil, stepNotFoundError(steps, targetStep)
 	}
-	return nil, fmt.Errorf("target step not found: %s", targetStep)
+	newStep.anchor = anchorOf(steps[i])
+	result := make([]WorkflowStep, 0, len(steps)+1)
+	result = append(result, steps[:i+1]...)
+	result = append(result, newStep)
+	result = append(result, steps[i+1:]...)
+	return result, nil
 }
 
 // replaceStep replaces the target step with the new step
 func (g *WorkflowGenerator) replaceStep(steps []WorkflowStep, newStep WorkflowStep, targetStep string) ([]WorkflowStep, error) {
-	for i, step := range steps {
-		if g.matchesStep(step, targetStep) {
-			steps[i] = newStep
-			return steps, nil
-		}
+	i := findStep(steps, targetStep)
+	if i < 0 {
+		return nil, stepNotFoundError(steps, targetStep)
 	}
-	return nil, fmt.Errorf("target step not found: %s", targetStep)
+	newStep.anchor = anchorOf(steps[i])
+	steps[i] = newStep
+	return steps, nil
 }
 
-// matchesStep checks if a workflow step matches the target identifier
-func (g *WorkflowGenerator) matchesStep(step WorkflowStep, target string) bool {
-	// Normalize both strings to lowercase for comparison
-	stepName := strings.ToLower(step.Name)
-	targetName := strings.ToLower(target)
-
-	// Special case mappings for common patterns
-	stepMappings := map[string][]string{
-		"run tests":            {"test", "tests"},
-		"build application":    {"build"},
-		"setup node.js":        {"setup-node", "node"},
-		"install dependencies": {"install", "dependencies"},
-		"checkout code":        {"checkout"},
+// anchorOf returns the template step a step stands for when placing steps relative to it:
+// a template step's own ID, or a custom step's anchor
+func anchorOf(step WorkflowStep) string {
+	if step.templateID != "" {
+		return step.templateID
 	}
+	return step.anchor
+}
 
-	// Check if we have a specific mapping for this step
-	if targets, exists := stepMappings[stepName]; exists {
-		for _, t := range targets {
-			if t == targetName {
-				return true
-			}
+// stepNotFoundError reports a position target that matches no step, listing the step IDs
+// that can be targeted at that point so typos are easy to spot
+func stepNotFoundError(steps []WorkflowStep, target string) error {
+	var known []string
+	for _, step := range steps {
+		switch {
+		case step.ID != "":
+			known = append(known, step.ID)
+		case step.templateID != "":
+			known = append(known, step.templateID)
 		}
-		return false
 	}
+	if len(known) == 0 {
+		return fmt.Errorf("target step not found: %s", target)
+	}
+	return fmt.Errorf("target step not found: %s (known steps: %s)", target, strings.Join(known, ", "))
+}
 
-	// Fallback: check if target is contained in any word of the step name
-	stepWords := strings.Fields(stepName)
-	for _, word := range stepWords {
-		// Remove common prefixes/suffixes and check for match
-		word = strings.TrimSuffix(word, "s") // Handle plurals
-		if word == targetName || targetName == word {
-			return true
+// findStep returns the index of the step a position targets, or -1. Targets match the ID of
+// the template step a step was rendered from, or a custom step's id, exactly. Positions
+// written against step names are still accepted as a fallback: the target then matches a
+// step whose name, lowercased with hyphens for spaces, equals it (e.g. "run-tests").
+func findStep(steps []WorkflowStep, target string) int {
+	for i, step := range steps {
+		if step.templateID == target || step.ID == target {
+			return i
 		}
-		// Also check if target is contained in word (for longer words)
-		if len(targetName) >= 4 && strings.Contains(word, targetName) {
-			return true
+	}
+	for i, step := range steps {
+		if strings.Join(strings.Fields(strings.ToLower(step.Name)), "-") == target {
+			return i
 		}
 	}
-
-	return false
+	return -1
 }
 
-// getWorkflowName generates the workflow name
-func (g *WorkflowGenerator) getWorkflowName(m *manifest.Manifest, environment string) string {
+// getWorkflowName generates the workflow name, rendering spec.workflowNameTemplate
+// over the manifest metadata and environment when it is set
+func (g *WorkflowGenerator) getWorkflowName(m *manifest.Manifest, environment string) (string, error) {
+	if m.Spec.WorkflowNameTemplate != "" {
+		tmpl, err := template.New("workflowName").Parse(m.Spec.WorkflowNameTemplate)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse workflowNameTemplate: %w", err)
+		}
+
+		data := map[string]interface{}{
+			"Metadata":    m.Metadata,
+			"Environment": environment,
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("failed to render workflowNameTemplate: %w", err)
+		}
+		return buf.String(), nil
+	}
+
 	name := m.Metadata.Name
 	if environment != "default" {
 		name = fmt.Sprintf("%s (%s)", name, environment)
 	}
-	return name
+	return name, nil
 }
 
 // getWorkflowTriggers generates workflow triggers based on environment
 func (g *WorkflowGenerator) getWorkflowTriggers(m *manifest.Manifest, environment string) map[string]interface{} {
+	// An environment's triggers take the place of the manifest's
+	configured := m.Spec.Triggers
+	if envConfig, exists := g.getEnvironmentConfig(m, environment); exists && envConfig.Triggers != nil {
+		configured = envConfig.Triggers
+	}
+	var triggers map[string]interface{}
+	if configured.ReplacesDefaults() {
+		triggers = configuredTriggers(configured)
+	} else {
+		triggers = defaultTriggers(configured, environment)
+	}
+
+	// spec.schedule runs the workflow on a schedule alongside its other triggers
+	if len(m.Spec.Schedule) > 0 {
+		schedules, _ := triggers["schedule"].([]map[string]string)
+		for _, cron := range m.Spec.Schedule {
+			schedules = append(schedules, map[string]string{"cron": cron})
+		}
+		triggers["schedule"] = schedules
+	}
+
+	return triggers
+}
+
+// defaultTriggers returns the environment's default triggers
+func defaultTriggers(configured *manifest.TriggersConfig, environment string) map[string]interface{} {
 	triggers := make(map[string]interface{})
 
 	switch environment {
@@ -453,9 +2278,7 @@ func (g *WorkflowGenerator) getWorkflowTriggers(m *manifest.Manifest, environmen
 		triggers["push"] = map[string]interface{}{
 			"tags": []string{"v*"},
 		}
-		triggers["release"] = map[string]interface{}{
-			"types": []string{"published"},
-		}
+		triggers["release"] = releaseTrigger(configured)
 	default:
 		// Custom environment - use push to main
 		triggers["push"] = map[string]interface{}{
@@ -466,27 +2289,425 @@ func (g *WorkflowGenerator) getWorkflowTriggers(m *manifest.Manifest, environmen
 	return triggers
 }
 
+// configuredTriggers builds the workflow triggers declared in the manifest
+func configuredTriggers(configured *manifest.TriggersConfig) map[string]interface{} {
+	triggers := make(map[string]interface{})
+
+	if push := configured.Push; push != nil {
+		event := make(map[string]interface{})
+		if len(push.Branches) > 0 {
+			event["branches"] = push.Branches
+		}
+		if len(push.Tags) > 0 {
+			event["tags"] = push.Tags
+		}
+		triggers["push"] = event
+	}
+	if pullRequest := configured.PullRequest; pullRequest != nil {
+		event := make(map[string]interface{})
+		if len(pullRequest.Branches) > 0 {
+			event["branches"] = pullRequest.Branches
+		}
+		triggers["pull_request"] = event
+	}
+	if len(configured.Schedule) > 0 {
+		schedules := make([]map[string]string, 0, len(configured.Schedule))
+		for _, schedule := range configured.Schedule {
+			schedules = append(schedules, map[string]string{"cron": schedule.Cron})
+		}
+		triggers["schedule"] = schedules
+	}
+	if configured.WorkflowDispatch {
+		triggers["workflow_dispatch"] = map[string]interface{}{}
+	}
+	if configured.Release != nil {
+		triggers["release"] = releaseTrigger(configured)
+	}
+
+	return triggers
+}
+
+// releaseTrigger returns the release trigger, defaulting to published releases
+func releaseTrigger(configured *manifest.TriggersConfig) map[string]interface{} {
+	releaseTypes := []string{"published"}
+	if configured != nil && configured.Release != nil && len(configured.Release.Types) > 0 {
+		releaseTypes = configured.Release.Types
+	}
+	return map[string]interface{}{
+		"types": releaseTypes,
+	}
+}
+
+// getJobEnv returns a job's env: the env configured for every job, such as enterprise proxy
+// settings, then the manifest's jobEnv and the job's own env, with GitHub Actions
+// placeholders resolved in the manifest's values
+func (g *WorkflowGenerator) getJobEnv(m *manifest.Manifest, jobID string) map[string]string {
+	jobSpecEnv := m.Spec.Jobs[jobID].Env
+	if len(config.Config.Jobs.Env) == 0 && len(m.Spec.JobEnv) == 0 && len(jobSpecEnv) == 0 {
+		return nil
+	}
+
+	env := make(map[string]string, len(config.Config.Jobs.Env)+len(m.Spec.JobEnv)+len(jobSpecEnv))
+	for k, v := range config.Config.Jobs.Env {
+		env[k] = v
+	}
+	for k, v := range m.Spec.JobEnv {
+		env[k] = g.replaceGitHubActionsPlaceholders(v)
+	}
+	for k, v := range jobSpecEnv {
+		env[k] = g.replaceGitHubActionsPlaceholders(v)
+	}
+	return env
+}
+
+// getWorkflowEnv generates the workflow-level env, resolving GitHub Actions placeholders and
+// recording the manifest hash unless disabled. A spec.env entry of the same name wins.
+func (g *WorkflowGenerator) getWorkflowEnv(m *manifest.Manifest) (map[string]string, error) {
+	env := make(map[string]string, len(m.Spec.Env)+1)
+	if !g.noManifestHash {
+		hash, err := ManifestHash(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash manifest: %w", err)
+		}
+		env[ManifestHashEnv] = hash
+	}
+	for k, v := range m.Spec.Env {
+		env[k] = g.replaceGitHubActionsPlaceholders(v)
+	}
+
+	if len(env) == 0 {
+		return nil, nil
+	}
+	return env, nil
+}
+
+// ManifestHashEnv is the workflow env variable recording the hash of the manifest a workflow
+// was generated from, so a run can be traced back to the manifest that produced it
+const ManifestHashEnv = "GPGEN_MANIFEST_SHA"
+
+// ManifestHash returns a short content hash of a manifest's settings. It is computed from the
+// parsed manifest, so comments and formatting don't change it.
+func ManifestHash(m *manifest.Manifest) (string, error) {
+	content, err := yaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
+
+// getJobIf returns the job condition skipping the workflow's jobs when spec.skipIf holds
+func (g *WorkflowGenerator) getJobIf(m *manifest.Manifest) string {
+	if strings.TrimSpace(m.Spec.SkipIf) == "" {
+		return ""
+	}
+	return fmt.Sprintf("${{ !(%s) }}", unwrapExpression(m.Spec.SkipIf))
+}
+
+// getConcurrency generates the concurrency settings declared in the manifest, or nil when
+// there are none. Cancellation defaults by environment.
+func (g *WorkflowGenerator) getConcurrency(m *manifest.Manifest, environment string) *Concurrency {
+	if m.Spec.Concurrency == nil {
+		return nil
+	}
+
+	// Production runs are never cancelled; PR and branch checks cancel superseded runs
+	concurrency := &Concurrency{
+		Group:            m.Spec.Concurrency.Group,
+		CancelInProgress: environment != "production",
+	}
+	if m.Spec.Concurrency.CancelInProgress != nil {
+		concurrency.CancelInProgress = *m.Spec.Concurrency.CancelInProgress
+	}
+
+	return concurrency
+}
+
+// getRunsOn returns the job's runner, taken from the matrix when it has an os dimension, from
+// the runsOn input, or else the template language's default runner. A list of labels stays a
+// list so runners must match all of them.
+func (g *WorkflowGenerator) getRunsOn(m *manifest.Manifest, inputs map[string]interface{}) interface{} {
+	if _, exists := m.Spec.Matrix[manifest.MatrixKeyOS]; exists {
+		return matrixExpression(manifest.MatrixKeyOS)
+	}
+	return g.getJobRunner(m, inputs)
+}
+
+// getJobRunner returns the runner of a job without a matrix: the runsOn input, or else the
+// template language's default runner
+func (g *WorkflowGenerator) getJobRunner(m *manifest.Manifest, inputs map[string]interface{}) interface{} {
+	if runsOn, err := getRunnerLabels(inputs); err == nil && runsOn != nil {
+		return runsOn
+	}
+	language, _ := config.Config.GetTemplateLanguage(m.Spec.Template)
+	return config.Config.GetDefaultRunner(language)
+}
+
+// getRunnerLabels returns the runsOn input as a label string or a list of labels, or nil when
+// it isn't set
+func getRunnerLabels(inputs map[string]interface{}) (interface{}, error) {
+	value, exists := inputs[runsOnInput]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	invalid := fmt.Errorf("input '%s' must be a runner label or a non-empty list of labels", runsOnInput)
+	switch runsOn := value.(type) {
+	case string:
+		if strings.TrimSpace(runsOn) == "" {
+			return nil, invalid
+		}
+		return runsOn, nil
+	case []interface{}:
+		if len(runsOn) == 0 {
+			return nil, invalid
+		}
+		labels := make([]string, 0, len(runsOn))
+		for _, item := range runsOn {
+			label, ok := item.(string)
+			if !ok || strings.TrimSpace(label) == "" {
+				return nil, invalid
+			}
+			labels = append(labels, label)
+		}
+		return labels, nil
+	case []string:
+		if len(runsOn) == 0 {
+			return nil, invalid
+		}
+		return runsOn, nil
+	default:
+		return nil, invalid
+	}
+}
+
+// getJobEnvironment returns the deployment environment configured for an environment, or nil
+func (g *WorkflowGenerator) getJobEnvironment(m *manifest.Manifest, environment string) *JobEnvironment {
+	envConfig, exists := g.getEnvironmentConfig(m, environment)
+	if !exists || envConfig.Environment == nil {
+		return nil
+	}
+
+	return &JobEnvironment{
+		Name: envConfig.Environment.Name,
+		URL:  envConfig.Environment.URL,
+	}
+}
+
+// getJobDefaults returns the job's defaults.run block from the manifest, or nil when unset
+func (g *WorkflowGenerator) getJobDefaults(m *manifest.Manifest) *JobDefaults {
+	if m.Spec.Defaults == nil || m.Spec.Defaults.Run == nil {
+		return nil
+	}
+
+	run := m.Spec.Defaults.Run
+	if run.Shell == "" && run.WorkingDirectory == "" {
+		return nil
+	}
+
+	return &JobDefaults{
+		Run: RunDefaults{
+			Shell:            run.Shell,
+			WorkingDirectory: run.WorkingDirectory,
+		},
+	}
+}
+
+// getJobTimeout returns the job timeout, defaulting to a longer timeout for production.
+// Returns 0 (omitted) when default timeouts are disabled and the manifest sets none.
+func (g *WorkflowGenerator) getJobTimeout(m *manifest.Manifest, environment string) int {
+	if m.Spec.TimeoutMinutes != nil {
+		return *m.Spec.TimeoutMinutes
+	}
+
+	if g.noDefaultJobTimeout {
+		return 0
+	}
+	if environment == "production" {
+		return config.Config.Jobs.ProductionTimeout
+	}
+	return config.Config.Jobs.DefaultTimeout
+}
+
 // getRequiredPermissions determines the required permissions for the workflow
 func (g *WorkflowGenerator) getRequiredPermissions(tmpl *templates.Template, inputs map[string]interface{}) map[string]string {
 	permissions := make(map[string]string)
 
+	// Start from the permissions the template declares
+	if tmpl != nil {
+		for scope, level := range tmpl.Permissions {
+			permissions[scope] = level
+		}
+	}
+
 	// Process inputs to get typed access
 	processedInputs, err := g.inputProcessor.ProcessInputs(inputs)
 	if err != nil {
 		// Fallback to legacy permission checking if processing fails
-		return g.getLegacyPermissions(inputs)
+		for scope, level := range g.getLegacyPermissions(inputs) {
+			if (scope == "security-events" && !hasSecurityInputs(tmpl)) || (scope == "packages" && !hasContainerInputs(tmpl)) {
+				continue
+			}
+			mergePermission(permissions, scope, level)
+		}
+		return permissions
 	}
 
-	// Check if Trivy scanning is enabled
-	if processedInputs.Security.Trivy.Enabled {
+	// Check if any SARIF-producing scanner is enabled
+	security := processedInputs.Security
+	trivySARIF := security.Trivy.Enabled && security.Trivy.Format == models.TrivyFormatSARIF
+	if hasSecurityInputs(tmpl) && (trivySARIF || security.Gosec.Enabled || security.Bandit.Enabled) {
 		// Add permissions required for uploading SARIF results to GitHub Security tab
+		mergePermission(permissions, "security-events", "write")
+		mergePermission(permissions, "contents", "read")
+	}
+
+	// Check if container building/pushing is enabled
+	if hasContainerInputs(tmpl) && processedInputs.Container.Enabled {
+		// Add permissions required for container registry operations
+		mergePermission(permissions, "packages", "write")
+		mergePermission(permissions, "contents", "read")
+	}
+
+	return permissions
+}
+
+// hasSecurityInputs reports whether a template can run security scans. Templates that
+// declare no security inputs have no scan steps, so security settings grant nothing.
+// A nil template (no template context) is assumed to have them.
+func hasSecurityInputs(tmpl *templates.Template) bool {
+	return tmpl == nil || templates.HasInput(tmpl, "security") || templates.HasInput(tmpl, "trivyScanEnabled")
+}
+
+// hasContainerInputs reports whether a template can build containers, see hasSecurityInputs
+func hasContainerInputs(tmpl *templates.Template) bool {
+	return tmpl == nil || templates.HasInput(tmpl, "container") || templates.HasInput(tmpl, "containerEnabled")
+}
+
+// permissionRank orders access levels so merging keeps the broader grant
+var permissionRank = map[string]int{"none": 0, "read": 1, "write": 2}
+
+// mergePermission sets scope to level unless a broader level is already granted
+func mergePermission(permissions map[string]string, scope, level string) {
+	if current, exists := permissions[scope]; exists && permissionRank[current] >= permissionRank[level] {
+		return
+	}
+	permissions[scope] = level
+}
+
+// defaultPermissions are granted when no enabled feature requires any scope. Declaring them
+// keeps the token from falling back to the repository's default, which may be read-write.
+var defaultPermissions = map[string]string{"contents": "read"}
+
+// getDefaultedPermissions returns the required permissions, or defaultPermissions when none
+// are required
+func getDefaultedPermissions(required map[string]string) map[string]string {
+	if len(required) > 0 {
+		return required
+	}
+	permissions := make(map[string]string, len(defaultPermissions))
+	for scope, level := range defaultPermissions {
+		permissions[scope] = level
+	}
+	return permissions
+}
+
+// getJobPermissions returns the manifest's explicit permissions when declared, otherwise the
+// minimal permissions derived from enabled features
+func (g *WorkflowGenerator) getJobPermissions(tmpl *templates.Template, m *manifest.Manifest, inputs map[string]interface{}) interface{} {
+	if m.Spec.Permissions != nil {
+		return m.Spec.Permissions
+	}
+	return getDefaultedPermissions(g.getRequiredPermissions(tmpl, inputs))
+}
+
+// LintPermissions flags explicitly declared permissions that are broader than the minimal set
+// required by enabled features, or that miss a required scope
+func LintPermissions(declared *manifest.Permissions, required map[string]string) []string {
+	if declared == nil {
+		return nil
+	}
+
+	suggestion := formatPermissions(required)
+
+	switch declared.Shorthand {
+	case manifest.PermissionsWriteAll:
+		return []string{fmt.Sprintf("permissions: write-all grants write access to every scope; use minimal scopes instead: %s", suggestion)}
+	case manifest.PermissionsReadAll:
+		var warnings []string
+		for _, scope := range sortedPermissionScopes(required) {
+			if required[scope] == "write" {
+				warnings = append(warnings, fmt.Sprintf("permissions: read-all is missing %s: write required by enabled features", scope))
+			}
+		}
+		return warnings
+	case manifest.PermissionsNone:
+		var warnings []string
+		for _, scope := range sortedPermissionScopes(required) {
+			warnings = append(warnings, fmt.Sprintf("permissions: none is missing %s: %s required by enabled features", scope, required[scope]))
+		}
+		return warnings
+	}
+
+	var warnings []string
+	for _, scope := range sortedPermissionScopes(declared.Scopes) {
+		if declared.Scopes[scope] == "write" && required[scope] != "write" {
+			needed := required[scope]
+			if needed == "" {
+				needed = "none"
+			}
+			warnings = append(warnings, fmt.Sprintf("permission %s: write is broader than needed (required: %s)", scope, needed))
+		}
+	}
+	for _, scope := range sortedPermissionScopes(required) {
+		level := declared.Scopes[scope]
+		if level == "" || level == "none" || (required[scope] == "write" && level != "write") {
+			warnings = append(warnings, fmt.Sprintf("permission %s: %s is required by enabled features but not granted", scope, required[scope]))
+		}
+	}
+	return warnings
+}
+
+// formatPermissions renders permissions as a compact "scope: level" list
+func formatPermissions(permissions map[string]string) string {
+	if len(permissions) == 0 {
+		return "{} (no scopes needed)"
+	}
+
+	parts := make([]string, 0, len(permissions))
+	for _, scope := range sortedPermissionScopes(permissions) {
+		parts = append(parts, fmt.Sprintf("%s: %s", scope, permissions[scope]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedPermissionScopes returns permission scopes in deterministic order
+func sortedPermissionScopes(permissions map[string]string) []string {
+	scopes := make([]string, 0, len(permissions))
+	for scope := range permissions {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// getLegacyPermissions provides fallback permission checking on the raw inputs map, for
+// both the legacy flat keys (trivyScanEnabled) and the nested form (security.trivy.enabled)
+func (g *WorkflowGenerator) getLegacyPermissions(inputs map[string]interface{}) map[string]string {
+	permissions := make(map[string]string)
+
+	// Check if a SARIF-producing scanner is enabled
+	if inputBool(inputs, "trivyScanEnabled") ||
+		inputBool(inputs, "security", "trivy", "enabled") ||
+		inputBool(inputs, "security", "gosec", "enabled") ||
+		inputBool(inputs, "security", "bandit", "enabled") {
 		permissions["security-events"] = "write"
 		permissions["contents"] = "read"
 	}
 
 	// Check if container building/pushing is enabled
-	if processedInputs.Container.Enabled {
-		// Add permissions required for container registry operations
+	if inputBool(inputs, "containerEnabled") || inputBool(inputs, "container", "enabled") {
 		permissions["packages"] = "write"
 		if permissions["contents"] == "" {
 			permissions["contents"] = "read"
@@ -496,29 +2717,127 @@ func (g *WorkflowGenerator) getRequiredPermissions(tmpl *templates.Template, inp
 	return permissions
 }
 
-// getLegacyPermissions provides fallback permission checking for legacy inputs
-func (g *WorkflowGenerator) getLegacyPermissions(inputs map[string]interface{}) map[string]string {
-	permissions := make(map[string]string)
+// inputBool reports whether the input at path through nested maps is the boolean true
+func inputBool(inputs map[string]interface{}, path ...string) bool {
+	var value interface{} = inputs
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		value = m[key]
+	}
+	enabled, ok := value.(bool)
+	return ok && enabled
+}
 
-	// Check if Trivy scanning is enabled (legacy way)
-	if trivyScanEnabled, exists := inputs["trivyScanEnabled"]; exists {
-		if enabled, ok := trivyScanEnabled.(bool); ok && enabled {
-			permissions["security-events"] = "write"
-			permissions["contents"] = "read"
+// hardenRunScripts prepends bash strict mode to the multi-line bash run scripts of custom
+// steps that lack it; template steps, overridden or not, are left as written. A step's shell
+// falls back to the job's defaults.run shell.
+func hardenRunScripts(steps []WorkflowStep, defaults *manifest.JobDefaults) {
+	jobShell := ""
+	if defaults != nil && defaults.Run != nil {
+		jobShell = defaults.Run.Shell
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		if step.templateID != "" {
+			continue
 		}
+		shell := step.Shell
+		if shell == "" {
+			shell = jobShell
+		}
+		if manifest.IsBashShell(shell) && manifest.NeedsStrictMode(step.Run) {
+			step.Run = manifest.StrictModeLine + "\n" + step.Run
+		}
+	}
+}
+
+// pruneSteps returns the steps whose condition can be true for some run
+func pruneSteps(steps []WorkflowStep) []WorkflowStep {
+	pruned := make([]WorkflowStep, 0, len(steps))
+	for _, step := range steps {
+		if step.If != "" && isStaticallyFalse(step.If) {
+			continue
+		}
+		pruned = append(pruned, step)
+	}
+	return pruned
+}
+
+// isStaticallyFalse reports whether a step condition is false regardless of the run's context.
+// Only the simple cases are evaluated: a literal false, or every || branch holding a literal
+// false (or a parenthesized statically false expression) joined with &&.
+func isStaticallyFalse(condition string) bool {
+	condition = strings.TrimSpace(condition)
+	if strings.HasPrefix(condition, "${{") && strings.HasSuffix(condition, "}}") {
+		condition = strings.TrimSpace(condition[3 : len(condition)-2])
+	}
+	if condition == "" {
+		return false
 	}
 
-	// Check if container building/pushing is enabled (legacy way)
-	if containerEnabled, exists := inputs["containerEnabled"]; exists {
-		if enabled, ok := containerEnabled.(bool); ok && enabled {
-			permissions["packages"] = "write"
-			if permissions["contents"] == "" {
-				permissions["contents"] = "read"
+	for _, branch := range splitTopLevel(condition, "||") {
+		branchFalse := false
+		for _, term := range splitTopLevel(branch, "&&") {
+			term = strings.TrimSpace(term)
+			if term == "false" || (isParenthesized(term) && isStaticallyFalse(term[1:len(term)-1])) {
+				branchFalse = true
+				break
 			}
 		}
+		if !branchFalse {
+			return false
+		}
 	}
+	return true
+}
 
-	return permissions
+// splitTopLevel splits an expression on op where it appears outside parentheses and quotes
+func splitTopLevel(expr, op string) []string {
+	var parts []string
+	depth, start, quoted := 0, 0, false
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\'':
+			quoted = !quoted
+		case quoted:
+		case expr[i] == '(':
+			depth++
+		case expr[i] == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(expr[i:], op):
+			parts = append(parts, expr[start:i])
+			start = i + len(op)
+			i += len(op) - 1
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+// isParenthesized reports whether expr is wrapped in a single pair of matching parentheses
+func isParenthesized(expr string) bool {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return false
+	}
+	depth, quoted := 0, false
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\'':
+			quoted = !quoted
+		case quoted:
+		case expr[i] == '(':
+			depth++
+		case expr[i] == ')':
+			depth--
+			if depth == 0 && i < len(expr)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
 }
 
 // replaceGitHubActionsPlaceholders replaces template placeholders with GitHub Actions syntax
@@ -526,5 +2845,13 @@ func (g *WorkflowGenerator) replaceGitHubActionsPlaceholders(value string) strin
 	// Replace placeholders with GitHub Actions syntax
 	value = strings.ReplaceAll(value, "GITHUB_ACTOR_PLACEHOLDER", "${{ github.actor }}")
 	value = strings.ReplaceAll(value, "GITHUB_TOKEN_PLACEHOLDER", "${{ secrets.GITHUB_TOKEN }}")
+	value = strings.ReplaceAll(value, "MATRIX_GOOS_PLACEHOLDER", "${{ matrix.goos }}")
+	value = strings.ReplaceAll(value, "MATRIX_GOARCH_PLACEHOLDER", "${{ matrix.goarch }}")
+	value = strings.ReplaceAll(value, "GITHUB_WORKFLOW_PLACEHOLDER", "${{ github.workflow }}")
+	value = strings.ReplaceAll(value, "GITHUB_RUN_URL_PLACEHOLDER", "${{ github.server_url }}/${{ github.repository }}/actions/runs/${{ github.run_id }}")
+	value = secretPlaceholderRegex.ReplaceAllString(value, "${{ secrets.$1 }}")
 	return value
 }
+
+// secretPlaceholderRegex matches a secret placeholder and captures the secret name
+var secretPlaceholderRegex = regexp.MustCompile(templates.GitHubPlaceholders.SecretPlaceholderPrefix + `([A-Za-z_][A-Za-z0-9_]*)`)
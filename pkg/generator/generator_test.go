@@ -1,33 +1,88 @@
 package generator
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/config"
 	"github.com/terrpan/gpgen/pkg/manifest"
 	"github.com/terrpan/gpgen/pkg/models"
+	"github.com/terrpan/gpgen/pkg/templates"
+	"gopkg.in/yaml.v3"
 )
 
+// testManifest returns a minimal valid manifest for a template with the given spec inputs
+func testManifest(template string, inputs map[string]interface{}) *manifest.Manifest {
+	return &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "test-app",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: template,
+			Inputs:   inputs,
+		},
+	}
+}
+
+// generateTestSteps renders the steps of a manifest's template for an environment, with
+// custom steps and overrides applied
+func generateTestSteps(t *testing.T, g *WorkflowGenerator, m *manifest.Manifest, environment string) []WorkflowStep {
+	t.Helper()
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
+	require.NoError(t, err)
+
+	steps, err := g.generateSteps(tmpl, m, environment, g.getEffectiveInputs(m, environment))
+	require.NoError(t, err)
+	return steps
+}
+
+// parseBuildSteps parses a generated workflow and returns the steps of its build job
+func parseBuildSteps(t *testing.T, workflow string) []WorkflowStep {
+	t.Helper()
+	var parsed GitHubActionsWorkflow
+	require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+	return parsed.Jobs[ManagedJobID].Steps
+}
+
+// stepNames returns the names of steps in order
+func stepNames(steps []WorkflowStep) []string {
+	names := make([]string, 0, len(steps))
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	return names
+}
+
+// requireStep returns the step with the given name, or the step a position target refers
+// to, failing the test when there is none
+func requireStep(t *testing.T, steps []WorkflowStep, target string) WorkflowStep {
+	t.Helper()
+	for _, step := range steps {
+		if step.Name == target {
+			return step
+		}
+	}
+	i := findStep(steps, target)
+	require.GreaterOrEqual(t, i, 0, "step %q not found", target)
+	return steps[i]
+}
+
 func TestWorkflowGenerator_GenerateWorkflow(t *testing.T) {
 	generator := NewWorkflowGenerator("")
 
 	t.Run("generate basic node-app workflow", func(t *testing.T) {
-		m := &manifest.Manifest{
-			APIVersion: "gpgen.dev/v1",
-			Kind:       "Pipeline",
-			Metadata: &manifest.ManifestMetadata{
-				Name: "test-app",
-			},
-			Spec: manifest.ManifestSpec{
-				Template: "node-app",
-				Inputs: map[string]interface{}{
-					"nodeVersion":    "18",
-					"packageManager": "npm",
-					"testCommand":    "npm test",
-				},
-			},
-		}
+		m := testManifest("node-app", map[string]interface{}{
+			"nodeVersion":    "18",
+			"packageManager": "npm",
+			"testCommand":    "npm test",
+		})
 
 		workflow, err := generator.GenerateWorkflow(m, "default")
 		require.NoError(t, err)
@@ -113,6 +168,86 @@ func TestWorkflowGenerator_GenerateWorkflow(t *testing.T) {
 	})
 }
 
+func TestWorkflowGenerator_GenerateWorkflowStruct(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "structured-service",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "go-service",
+			Inputs: map[string]interface{}{
+				"goVersion": "1.24",
+			},
+			CustomSteps: []manifest.CustomStep{
+				{ID: "vet", Name: "Vet", Position: "after:setup-go", Run: "go vet ./..."},
+			},
+			Jobs: map[string]manifest.JobSpec{
+				"release": {
+					Needs: []string{ManagedJobID},
+					Steps: []manifest.CustomStep{{Name: "Release", Run: "make release"}},
+				},
+			},
+		},
+	}
+
+	workflow, err := generator.GenerateWorkflowStruct(m, "default")
+	require.NoError(t, err)
+
+	t.Run("build job steps", func(t *testing.T) {
+		require.Contains(t, workflow.Jobs, ManagedJobID)
+		steps := workflow.Jobs[ManagedJobID].Steps
+		require.GreaterOrEqual(t, len(steps), 4)
+
+		assert.Equal(t, "Checkout code", steps[0].Name)
+		assert.Equal(t, "actions/checkout@v4", steps[0].Uses)
+		assert.Equal(t, "Setup Go", steps[1].Name)
+		assert.Equal(t, "1.24", steps[1].With["go-version"])
+		assert.Equal(t, WorkflowStep{ID: "vet", Name: "Vet", Run: "go vet ./...", anchor: "setup-go"}, steps[2])
+		assert.Equal(t, "Run tests", steps[3].Name)
+	})
+
+	t.Run("workflow settings and extra jobs", func(t *testing.T) {
+		assert.Equal(t, "structured-service", workflow.Name)
+		assert.Contains(t, workflow.On, "pull_request")
+		assert.NotEmpty(t, workflow.Env[ManifestHashEnv])
+
+		release := workflow.Jobs["release"]
+		assert.Equal(t, []string{ManagedJobID}, release.Needs)
+		assert.Equal(t, []string{"Checkout code", "Release"}, stepNames(release.Steps))
+		assert.Equal(t, "make release", release.Steps[1].Run)
+	})
+
+	t.Run("GenerateWorkflow renders the same workflow", func(t *testing.T) {
+		workflowYAML, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var rendered GitHubActionsWorkflow
+		require.NoError(t, yaml.Unmarshal([]byte(workflowYAML), &rendered))
+
+		structured := workflow.Jobs[ManagedJobID].Steps
+		renderedSteps := rendered.Jobs[ManagedJobID].Steps
+		require.Len(t, renderedSteps, len(structured))
+		for i := range structured {
+			assert.Equal(t, structured[i].Name, renderedSteps[i].Name)
+			assert.Equal(t, structured[i].Uses, renderedSteps[i].Uses)
+			assert.Equal(t, structured[i].If, renderedSteps[i].If)
+		}
+	})
+
+	t.Run("errors are returned without a workflow", func(t *testing.T) {
+		invalid := *m
+		invalid.Spec.Template = "no-such-template"
+
+		workflow, err := generator.GenerateWorkflowStruct(&invalid, "default")
+		require.Error(t, err)
+		assert.Nil(t, workflow)
+	})
+}
+
 func TestWorkflowGenerator_GetEffectiveInputs(t *testing.T) {
 	generator := NewWorkflowGenerator("")
 
@@ -158,11 +293,11 @@ func TestWorkflowGenerator_ApplyCustomStep(t *testing.T) {
 	generator := NewWorkflowGenerator("")
 
 	originalSteps := []WorkflowStep{
-		{Name: "Checkout code"},
-		{Name: "Setup Node.js"},
-		{Name: "Install dependencies"},
-		{Name: "Run tests"},
-		{Name: "Build application"},
+		{Name: "Checkout code", templateID: "checkout"},
+		{Name: "Setup Node.js", templateID: "setup-node"},
+		{Name: "Install dependencies", templateID: "install"},
+		{Name: "Run tests", templateID: "test"},
+		{Name: "Build application", templateID: "build"},
 	}
 
 	t.Run("insert after test", func(t *testing.T) {
@@ -172,7 +307,7 @@ func TestWorkflowGenerator_ApplyCustomStep(t *testing.T) {
 			Uses:     "security/scan@v1",
 		}
 
-		result, err := generator.applyCustomStep(originalSteps, customStep)
+		result, err := generator.applyCustomStep(originalSteps, customStep, nil)
 		require.NoError(t, err)
 
 		// Should have one more step
@@ -199,7 +334,7 @@ func TestWorkflowGenerator_ApplyCustomStep(t *testing.T) {
 			Run:      "npm run lint",
 		}
 
-		result, err := generator.applyCustomStep(originalSteps, customStep)
+		result, err := generator.applyCustomStep(originalSteps, customStep, nil)
 		require.NoError(t, err)
 
 		// Should have one more step
@@ -226,7 +361,7 @@ func TestWorkflowGenerator_ApplyCustomStep(t *testing.T) {
 			Run:      "custom build command",
 		}
 
-		result, err := generator.applyCustomStep(originalSteps, customStep)
+		result, err := generator.applyCustomStep(originalSteps, customStep, nil)
 		require.NoError(t, err)
 
 		// Should have same number of steps
@@ -255,7 +390,7 @@ func TestWorkflowGenerator_ApplyCustomStep(t *testing.T) {
 			Run:  "deploy command",
 		}
 
-		result, err := generator.applyCustomStep(originalSteps, customStep)
+		result, err := generator.applyCustomStep(originalSteps, customStep, nil)
 		require.NoError(t, err)
 
 		// Should have one more step at the end
@@ -270,7 +405,7 @@ func TestWorkflowGenerator_ApplyCustomStep(t *testing.T) {
 			Run:      "some command",
 		}
 
-		_, err := generator.applyCustomStep(originalSteps, customStep)
+		_, err := generator.applyCustomStep(originalSteps, customStep, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid position format")
 	})
@@ -282,39 +417,90 @@ func TestWorkflowGenerator_ApplyCustomStep(t *testing.T) {
 			Run:      "some command",
 		}
 
-		_, err := generator.applyCustomStep(originalSteps, customStep)
+		_, err := generator.applyCustomStep(originalSteps, customStep, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "target step not found")
 	})
 }
 
-func TestWorkflowGenerator_MatchesStep(t *testing.T) {
-	generator := NewWorkflowGenerator("")
+func TestFindStep(t *testing.T) {
+	steps := []WorkflowStep{
+		{Name: "Checkout code", templateID: "checkout"},
+		{Name: "Setup Go", templateID: "setup-go"},
+		{Name: "Run tests", templateID: "test"},
+		{Name: "Run linting", templateID: "lint"},
+		{Name: "Upload Trivy scan results to GitHub Security tab", templateID: "upload-sarif"},
+		{ID: "e2e", Name: "Run end-to-end tests"},
+		{Name: "Deploy"},
+		{Name: "Test", templateID: "smoke"},
+	}
 
 	tests := []struct {
-		stepName string
 		target   string
-		expected bool
+		expected int
 	}{
-		{"Run tests", "test", true},
-		{"Run tests", "tests", true},
-		{"Build application", "build", true},
-		{"Setup Node.js", "setup-node", true},
-		{"Install dependencies", "install", true},
-		{"Checkout code", "checkout", true},
-		{"Run tests", "build", false},
-		{"Setup Node.js", "setup-go", false},
+		{"checkout", 0},
+		{"setup-go", 1},
+		{"test", 2},
+		{"lint", 3},
+		{"upload-sarif", 4},
+		{"e2e", 5},
+		// Names are a fallback for positions written before step IDs
+		{"run-end-to-end-tests", 5},
+		{"deploy", 6},
+		// Partial words and plurals no longer match
+		{"tests", -1},
+		{"go", -1},
+		{"upload", -1},
+		{"setup", -1},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.stepName+"->"+tt.target, func(t *testing.T) {
-			step := WorkflowStep{Name: tt.stepName}
-			result := generator.matchesStep(step, tt.target)
-			assert.Equal(t, tt.expected, result)
+		t.Run(tt.target, func(t *testing.T) {
+			assert.Equal(t, tt.expected, findStep(steps, tt.target))
 		})
 	}
 }
 
+func TestWorkflowGenerator_CustomStepTargetsTemplateIDs(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata:   &manifest.ManifestMetadata{Name: "positions"},
+		Spec: manifest.ManifestSpec{
+			Template: "go-service",
+			CustomSteps: []manifest.CustomStep{
+				{Name: "Warm module cache", Position: "before:setup-go", Run: "make warm-cache"},
+				{Name: "Report findings", Position: "after:upload-sarif", Run: "make report"},
+			},
+		},
+	}
+	require.NoError(t, generator.ValidateCustomStepTargets(m))
+
+	workflow, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	names := stepNames(parseBuildSteps(t, workflow))
+
+	setupGo := indexOf(names, "Setup Go")
+	require.GreaterOrEqual(t, setupGo, 1)
+	assert.Equal(t, "Warm module cache", names[setupGo-1])
+
+	report := indexOf(names, "Report findings")
+	require.GreaterOrEqual(t, report, 1)
+	assert.Equal(t, "Upload Trivy scan results to GitHub Security tab", names[report-1])
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
 func TestWorkflowGenerator_GetWorkflowTriggers(t *testing.T) {
 	generator := NewWorkflowGenerator("")
 	m := &manifest.Manifest{}
@@ -345,6 +531,130 @@ func TestWorkflowGenerator_GetWorkflowTriggers(t *testing.T) {
 		assert.Contains(t, triggers, "push")
 		assert.Contains(t, triggers, "pull_request")
 	})
+
+	t.Run("release types default to published", func(t *testing.T) {
+		triggers := generator.getWorkflowTriggers(m, "production")
+
+		releaseTrigger := triggers["release"].(map[string]interface{})
+		assert.Equal(t, []string{"published"}, releaseTrigger["types"])
+	})
+
+	t.Run("release types can be overridden", func(t *testing.T) {
+		m := &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata: &manifest.ManifestMetadata{
+				Name: "release-app",
+			},
+			Spec: manifest.ManifestSpec{
+				Template: "node-app",
+				Triggers: &manifest.TriggersConfig{
+					Release: &manifest.ReleaseTrigger{Types: []string{"prereleased"}},
+				},
+				Environments: map[string]manifest.EnvironmentConfig{
+					"production": {},
+				},
+			},
+		}
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "production")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "  release:\n    types:\n      - prereleased\n")
+	})
+
+	newTriggerManifest := func(triggers *manifest.TriggersConfig) *manifest.Manifest {
+		m := testManifest("node-app", nil)
+		m.Spec.Triggers = triggers
+		return m
+	}
+
+	t.Run("cron and dispatch replace the defaults", func(t *testing.T) {
+		m := newTriggerManifest(&manifest.TriggersConfig{
+			Schedule:         []manifest.ScheduleTrigger{{Cron: "0 2 * * *"}},
+			WorkflowDispatch: true,
+		})
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "\"on\":\n  schedule:\n    - cron: 0 2 * * *\n  workflow_dispatch: {}\nenv:\n")
+	})
+
+	t.Run("push and pull request branches", func(t *testing.T) {
+		m := newTriggerManifest(&manifest.TriggersConfig{
+			Push:        &manifest.PushTrigger{Branches: []string{"trunk"}, Tags: []string{"release-*"}},
+			PullRequest: &manifest.PullRequestTrigger{Branches: []string{"trunk"}},
+		})
+
+		triggers := generator.getWorkflowTriggers(m, "production")
+		assert.Equal(t, map[string]interface{}{
+			"push":         map[string]interface{}{"branches": []string{"trunk"}, "tags": []string{"release-*"}},
+			"pull_request": map[string]interface{}{"branches": []string{"trunk"}},
+		}, triggers)
+	})
+
+	t.Run("environment triggers take precedence", func(t *testing.T) {
+		m := newTriggerManifest(&manifest.TriggersConfig{WorkflowDispatch: true})
+		m.Spec.Environments = map[string]manifest.EnvironmentConfig{
+			"production": {
+				Triggers: &manifest.TriggersConfig{
+					Push:    &manifest.PushTrigger{Tags: []string{"v*"}},
+					Release: &manifest.ReleaseTrigger{Types: []string{"released"}},
+				},
+			},
+		}
+
+		assert.Equal(t, map[string]interface{}{
+			"workflow_dispatch": map[string]interface{}{},
+		}, generator.getWorkflowTriggers(m, "default"))
+		assert.Equal(t, map[string]interface{}{
+			"push":    map[string]interface{}{"tags": []string{"v*"}},
+			"release": map[string]interface{}{"types": []string{"released"}},
+		}, generator.getWorkflowTriggers(m, "production"))
+	})
+
+	t.Run("spec schedule adds to the default triggers", func(t *testing.T) {
+		m := newTriggerManifest(nil)
+		m.Spec.Schedule = []string{"0 2 * * *"}
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		triggers := generator.getWorkflowTriggers(m, "default")
+		assert.Contains(t, triggers, "push")
+		assert.Contains(t, triggers, "pull_request")
+		assert.Equal(t, []map[string]string{{"cron": "0 2 * * *"}}, triggers["schedule"])
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "  schedule:\n    - cron: 0 2 * * *\n")
+	})
+
+	t.Run("spec schedule follows configured schedules", func(t *testing.T) {
+		m := newTriggerManifest(&manifest.TriggersConfig{
+			Schedule: []manifest.ScheduleTrigger{{Cron: "0 3 * * 0"}},
+		})
+		m.Spec.Schedule = []string{"0 2 * * *"}
+
+		triggers := generator.getWorkflowTriggers(m, "default")
+		assert.Equal(t, []map[string]string{{"cron": "0 3 * * 0"}, {"cron": "0 2 * * *"}}, triggers["schedule"])
+	})
+
+	t.Run("malformed spec schedule is rejected", func(t *testing.T) {
+		m := newTriggerManifest(nil)
+		m.Spec.Schedule = []string{"every night"}
+		err := manifest.ValidateManifest(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected 5 fields")
+	})
+
+	t.Run("empty cron is rejected", func(t *testing.T) {
+		m := newTriggerManifest(&manifest.TriggersConfig{
+			Schedule: []manifest.ScheduleTrigger{{Cron: ""}},
+		})
+		err := manifest.ValidateManifest(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cron cannot be empty")
+	})
 }
 
 func TestWorkflowGenerator_SubstituteTemplate(t *testing.T) {
@@ -390,6 +700,19 @@ func TestWorkflowGenerator_SubstituteTemplate(t *testing.T) {
 		_, err := generator.substituteTemplate("{{ .Invalid", inputs)
 		assert.Error(t, err)
 	})
+
+	t.Run("cached templates substitute fresh inputs", func(t *testing.T) {
+		templateStr := "node-version: {{ .Inputs.nodeVersion }}"
+
+		first, err := generator.substituteTemplate(templateStr, map[string]interface{}{"nodeVersion": "18"})
+		require.NoError(t, err)
+		second, err := generator.substituteTemplate(templateStr, map[string]interface{}{"nodeVersion": "20"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "node-version: 18", first)
+		assert.Equal(t, "node-version: 20", second)
+		assert.Contains(t, generator.parsedTemplates, templateStr)
+	})
 }
 
 func TestWorkflowGenerator_Integration(t *testing.T) {
@@ -575,6 +898,55 @@ func TestWorkflowGenerator_GetRequiredPermissions(t *testing.T) {
 			expected:    map[string]string{},
 			description: "Should not add permissions when container building is not specified",
 		},
+		{
+			name: "gosec scanning enabled",
+			inputs: map[string]interface{}{
+				"security": map[string]interface{}{
+					"gosec": map[string]interface{}{"enabled": true},
+				},
+				"goVersion": "1.22",
+			},
+			expected: map[string]string{
+				"security-events": "write",
+				"contents":        "read",
+			},
+			description: "Should add security permissions when gosec SARIF upload is enabled",
+		},
+		{
+			name: "nested trivy scanning enabled",
+			inputs: map[string]interface{}{
+				"security": map[string]interface{}{
+					"trivy": map[string]interface{}{"enabled": true},
+				},
+			},
+			expected: map[string]string{
+				"security-events": "write",
+				"contents":        "read",
+			},
+			description: "Should add security permissions for security.trivy.enabled",
+		},
+		{
+			name: "nested container building enabled",
+			inputs: map[string]interface{}{
+				"container": map[string]interface{}{"enabled": true},
+			},
+			expected: map[string]string{
+				"packages": "write",
+				"contents": "read",
+			},
+			description: "Should add package permissions for container.enabled",
+		},
+		{
+			name: "nested features disabled",
+			inputs: map[string]interface{}{
+				"security": map[string]interface{}{
+					"trivy": map[string]interface{}{"enabled": false},
+				},
+				"container": map[string]interface{}{"enabled": false},
+			},
+			expected:    map[string]string{},
+			description: "Should not add permissions when nested features are disabled",
+		},
 	}
 
 	for _, tt := range tests {
@@ -585,6 +957,62 @@ func TestWorkflowGenerator_GetRequiredPermissions(t *testing.T) {
 	}
 }
 
+func TestWorkflowGenerator_GetLegacyPermissions(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	tests := []struct {
+		name     string
+		inputs   map[string]interface{}
+		expected map[string]string
+	}{
+		{
+			name:     "flat trivy",
+			inputs:   map[string]interface{}{"trivyScanEnabled": true},
+			expected: map[string]string{"security-events": "write", "contents": "read"},
+		},
+		{
+			name: "nested trivy",
+			inputs: map[string]interface{}{
+				"security": map[string]interface{}{"trivy": map[string]interface{}{"enabled": true}},
+			},
+			expected: map[string]string{"security-events": "write", "contents": "read"},
+		},
+		{
+			name: "nested bandit",
+			inputs: map[string]interface{}{
+				"security": map[string]interface{}{"bandit": map[string]interface{}{"enabled": true}},
+			},
+			expected: map[string]string{"security-events": "write", "contents": "read"},
+		},
+		{
+			name:     "flat container",
+			inputs:   map[string]interface{}{"containerEnabled": true},
+			expected: map[string]string{"packages": "write", "contents": "read"},
+		},
+		{
+			name:     "nested container",
+			inputs:   map[string]interface{}{"container": map[string]interface{}{"enabled": true}},
+			expected: map[string]string{"packages": "write", "contents": "read"},
+		},
+		{
+			name:     "non-boolean nested value",
+			inputs:   map[string]interface{}{"container": map[string]interface{}{"enabled": "true"}},
+			expected: map[string]string{},
+		},
+		{
+			name:     "nested value that isn't a map",
+			inputs:   map[string]interface{}{"security": "trivy"},
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, generator.getLegacyPermissions(tt.inputs))
+		})
+	}
+}
+
 func TestWorkflowGenerator_AddEventDrivenContext(t *testing.T) {
 	generator := NewWorkflowGenerator("")
 
@@ -889,3 +1317,3284 @@ func TestWorkflowGenerator_ReplaceGitHubActionsPlaceholders(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkflowGenerator_FetchDepth(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(inputs map[string]interface{}) *manifest.Manifest {
+		return testManifest("go-service", inputs)
+	}
+
+	t.Run("defaults to shallow checkout", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(map[string]interface{}{}), "default")
+		require.NoError(t, err)
+
+		assert.Contains(t, workflow, "fetch-depth: \"1\"")
+	})
+
+	t.Run("auto renders release conditional expression", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(map[string]interface{}{
+			"fetchDepth": "auto",
+		}), "default")
+		require.NoError(t, err)
+
+		assert.Contains(t, workflow, models.FetchDepthReleaseExpression)
+		assert.NotContains(t, workflow, "fetch-depth: auto")
+	})
+
+	t.Run("explicit value is passed through", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(map[string]interface{}{
+			"fetchDepth": "0",
+		}), "default")
+		require.NoError(t, err)
+
+		assert.Contains(t, workflow, "fetch-depth: \"0\"")
+	})
+}
+
+func TestWorkflowGenerator_StepTimeouts(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	t.Run("go-service test step has default timeout", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{})
+		steps := generateTestSteps(t, generator, m, "default")
+
+		assert.Equal(t, 15, requireStep(t, steps, "Run tests").TimeoutMins)
+		assert.Equal(t, 10, requireStep(t, steps, "Build service").TimeoutMins)
+		assert.Equal(t, 10, requireStep(t, steps, "Run Trivy vulnerability scanner").TimeoutMins)
+	})
+
+	t.Run("timeouts input overrides defaults", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{
+			"timeouts": map[string]interface{}{
+				"test": 30,
+			},
+		})
+		steps := generateTestSteps(t, generator, m, "default")
+
+		assert.Equal(t, 30, requireStep(t, steps, "Run tests").TimeoutMins)
+		assert.Equal(t, 10, requireStep(t, steps, "Build service").TimeoutMins)
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "timeout-minutes: 30")
+	})
+
+	t.Run("timeouts accept durations", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{
+			"timeouts": map[string]interface{}{
+				"test":  "1h30m",
+				"build": "5",
+			},
+		})
+		steps := generateTestSteps(t, generator, m, "default")
+
+		assert.Equal(t, 90, requireStep(t, steps, "Run tests").TimeoutMins)
+		assert.Equal(t, 5, requireStep(t, steps, "Build service").TimeoutMins)
+	})
+
+	t.Run("invalid duration fails generation", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{
+			"timeouts": map[string]interface{}{
+				"test": "soon",
+			},
+		})
+
+		_, err := generator.GenerateWorkflow(m, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "input 'timeouts.test' must be a duration")
+	})
+}
+
+func TestWorkflowGenerator_ExplainWorkflow(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "explain-service",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "go-service",
+			Environments: map[string]manifest.EnvironmentConfig{
+				"production": {
+					Inputs: map[string]interface{}{
+						"container": map[string]interface{}{"enabled": true},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("default environment", func(t *testing.T) {
+		explanation, err := generator.ExplainWorkflow(m, "default")
+		require.NoError(t, err)
+
+		assert.Equal(t, "default", explanation.Environment)
+		assert.Contains(t, explanation.Triggers, "pull_request")
+		assert.True(t, explanation.SecurityEnabled)
+		assert.False(t, explanation.ContainerEnabled)
+		assert.NotContains(t, explanation.Permissions, "packages")
+	})
+
+	t.Run("production environment", func(t *testing.T) {
+		explanation, err := generator.ExplainWorkflow(m, "production")
+		require.NoError(t, err)
+
+		assert.Contains(t, explanation.Triggers, "release")
+		assert.True(t, explanation.ContainerEnabled)
+		assert.True(t, explanation.ContainerPush)
+		assert.Equal(t, "write", explanation.Permissions["packages"])
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		invalid := *m
+		invalid.Spec.Template = "unknown"
+		_, err := generator.ExplainWorkflow(&invalid, "default")
+		assert.Error(t, err)
+	})
+}
+
+func TestWorkflowGenerator_ExplainSteps(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "explain-steps",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "node-app",
+			Inputs: map[string]interface{}{
+				"security": map[string]interface{}{
+					"trivy": map[string]interface{}{"enabled": true, "severity": "CRITICAL"},
+				},
+			},
+			CustomSteps: []manifest.CustomStep{
+				{Name: "Lint", Position: "after:test", Run: "npm run lint", If: "github.event_name == 'push'"},
+			},
+		},
+	}
+
+	steps, err := generator.ExplainSteps(m, "default")
+	require.NoError(t, err)
+
+	explained := make(map[string]StepExplanation, len(steps))
+	for _, step := range steps {
+		explained[step.Name] = step
+	}
+
+	t.Run("security scan condition follows trivy", func(t *testing.T) {
+		scan := explained["Run Trivy vulnerability scanner"]
+		assert.Equal(t, "security-scan", scan.ID)
+		assert.Equal(t, StepSourceTemplate, scan.Source)
+		assert.Equal(t, "true", scan.If)
+		assert.Equal(t, map[string]interface{}{
+			"security.trivy.enabled":  true,
+			"security.trivy.severity": "CRITICAL",
+		}, scan.Inputs)
+	})
+
+	t.Run("custom steps keep their condition", func(t *testing.T) {
+		lint := explained["Lint"]
+		assert.Equal(t, StepSourceCustom, lint.Source)
+		assert.Empty(t, lint.ID)
+		assert.Equal(t, "github.event_name == 'push'", lint.If)
+		assert.Empty(t, lint.Inputs)
+	})
+
+	t.Run("pruning drops statically false steps", func(t *testing.T) {
+		pruning := NewWorkflowGenerator("")
+		pruning.EnablePruning()
+
+		pruned, err := pruning.ExplainSteps(m, "default")
+		require.NoError(t, err)
+		assert.Less(t, len(pruned), len(steps))
+		for _, step := range pruned {
+			assert.NotEqual(t, "build-and-push", step.ID, "container steps are off without container.enabled")
+		}
+	})
+}
+
+func TestWorkflowGenerator_DefaultEnvironments(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(environments map[string]manifest.EnvironmentConfig) *manifest.Manifest {
+		m := testManifest("node-app", nil)
+		m.Spec.Environments = environments
+		return m
+	}
+
+	t.Run("unconfigured environment falls back to the template default", func(t *testing.T) {
+		inputs := generator.getEffectiveInputs(newManifest(nil), "production")
+		assert.Equal(t, "20", inputs["nodeVersion"])
+		assert.Equal(t, "npm run test:all", inputs["testCommand"])
+	})
+
+	t.Run("manifest environment replaces the template default", func(t *testing.T) {
+		m := newManifest(map[string]manifest.EnvironmentConfig{
+			"production": {Inputs: map[string]interface{}{"testCommand": "npm run test:prod"}},
+		})
+		inputs := generator.getEffectiveInputs(m, "production")
+		assert.Equal(t, "18", inputs["nodeVersion"])
+		assert.Equal(t, "npm run test:prod", inputs["testCommand"])
+	})
+
+	t.Run("template default keeps the manifest's inputs", func(t *testing.T) {
+		m := newManifest(nil)
+		m.Spec.Inputs = map[string]interface{}{"testCommand": "make test"}
+		inputs := generator.getEffectiveInputs(m, "production")
+		assert.Equal(t, "make test", inputs["testCommand"])
+		assert.Equal(t, "20", inputs["nodeVersion"])
+	})
+
+	t.Run("java commands follow the package manager", func(t *testing.T) {
+		m := testManifest("java-app", map[string]interface{}{"packageManager": "gradle"})
+
+		steps := generateTestSteps(t, generator, m, "default")
+		assert.Equal(t, "./gradlew test", requireStep(t, steps, "test").Run)
+		assert.Equal(t, "./gradlew build", requireStep(t, steps, "build").Run)
+
+		steps = generateTestSteps(t, generator, m, "staging")
+		assert.Equal(t, "./gradlew check", requireStep(t, steps, "test").Run)
+
+		steps = generateTestSteps(t, generator, testManifest("java-app", nil), "production")
+		assert.Equal(t, "mvn -B verify -Pci", requireStep(t, steps, "test").Run)
+		assert.Equal(t, "mvn -B package", requireStep(t, steps, "build").Run)
+	})
+
+	t.Run("default workflow ignores template environments", func(t *testing.T) {
+		inputs := generator.getEffectiveInputs(newManifest(nil), "default")
+		assert.Equal(t, "npm test", inputs["testCommand"])
+	})
+
+	t.Run("environments without a template default use the base inputs", func(t *testing.T) {
+		content, err := generator.GenerateWorkflow(newManifest(nil), "qa")
+		require.NoError(t, err)
+		assert.Contains(t, content, "run: npm test\n")
+	})
+}
+
+func TestWorkflowGenerator_SkipIf(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(skipIf string) *manifest.Manifest {
+		m := testManifest("node-app", nil)
+		m.Spec.SkipIf = skipIf
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		skipIf   string
+		expected string
+	}{
+		{"bare condition", "github.repository != 'acme/api'", "${{ !(github.repository != 'acme/api') }}"},
+		{"wrapped condition", "${{ github.repository != 'acme/api' }}", "${{ !(github.repository != 'acme/api') }}"},
+		{"unset", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newManifest(tt.skipIf)
+			require.NoError(t, manifest.ValidateManifest(m))
+
+			content, err := generator.GenerateWorkflow(m, "default")
+			require.NoError(t, err)
+
+			var workflow GitHubActionsWorkflow
+			require.NoError(t, yaml.Unmarshal([]byte(content), &workflow))
+			assert.Equal(t, tt.expected, workflow.Jobs[ManagedJobID].If)
+		})
+	}
+
+	t.Run("unclosed expression is rejected", func(t *testing.T) {
+		err := manifest.ValidateManifest(newManifest("${{ github.repository != 'acme/api'"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid skipIf")
+	})
+}
+
+func TestWorkflowGenerator_EnvironmentJobDefaults(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func() *manifest.Manifest {
+		return &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata: &manifest.ManifestMetadata{
+				Name: "deploy-app",
+			},
+			Spec: manifest.ManifestSpec{
+				Template: "node-app",
+				Environments: map[string]manifest.EnvironmentConfig{
+					"production": {},
+				},
+			},
+		}
+	}
+
+	t.Run("concurrency is omitted by default", func(t *testing.T) {
+		m := newManifest()
+		assert.Nil(t, generator.getConcurrency(m, "default"))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.NotContains(t, workflow, "concurrency:")
+		assert.NotContains(t, workflow, "cancel-in-progress")
+	})
+
+	t.Run("default uses short timeout and cancels in progress", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Concurrency = &manifest.ConcurrencyConfig{Group: "${{ github.workflow }}-${{ github.ref }}"}
+		concurrency := generator.getConcurrency(m, "default")
+		assert.True(t, concurrency.CancelInProgress)
+		assert.Equal(t, config.Config.Jobs.DefaultTimeout, generator.getJobTimeout(m, "default"))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "cancel-in-progress: true")
+		assert.Contains(t, workflow, "timeout-minutes: 30")
+	})
+
+	t.Run("production uses longer timeout and no cancellation", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Concurrency = &manifest.ConcurrencyConfig{Group: "${{ github.workflow }}-${{ github.ref }}"}
+		concurrency := generator.getConcurrency(m, "production")
+		assert.False(t, concurrency.CancelInProgress)
+		assert.Equal(t, config.Config.Jobs.ProductionTimeout, generator.getJobTimeout(m, "production"))
+
+		workflow, err := generator.GenerateWorkflow(m, "production")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "cancel-in-progress: false")
+		assert.Contains(t, workflow, "timeout-minutes: 60")
+	})
+
+	t.Run("explicit values take precedence", func(t *testing.T) {
+		m := newManifest()
+		cancel := true
+		timeout := 45
+		m.Spec.Concurrency = &manifest.ConcurrencyConfig{
+			Group:            "deploy-${{ github.ref }}",
+			CancelInProgress: &cancel,
+		}
+		m.Spec.TimeoutMinutes = &timeout
+
+		concurrency := generator.getConcurrency(m, "production")
+		assert.Equal(t, "deploy-${{ github.ref }}", concurrency.Group)
+		assert.True(t, concurrency.CancelInProgress)
+		assert.Equal(t, 45, generator.getJobTimeout(m, "production"))
+	})
+
+	t.Run("explicit timeout applies to every generated job", func(t *testing.T) {
+		m := newManifest()
+		timeout := 15
+		m.Spec.TimeoutMinutes = &timeout
+		m.Spec.Jobs = map[string]manifest.JobSpec{
+			"deploy": {Needs: []string{"build"}, Steps: []manifest.CustomStep{{Name: "Deploy", Run: "./deploy.sh"}}},
+		}
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "production")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]map[string]interface{} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		assert.Equal(t, 15, parsed.Jobs["build"]["timeout-minutes"])
+		assert.Equal(t, 15, parsed.Jobs["deploy"]["timeout-minutes"])
+	})
+
+	t.Run("custom group expression renders verbatim", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Concurrency = &manifest.ConcurrencyConfig{
+			Group: "pr-${{ github.event.pull_request.number || github.ref }}",
+		}
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "concurrency:\n  group: pr-${{ github.event.pull_request.number || github.ref }}\n  cancel-in-progress: true\n")
+	})
+
+	t.Run("default timeout can be disabled", func(t *testing.T) {
+		m := newManifest()
+		noTimeoutGenerator := NewWorkflowGenerator("")
+		noTimeoutGenerator.DisableDefaultJobTimeout()
+
+		assert.Equal(t, 0, noTimeoutGenerator.getJobTimeout(m, "production"))
+
+		workflow, err := noTimeoutGenerator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]map[string]interface{} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		assert.NotContains(t, parsed.Jobs["build"], "timeout-minutes")
+
+		// An explicit manifest timeout still applies
+		timeout := 20
+		m.Spec.TimeoutMinutes = &timeout
+		assert.Equal(t, 20, noTimeoutGenerator.getJobTimeout(m, "default"))
+	})
+}
+
+func TestWorkflowGenerator_WorkflowEnv(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "env-service",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "go-service",
+			Env: map[string]string{
+				"GO_VERSION":   "1.22",
+				"GITHUB_TOKEN": "GITHUB_TOKEN_PLACEHOLDER",
+			},
+		},
+	}
+
+	t.Run("renders top-level env with resolved placeholders", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var parsed map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+
+		env, ok := parsed["env"].(map[string]interface{})
+		require.True(t, ok, "workflow should have a top-level env block")
+		assert.Equal(t, "1.22", env["GO_VERSION"])
+		assert.Equal(t, "${{ secrets.GITHUB_TOKEN }}", env["GITHUB_TOKEN"])
+	})
+
+	t.Run("omits env when not configured", func(t *testing.T) {
+		noEnv := *m
+		noEnv.Spec.Env = nil
+
+		noHashGenerator := NewWorkflowGenerator("")
+		noHashGenerator.DisableManifestHash()
+
+		workflow, err := noHashGenerator.GenerateWorkflow(&noEnv, "default")
+		require.NoError(t, err)
+		assert.NotContains(t, workflow, "\nenv:")
+	})
+}
+
+func TestWorkflowGenerator_ManifestHashEnv(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(goVersion string) *manifest.Manifest {
+		return &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata: &manifest.ManifestMetadata{
+				Name: "traced-service",
+			},
+			Spec: manifest.ManifestSpec{
+				Template: "go-service",
+				Inputs:   map[string]interface{}{"goVersion": goVersion},
+				Environments: map[string]manifest.EnvironmentConfig{
+					"production": {},
+				},
+			},
+		}
+	}
+
+	workflowEnv := func(t *testing.T, generator *WorkflowGenerator, m *manifest.Manifest, environment string) map[string]string {
+		t.Helper()
+		workflowYAML, err := generator.GenerateWorkflow(m, environment)
+		require.NoError(t, err)
+
+		var workflow GitHubActionsWorkflow
+		require.NoError(t, yaml.Unmarshal([]byte(workflowYAML), &workflow))
+		return workflow.Env
+	}
+
+	hash, err := ManifestHash(newManifest("1.22"))
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{16}$`, hash)
+
+	t.Run("records a stable hash in every environment", func(t *testing.T) {
+		assert.Equal(t, hash, workflowEnv(t, generator, newManifest("1.22"), "default")[ManifestHashEnv])
+		assert.Equal(t, hash, workflowEnv(t, generator, newManifest("1.22"), "production")[ManifestHashEnv])
+	})
+
+	t.Run("changes with the manifest", func(t *testing.T) {
+		changed := workflowEnv(t, generator, newManifest("1.23"), "default")[ManifestHashEnv]
+		assert.NotEmpty(t, changed)
+		assert.NotEqual(t, hash, changed)
+	})
+
+	t.Run("spec.env wins", func(t *testing.T) {
+		m := newManifest("1.22")
+		m.Spec.Env = map[string]string{ManifestHashEnv: "pinned"}
+		assert.Equal(t, "pinned", workflowEnv(t, generator, m, "default")[ManifestHashEnv])
+	})
+
+	t.Run("can be disabled", func(t *testing.T) {
+		noHashGenerator := NewWorkflowGenerator("")
+		noHashGenerator.DisableManifestHash()
+		assert.NotContains(t, workflowEnv(t, noHashGenerator, newManifest("1.22"), "default"), ManifestHashEnv)
+	})
+}
+
+func TestWorkflowGenerator_MatrixContainerTags(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(container map[string]interface{}) *manifest.Manifest {
+		return &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata: &manifest.ManifestMetadata{
+				Name: "matrix-service",
+			},
+			Spec: manifest.ManifestSpec{
+				Template: "go-service",
+				Inputs: map[string]interface{}{
+					"container": container,
+				},
+				Matrix: map[string][]string{
+					"goVersion": {"1.21", "1.22"},
+				},
+			},
+		}
+	}
+
+	findBuildPushStep := func(t *testing.T, workflow string) map[string]string {
+		t.Helper()
+		var parsed struct {
+			Jobs map[string]struct {
+				Strategy map[string]interface{} `yaml:"strategy"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+
+		job := parsed.Jobs["build"]
+		require.NotNil(t, job.Strategy, "job should have a strategy")
+		assert.Equal(t, map[string]interface{}{"goVersion": []interface{}{"1.21", "1.22"}}, job.Strategy["matrix"])
+
+		return requireStep(t, parseBuildSteps(t, workflow), "Build and push container image").With
+	}
+
+	t.Run("matrix-aware tags push from every leg", func(t *testing.T) {
+		m := newManifest(map[string]interface{}{
+			"enabled":  true,
+			"imageTag": "${{ github.sha }}-go${{ matrix.goVersion }}",
+		})
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		with := findBuildPushStep(t, workflow)
+		assert.Contains(t, with["tags"], "${{ github.sha }}-go${{ matrix.goVersion }}")
+		assert.Equal(t, "true", with["push"])
+		assert.Contains(t, workflow, "go-version: ${{ matrix.goVersion }}")
+	})
+
+	t.Run("shared tags push only from one leg", func(t *testing.T) {
+		m := newManifest(map[string]interface{}{
+			"enabled": true,
+		})
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		with := findBuildPushStep(t, workflow)
+		assert.Equal(t, "${{ matrix.goVersion == '1.21' }}", with["push"])
+	})
+
+	t.Run("push disabled is not gated", func(t *testing.T) {
+		m := newManifest(map[string]interface{}{
+			"enabled": true,
+			"push":    map[string]interface{}{"enabled": false},
+		})
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		with := findBuildPushStep(t, workflow)
+		assert.Equal(t, "false", with["push"])
+	})
+
+	t.Run("invalid matrix value is rejected", func(t *testing.T) {
+		m := newManifest(map[string]interface{}{"enabled": false})
+		m.Spec.Matrix["goVersion"] = []string{"1.21", "1.10"}
+
+		_, err := generator.GenerateWorkflow(m, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid matrix value")
+	})
+}
+
+func TestGetMatrixPushGate(t *testing.T) {
+	matrix := map[string][]string{
+		"goVersion": {"1.21", "1.22"},
+		"variant":   {"slim", "full"},
+	}
+
+	assert.Equal(t, "matrix.goVersion == '1.21' && matrix.variant == 'slim'", getMatrixPushGate(matrix, "ghcr.io/app:latest"))
+	assert.Equal(t, "matrix.variant == 'slim'", getMatrixPushGate(matrix, "ghcr.io/app:${{ matrix.goVersion }}"))
+	assert.Equal(t, "", getMatrixPushGate(matrix, "ghcr.io/app:${{ matrix.goVersion }}-${{ matrix.variant }}"))
+}
+
+func TestWorkflowGenerator_ContainerTarget(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	t.Run("target is rendered when set", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{
+			"container": map[string]interface{}{"enabled": true, "target": "runtime"},
+		})
+		step := requireStep(t, generateTestSteps(t, generator, m, "default"), "build-and-push")
+
+		assert.Equal(t, "runtime", step.With["target"])
+	})
+
+	t.Run("target is omitted when unset", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{
+			"container": map[string]interface{}{"enabled": true},
+		})
+		step := requireStep(t, generateTestSteps(t, generator, m, "default"), "build-and-push")
+
+		_, exists := step.With["target"]
+		assert.False(t, exists)
+		assert.Equal(t, "Dockerfile", step.With["file"])
+	})
+}
+
+func TestWorkflowGenerator_ContainerPlatforms(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(container map[string]interface{}) *manifest.Manifest {
+		return testManifest("go-service", map[string]interface{}{"container": container})
+	}
+
+	t.Run("platforms are rendered when set", func(t *testing.T) {
+		m := newManifest(map[string]interface{}{
+			"enabled":   true,
+			"platforms": "linux/amd64,linux/arm64,linux/arm/v7",
+		})
+		step := requireStep(t, generateTestSteps(t, generator, m, "default"), "build-and-push")
+
+		assert.Equal(t, "linux/amd64,linux/arm64,linux/arm/v7", step.With["platforms"])
+	})
+
+	t.Run("foreign architectures set up QEMU", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflowStruct(newManifest(map[string]interface{}{
+			"enabled":   true,
+			"platforms": "linux/amd64,linux/arm64",
+		}), "default")
+		require.NoError(t, err)
+
+		steps := workflow.Jobs[ManagedJobID].Steps
+		qemu := requireStep(t, steps, "Set up QEMU")
+		assert.Equal(t, templates.GitHubActionVersions.DockerSetupQEMU, qemu.Uses)
+		assert.Equal(t, "linux/amd64,linux/arm64", qemu.With["platforms"])
+		assert.Less(t, slices.Index(stepNames(steps), "Set up QEMU"), slices.Index(stepNames(steps), "Set up Docker Buildx"))
+	})
+
+	t.Run("runner architecture builds without QEMU", func(t *testing.T) {
+		for _, platforms := range []string{"", "linux/amd64"} {
+			workflow, err := generator.GenerateWorkflowStruct(newManifest(map[string]interface{}{
+				"enabled":   true,
+				"platforms": platforms,
+			}), "default")
+			require.NoError(t, err)
+			assert.NotContains(t, stepNames(workflow.Jobs[ManagedJobID].Steps), "Set up QEMU", "platforms %q", platforms)
+		}
+	})
+
+	t.Run("platforms default to the runner's platform", func(t *testing.T) {
+		m := newManifest(map[string]interface{}{"enabled": true})
+		step := requireStep(t, generateTestSteps(t, generator, m, "default"), "build-and-push")
+
+		_, exists := step.With["platforms"]
+		assert.False(t, exists)
+	})
+
+	t.Run("invalid platforms are rejected", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			platforms interface{}
+			wantErr   string
+		}{
+			{name: "missing arch", platforms: "linux", wantErr: `invalid container.platforms entry "linux"`},
+			{name: "empty entry", platforms: "linux/amd64,", wantErr: `invalid container.platforms entry ""`},
+			{name: "not a string", platforms: []interface{}{"linux/amd64"}, wantErr: "invalid container.platforms: must be a comma-separated string"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := generator.GenerateWorkflow(newManifest(map[string]interface{}{
+					"enabled":   true,
+					"platforms": tt.platforms,
+				}), "default")
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			})
+		}
+	})
+}
+
+func TestWorkflowGenerator_ContainerPushDefaults(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	findLoginStep := func(t *testing.T, container map[string]interface{}, environment string) WorkflowStep {
+		t.Helper()
+		m := testManifest("go-service", map[string]interface{}{"container": container})
+		m.Spec.Environments = map[string]manifest.EnvironmentConfig{"production": {}}
+		return requireStep(t, generateTestSteps(t, generator, m, environment), "Log in to Container Registry")
+	}
+
+	t.Run("production pushes on production events by default", func(t *testing.T) {
+		step := findLoginStep(t, map[string]interface{}{"enabled": true}, "production")
+
+		assert.NotContains(t, step.If, "<no value>")
+		assert.Contains(t, step.If, "true && (false || true && (github.event_name == 'push'")
+	})
+
+	t.Run("default environment does not push on production events", func(t *testing.T) {
+		step := findLoginStep(t, map[string]interface{}{"enabled": true}, "default")
+
+		assert.NotContains(t, step.If, "<no value>")
+		assert.Contains(t, step.If, "(false || false && (github.event_name == 'push'")
+	})
+
+	t.Run("explicit push settings are kept", func(t *testing.T) {
+		step := findLoginStep(t, map[string]interface{}{
+			"enabled": true,
+			"push":    map[string]interface{}{"onProduction": true},
+		}, "default")
+
+		assert.Contains(t, step.If, "(false || true && (github.event_name == 'push'")
+	})
+
+	t.Run("alwaysPush is read from the build config", func(t *testing.T) {
+		step := findLoginStep(t, map[string]interface{}{
+			"enabled": true,
+			"build":   map[string]interface{}{"alwaysPush": true},
+		}, "default")
+
+		assert.Contains(t, step.If, "(true || false && (github.event_name == 'push'")
+	})
+}
+
+func TestWorkflowGenerator_InsecureRegistry(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	findBuildxStep := func(t *testing.T, container map[string]interface{}) WorkflowStep {
+		t.Helper()
+		m := testManifest("go-service", map[string]interface{}{"container": container})
+		return requireStep(t, generateTestSteps(t, generator, m, "default"), "Set up Docker Buildx")
+	}
+
+	t.Run("insecure registry configures buildkitd", func(t *testing.T) {
+		step := findBuildxStep(t, map[string]interface{}{
+			"enabled":          true,
+			"registry":         "registry.internal:5000",
+			"insecureRegistry": true,
+		})
+
+		config := step.With["buildkitd-config-inline"]
+		assert.Contains(t, config, `[registry."registry.internal:5000"]`)
+		assert.Contains(t, config, "http = true")
+		assert.Contains(t, config, "insecure = true")
+	})
+
+	t.Run("secure registry leaves buildx unconfigured", func(t *testing.T) {
+		step := findBuildxStep(t, map[string]interface{}{
+			"enabled":  true,
+			"registry": "registry.internal:5000",
+		})
+
+		_, exists := step.With["buildkitd-config-inline"]
+		assert.False(t, exists)
+	})
+}
+
+func TestWorkflowGenerator_WorkflowNameTemplate(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := testManifest("go-service", nil)
+	m.Metadata.Name = "payments"
+
+	t.Run("default naming", func(t *testing.T) {
+		name, err := generator.getWorkflowName(m, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "payments", name)
+
+		name, err = generator.getWorkflowName(m, "staging")
+		require.NoError(t, err)
+		assert.Equal(t, "payments (staging)", name)
+	})
+
+	t.Run("template renders metadata and environment", func(t *testing.T) {
+		templated := *m
+		templated.Spec.WorkflowNameTemplate = "{{ .Metadata.Name }} CI ({{ .Environment }})"
+
+		name, err := generator.getWorkflowName(&templated, "staging")
+		require.NoError(t, err)
+		assert.Equal(t, "payments CI (staging)", name)
+
+		workflow, err := generator.GenerateWorkflow(&templated, "staging")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "name: payments CI (staging)")
+	})
+
+	t.Run("template execution errors are reported", func(t *testing.T) {
+		templated := *m
+		templated.Spec.WorkflowNameTemplate = "{{ .Metadata.Missing }}"
+
+		_, err := generator.getWorkflowName(&templated, "staging")
+		assert.Error(t, err)
+	})
+}
+
+func TestWorkflowGenerator_TemplateHashHeader(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := testManifest("node-app", nil)
+
+	hash, err := generator.TemplateHash("node-app")
+	require.NoError(t, err)
+	assert.Len(t, hash, 16)
+
+	workflow, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+	assert.Equal(t, hash, TemplateHashFromWorkflow(workflow))
+
+	otherHash, err := generator.TemplateHash("go-service")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, otherHash)
+
+	assert.Empty(t, TemplateHashFromWorkflow("name: hand-written\n# gpgen-template-hash: abc\n"))
+}
+
+func TestWorkflowGenerator_JobDefaults(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(defaults *manifest.JobDefaults) *manifest.Manifest {
+		m := testManifest("go-service", nil)
+		m.Spec.Defaults = defaults
+		return m
+	}
+
+	t.Run("working directory serializes under defaults.run", func(t *testing.T) {
+		m := newManifest(&manifest.JobDefaults{
+			Run: &manifest.RunDefaults{WorkingDirectory: "services/api"},
+		})
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]struct {
+				Defaults struct {
+					Run map[string]string `yaml:"run"`
+				} `yaml:"defaults"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		assert.Equal(t, map[string]string{"working-directory": "services/api"}, parsed.Jobs["build"].Defaults.Run)
+	})
+
+	t.Run("defaults are omitted when unset", func(t *testing.T) {
+		assert.Nil(t, generator.getJobDefaults(newManifest(nil)))
+		assert.Nil(t, generator.getJobDefaults(newManifest(&manifest.JobDefaults{Run: &manifest.RunDefaults{}})))
+
+		workflow, err := generator.GenerateWorkflow(newManifest(nil), "default")
+		require.NoError(t, err)
+		assert.NotContains(t, workflow, "defaults:")
+	})
+}
+
+func TestWorkflowGenerator_InstallStepResilience(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	findInstallStep := func(t *testing.T, templateName string, inputs map[string]interface{}) WorkflowStep {
+		t.Helper()
+		m := testManifest(templateName, inputs)
+		return requireStep(t, generateTestSteps(t, generator, m, "default"), "Install dependencies")
+	}
+
+	t.Run("defaults leave install untouched", func(t *testing.T) {
+		step := findInstallStep(t, "node-app", map[string]interface{}{})
+
+		assert.Equal(t, 0, step.TimeoutMins)
+		assert.Equal(t, "npm ci", step.Run)
+	})
+
+	t.Run("install timeout renders on the install step", func(t *testing.T) {
+		step := findInstallStep(t, "node-app", map[string]interface{}{"installTimeout": "10m"})
+		assert.Equal(t, 10, step.TimeoutMins)
+
+		step = findInstallStep(t, "python-app", map[string]interface{}{"installTimeout": 20})
+		assert.Equal(t, 20, step.TimeoutMins)
+	})
+
+	t.Run("timeouts input takes precedence", func(t *testing.T) {
+		step := findInstallStep(t, "node-app", map[string]interface{}{
+			"installTimeout": "10m",
+			"timeouts":       map[string]interface{}{"install": "5m"},
+		})
+		assert.Equal(t, 5, step.TimeoutMins)
+	})
+
+	t.Run("install retries wrap the command", func(t *testing.T) {
+		step := findInstallStep(t, "python-app", map[string]interface{}{"installRetries": 3})
+
+		assert.Contains(t, step.Run, "until pip install -r requirements.txt; do")
+		assert.Contains(t, step.Run, `if [ "$attempt" -gt 3 ]; then`)
+	})
+}
+
+func TestWorkflowGenerator_CustomStepOnEnvironment(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	renderCustomStep := func(t *testing.T, customStep manifest.CustomStep) WorkflowStep {
+		t.Helper()
+		m := testManifest("go-service", nil)
+		m.Spec.CustomSteps = []manifest.CustomStep{customStep}
+		return requireStep(t, generateTestSteps(t, generator, m, "default"), customStep.Name)
+	}
+
+	t.Run("production expands to tag and release events", func(t *testing.T) {
+		step := renderCustomStep(t, manifest.CustomStep{
+			Name:          "Publish release notes",
+			Position:      "after:build",
+			Run:           "make release-notes",
+			OnEnvironment: "production",
+		})
+
+		assert.Equal(t, "(github.event_name == 'push' && startsWith(github.ref, 'refs/tags/') || github.event_name == 'release')", step.If)
+	})
+
+	t.Run("combines with an explicit if", func(t *testing.T) {
+		step := renderCustomStep(t, manifest.CustomStep{
+			Name:          "Preview deploy",
+			Position:      "after:build",
+			Run:           "make preview",
+			OnEnvironment: "staging",
+			If:            "${{ github.actor != 'dependabot[bot]' }}",
+		})
+
+		assert.Equal(t, "(github.event_name == 'pull_request' || github.event_name == 'push' && !startsWith(github.ref, 'refs/tags/')) && (github.actor != 'dependabot[bot]')", step.If)
+	})
+}
+
+func TestWorkflowGenerator_JobEnvironment(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "gated-service",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "go-service",
+			Environments: map[string]manifest.EnvironmentConfig{
+				"production": {
+					Environment: &manifest.DeploymentEnvironment{
+						Name: "production",
+						URL:  "https://api.example.com",
+					},
+				},
+				"staging": {},
+			},
+		},
+	}
+
+	workflow, err := generator.GenerateWorkflow(m, "production")
+	require.NoError(t, err)
+	assert.Contains(t, workflow, "    environment:\n      name: production\n      url: https://api.example.com\n")
+
+	assert.Nil(t, generator.getJobEnvironment(m, "staging"))
+	assert.Nil(t, generator.getJobEnvironment(m, "default"))
+}
+
+func TestWorkflowGenerator_TrivyScans(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	generate := func(scans []interface{}) ([]WorkflowStep, error) {
+		m := testManifest("go-service", map[string]interface{}{
+			"container": map[string]interface{}{
+				"enabled":   true,
+				"imageName": "scanned-service",
+			},
+			"security": map[string]interface{}{
+				"trivy": map[string]interface{}{
+					"enabled": true,
+					"scans":   scans,
+				},
+			},
+		})
+
+		tmpl, err := generator.templateManager.LoadTemplate("go-service")
+		require.NoError(t, err)
+
+		return generator.generateSteps(tmpl, m, "default", generator.getEffectiveInputs(m, "default"))
+	}
+
+	t.Run("fs and image scans", func(t *testing.T) {
+		steps, err := generate([]interface{}{
+			map[string]interface{}{"scanType": "fs"},
+			map[string]interface{}{"scanType": "image"},
+		})
+		require.NoError(t, err)
+
+		index := make(map[string]int)
+		for i, step := range steps {
+			index[step.Name] = i
+		}
+
+		assert.NotContains(t, index, "Run Trivy vulnerability scanner")
+		require.Contains(t, index, "Run Trivy fs vulnerability scan")
+		require.Contains(t, index, "Upload Trivy fs scan results to GitHub Security tab")
+		require.Contains(t, index, "Run Trivy image vulnerability scan")
+		require.Contains(t, index, "Upload Trivy image scan results to GitHub Security tab")
+		require.Contains(t, index, "Build and push container image")
+
+		buildIndex := index["Build and push container image"]
+		assert.Less(t, index["Run Trivy fs vulnerability scan"], buildIndex)
+		assert.Greater(t, index["Run Trivy image vulnerability scan"], buildIndex)
+
+		imageScan := steps[index["Run Trivy image vulnerability scan"]]
+		assert.Equal(t, "ghcr.io/scanned-service:${{ github.sha }}", imageScan.With["image-ref"])
+		assert.Equal(t, "trivy-image-results.sarif", imageScan.With["output"])
+		assert.Contains(t, imageScan.If, "startsWith(github.ref, 'refs/tags/')")
+
+		upload := steps[index["Upload Trivy fs scan results to GitHub Security tab"]]
+		assert.Equal(t, "trivy-fs-results.sarif", upload.With["sarif_file"])
+		assert.Equal(t, "trivy-fs", upload.With["category"])
+	})
+
+	t.Run("every SARIF upload has its own category", func(t *testing.T) {
+		steps, err := generate([]interface{}{
+			map[string]interface{}{"scanType": "fs"},
+			map[string]interface{}{"scanType": "config"},
+			map[string]interface{}{"scanType": "image"},
+		})
+		require.NoError(t, err)
+
+		var categories []string
+		for _, step := range steps {
+			if step.With["sarif_file"] != "" {
+				categories = append(categories, step.With["category"])
+			}
+		}
+		assert.ElementsMatch(t, []string{"trivy-fs", "trivy-config", "trivy-image", "gosec"}, categories)
+	})
+
+	t.Run("first scan keeps the default step IDs", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{
+			"security": map[string]interface{}{
+				"trivy": map[string]interface{}{
+					"enabled": true,
+					"scans": []interface{}{
+						map[string]interface{}{"scanType": "fs"},
+						map[string]interface{}{"scanType": "config"},
+					},
+				},
+			},
+			"timeouts": map[string]interface{}{"security-scan": 7},
+		})
+		m.Spec.Overrides = map[string]manifest.StepOverride{
+			"security-scan": {Env: map[string]string{"TRIVY_DEBUG": "true"}},
+		}
+		m.Spec.CustomSteps = []manifest.CustomStep{
+			{Name: "After scan", Position: "after:security-scan", Run: "echo scanned"},
+		}
+		steps := generateTestSteps(t, generator, m, "default")
+
+		fs := requireStep(t, steps, "Run Trivy fs vulnerability scan")
+		assert.Equal(t, "security-scan", fs.templateID)
+		assert.Equal(t, 7, fs.TimeoutMins)
+		assert.Equal(t, "true", fs.Env["TRIVY_DEBUG"])
+		assert.Equal(t, "upload-sarif", requireStep(t, steps, "Upload Trivy fs scan results to GitHub Security tab").templateID)
+		assert.Equal(t, "security-scan-config", requireStep(t, steps, "Run Trivy config vulnerability scan").templateID)
+
+		after := requireStep(t, steps, "After scan")
+		for i, step := range steps {
+			if step.Name == after.Name {
+				assert.Equal(t, "Run Trivy fs vulnerability scan", steps[i-1].Name)
+			}
+		}
+	})
+
+	t.Run("invalid scan type", func(t *testing.T) {
+		_, err := generate([]interface{}{
+			map[string]interface{}{"scanType": "sbom"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "scanType")
+	})
+}
+
+func TestWorkflowGenerator_TrivyFormat(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	generate := func(trivy map[string]interface{}) ([]WorkflowStep, map[string]string, error) {
+		m := testManifest("go-service", map[string]interface{}{
+			"security": map[string]interface{}{
+				"trivy": trivy,
+				"gosec": map[string]interface{}{"enabled": false},
+			},
+		})
+
+		tmpl, err := generator.templateManager.LoadTemplate("go-service")
+		require.NoError(t, err)
+
+		inputs := generator.getEffectiveInputs(m, "default")
+		if err := generator.validateInputs(tmpl, m, inputs); err != nil {
+			return nil, nil, err
+		}
+		steps, err := generator.generateSteps(tmpl, m, "default", inputs)
+		return steps, generator.getRequiredPermissions(tmpl, inputs), err
+	}
+
+	t.Run("sarif keeps the upload", func(t *testing.T) {
+		steps, permissions, err := generate(map[string]interface{}{"enabled": true, "format": "sarif"})
+		require.NoError(t, err)
+
+		scan := requireStep(t, steps, "Run Trivy vulnerability scanner")
+		assert.Equal(t, "sarif", scan.With["format"])
+		assert.Equal(t, "trivy-results.sarif", scan.With["output"])
+		assert.Contains(t, stepNames(steps), "Upload Trivy scan results to GitHub Security tab")
+		assert.Equal(t, "write", permissions["security-events"])
+	})
+
+	t.Run("table omits the upload", func(t *testing.T) {
+		steps, permissions, err := generate(map[string]interface{}{"enabled": true, "format": "table"})
+		require.NoError(t, err)
+
+		scan := requireStep(t, steps, "Run Trivy vulnerability scanner")
+		assert.Equal(t, "table", scan.With["format"])
+		assert.Equal(t, "trivy-results.txt", scan.With["output"])
+		assert.NotContains(t, stepNames(steps), "Upload Trivy scan results to GitHub Security tab")
+		assert.NotContains(t, permissions, "security-events")
+	})
+
+	t.Run("json applies to configured scans", func(t *testing.T) {
+		steps, _, err := generate(map[string]interface{}{
+			"enabled": true,
+			"format":  "json",
+			"scans": []interface{}{
+				map[string]interface{}{"scanType": "fs"},
+			},
+		})
+		require.NoError(t, err)
+
+		scan := requireStep(t, steps, "Run Trivy fs vulnerability scan")
+		assert.Equal(t, "json", scan.With["format"])
+		assert.Equal(t, "trivy-fs-results.json", scan.With["output"])
+		assert.NotContains(t, stepNames(steps), "Upload Trivy fs scan results to GitHub Security tab")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, _, err := generate(map[string]interface{}{"enabled": true, "format": "html"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "security.trivy.format")
+	})
+}
+
+func TestWorkflowGenerator_LocalActions(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "local-actions",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "node-app",
+			CustomSteps: []manifest.CustomStep{
+				{
+					Name:     "shared-setup",
+					Position: "after:setup-node",
+					Uses:     "./.github/actions/setup",
+					With: map[string]string{
+						"cache-key": "node-modules",
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, manifest.ValidateManifest(m))
+
+	t.Run("local action step renders", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		assert.Contains(t, workflow, "uses: ./.github/actions/setup")
+		assert.Contains(t, workflow, "cache-key: node-modules")
+	})
+
+	t.Run("scaffold writes a composite action", func(t *testing.T) {
+		rootDir := t.TempDir()
+
+		written, err := generator.ScaffoldLocalActions(m, []string{"default"}, rootDir)
+		require.NoError(t, err)
+
+		actionFile := filepath.Join(rootDir, ".github", "actions", "setup", "action.yml")
+		assert.Equal(t, []string{actionFile}, written)
+
+		content, err := os.ReadFile(actionFile)
+		require.NoError(t, err)
+
+		var action map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(content, &action))
+		assert.Equal(t, "setup", action["name"])
+		assert.Contains(t, action["inputs"], "cache-key")
+		assert.Equal(t, "composite", action["runs"].(map[string]interface{})["using"])
+
+		// An existing action is left alone
+		written, err = generator.ScaffoldLocalActions(m, []string{"default"}, rootDir)
+		require.NoError(t, err)
+		assert.Empty(t, written)
+	})
+}
+
+func TestWorkflowGenerator_BanditScan(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	generate := func(t *testing.T, bandit map[string]interface{}) []WorkflowStep {
+		t.Helper()
+		m := testManifest("python-app", map[string]interface{}{
+			"security": map[string]interface{}{
+				"trivy": map[string]interface{}{
+					"enabled": false,
+				},
+				"bandit": bandit,
+			},
+		})
+
+		return generateTestSteps(t, generator, m, "default")
+	}
+
+	t.Run("enabled bandit emits scan and upload", func(t *testing.T) {
+		steps := generate(t, map[string]interface{}{
+			"enabled":    true,
+			"args":       "-ll --skip B101",
+			"configFile": "bandit.yaml",
+		})
+
+		scan := requireStep(t, steps, "Run bandit security scanner")
+		assert.Equal(t, "true", scan.If)
+		assert.Contains(t, scan.Run, "bandit -r . -f sarif -o bandit-results.sarif --exit-zero -c bandit.yaml -ll --skip B101")
+
+		upload := requireStep(t, steps, "Upload bandit scan results to GitHub Security tab")
+		assert.Equal(t, "bandit-results.sarif", upload.With["sarif_file"])
+		assert.Equal(t, "true && always()", upload.If)
+	})
+
+	t.Run("bandit alone requires security-events permission", func(t *testing.T) {
+		m := testManifest("python-app", nil)
+		tmpl, err := generator.templateManager.LoadTemplate("python-app")
+		require.NoError(t, err)
+
+		inputs := generator.getEffectiveInputs(m, "default")
+		inputs["security"] = map[string]interface{}{
+			"trivy":  map[string]interface{}{"enabled": false},
+			"bandit": map[string]interface{}{"enabled": true},
+		}
+		assert.Equal(t, "write", generator.getRequiredPermissions(tmpl, inputs)["security-events"])
+	})
+
+	t.Run("disabled bandit is gated off", func(t *testing.T) {
+		steps := generate(t, map[string]interface{}{"enabled": false})
+
+		scan := requireStep(t, steps, "Run bandit security scanner")
+		assert.Equal(t, "false", scan.If)
+		assert.NotContains(t, scan.Run, " -c ")
+	})
+}
+
+func TestWorkflowGenerator_TemplatedUses(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(uses string, inputs map[string]interface{}) *manifest.Manifest {
+		return &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata: &manifest.ManifestMetadata{
+				Name: "pinned-actions",
+			},
+			Spec: manifest.ManifestSpec{
+				Template: "node-app",
+				Inputs:   inputs,
+				CustomSteps: []manifest.CustomStep{
+					{
+						Name:     "image-scan",
+						Position: "after:test",
+						Uses:     uses,
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("action version resolves from an input", func(t *testing.T) {
+		m := newManifest("aquasecurity/trivy-action@{{ .Inputs.trivyVersion }}", map[string]interface{}{
+			"trivyVersion": "0.28.0",
+		})
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "uses: aquasecurity/trivy-action@0.28.0")
+	})
+
+	t.Run("template step uses is substituted", func(t *testing.T) {
+		step, err := generator.processTemplateStep(templates.Step{
+			ID:   "scan",
+			Name: "Scan",
+			Uses: "aquasecurity/trivy-action@{{ .Inputs.trivyVersion }}",
+		}, map[string]interface{}{"trivyVersion": "0.28.0"})
+		require.NoError(t, err)
+		assert.Equal(t, "aquasecurity/trivy-action@0.28.0", step.Uses)
+	})
+
+	t.Run("malformed resolved reference is rejected", func(t *testing.T) {
+		m := newManifest("aquasecurity/trivy-action@{{ .Inputs.trivyVersion }}", map[string]interface{}{
+			"trivyVersion": "",
+		})
+
+		_, err := generator.GenerateWorkflow(m, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid action reference "aquasecurity/trivy-action@"`)
+	})
+
+	t.Run("action reference formats", func(t *testing.T) {
+		assert.NoError(t, validateActionRef("actions/checkout@v4"))
+		assert.NoError(t, validateActionRef("github/codeql-action/upload-sarif@v3"))
+		assert.NoError(t, validateActionRef("./.github/actions/setup"))
+		assert.NoError(t, validateActionRef("docker://alpine:3.20"))
+		assert.Error(t, validateActionRef("actions/checkout"))
+		assert.Error(t, validateActionRef("checkout@v4"))
+		assert.Error(t, validateActionRef("actions/checkout@v4 extra"))
+	})
+}
+
+func TestWorkflowGenerator_StepDefaults(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	stepTimeout := 5
+	defaultTimeout := 20
+	continueOnError := true
+	noContinue := false
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "step-defaults",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "node-app",
+			StepDefaults: &manifest.StepDefaults{
+				ContinueOnError:  &continueOnError,
+				TimeoutMinutes:   &defaultTimeout,
+				Shell:            "bash",
+				WorkingDirectory: "app",
+			},
+			CustomSteps: []manifest.CustomStep{
+				{
+					Name:            "smoke-test",
+					Position:        "after:test",
+					Run:             "npm run smoke",
+					TimeoutMinutes:  &stepTimeout,
+					ContinueOnError: &noContinue,
+				},
+			},
+		},
+	}
+	require.NoError(t, manifest.ValidateManifest(m))
+
+	steps := generateTestSteps(t, generator, m, "default")
+
+	t.Run("steps without a timeout get the default", func(t *testing.T) {
+		checkout := requireStep(t, steps, "Checkout code")
+		assert.Equal(t, 20, checkout.TimeoutMins)
+		require.NotNil(t, checkout.ContinueOnError)
+		assert.True(t, *checkout.ContinueOnError)
+
+		// Shell and working directory only apply to run steps
+		assert.Empty(t, checkout.Shell)
+		assert.Empty(t, checkout.WorkingDirectory)
+
+		install := requireStep(t, steps, "Install dependencies")
+		assert.Equal(t, 20, install.TimeoutMins)
+		assert.Equal(t, "bash", install.Shell)
+		assert.Equal(t, "app", install.WorkingDirectory)
+	})
+
+	t.Run("per-step values win", func(t *testing.T) {
+		assert.Equal(t, config.Config.Languages[config.LanguageNode].DefaultTestTimeout, requireStep(t, steps, "Run tests").TimeoutMins)
+
+		smoke := requireStep(t, steps, "smoke-test")
+		assert.Equal(t, 5, smoke.TimeoutMins)
+		require.NotNil(t, smoke.ContinueOnError)
+		assert.False(t, *smoke.ContinueOnError)
+	})
+
+	t.Run("defaults render in the workflow", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "continue-on-error: true")
+		assert.Contains(t, workflow, "continue-on-error: false")
+		assert.Contains(t, workflow, "working-directory: app")
+	})
+}
+
+func TestWorkflowGenerator_LintContinueOnError(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(inputs map[string]interface{}) *manifest.Manifest {
+		return testManifest("python-app", inputs)
+	}
+
+	t.Run("lint is advisory by default", func(t *testing.T) {
+		m := newManifest(nil)
+
+		lint := requireStep(t, generateTestSteps(t, generator, m, "default"), "lint")
+		require.NotNil(t, lint.ContinueOnError)
+		assert.True(t, *lint.ContinueOnError)
+		assert.Nil(t, requireStep(t, generateTestSteps(t, generator, m, "default"), "test").ContinueOnError)
+	})
+
+	t.Run("failOnLint makes lint blocking", func(t *testing.T) {
+		m := newManifest(map[string]interface{}{"failOnLint": true})
+
+		assert.Nil(t, requireStep(t, generateTestSteps(t, generator, m, "default"), "lint").ContinueOnError)
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.NotContains(t, workflow, "continue-on-error")
+	})
+
+	t.Run("step override wins over the template default", func(t *testing.T) {
+		blocking := false
+		m := newManifest(nil)
+		m.Spec.Overrides = map[string]manifest.StepOverride{
+			"lint": {ContinueOnError: &blocking},
+		}
+
+		lint := requireStep(t, generateTestSteps(t, generator, m, "default"), "lint")
+		require.NotNil(t, lint.ContinueOnError)
+		assert.False(t, *lint.ContinueOnError)
+	})
+}
+
+func TestWorkflowGenerator_ActionVersionOverrides(t *testing.T) {
+	original := templates.GitHubActionVersions
+	defer func() { templates.GitHubActionVersions = original }()
+
+	require.NoError(t, templates.SetActionVersions(map[string]string{"checkout": "actions/checkout@v3"}))
+
+	m := testManifest("go-service", nil)
+
+	workflow, err := NewWorkflowGenerator("").GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	assert.Contains(t, workflow, "uses: actions/checkout@v3")
+	assert.NotContains(t, workflow, "actions/checkout@v4")
+}
+
+func TestWorkflowGenerator_InputTypeCoercion(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m := testManifest("go-service", map[string]interface{}{
+		"containerEnabled": "true",
+		"goVersion":        1.22,
+	})
+
+	inputs := generator.getEffectiveInputs(m, "default")
+	assert.Equal(t, "1.22", inputs["goVersion"])
+	assert.Equal(t, true, inputs["containerEnabled"])
+
+	tmpl, err := generator.templateManager.LoadTemplate("go-service")
+	require.NoError(t, err)
+	permissions := generator.getRequiredPermissions(tmpl, inputs)
+	assert.Equal(t, "write", permissions["packages"])
+
+	workflow, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+	assert.Contains(t, workflow, "packages: write")
+	assert.Contains(t, workflow, "go-version: \"1.22\"")
+
+	t.Run("trailing zero survives parsing", func(t *testing.T) {
+		parsed, err := manifest.ParseManifest([]byte(`apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: test-app
+spec:
+  template: python-app
+  inputs:
+    pythonVersion: 3.10
+    container:
+      enabled: "true"
+`))
+		require.NoError(t, err)
+
+		inputs := generator.getEffectiveInputs(parsed, "default")
+		assert.Equal(t, "3.10", inputs["pythonVersion"])
+
+		workflow, err := generator.GenerateWorkflow(parsed, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "python-version: \"3.10\"")
+		assert.Contains(t, workflow, "packages: write")
+	})
+}
+
+func TestWorkflowGenerator_CrossCompile(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(inputs map[string]interface{}) *manifest.Manifest {
+		return testManifest("go-service", inputs)
+	}
+
+	type parsedWorkflow struct {
+		Jobs map[string]struct {
+			Strategy *struct {
+				Matrix struct {
+					Platform []string            `yaml:"platform"`
+					Include  []map[string]string `yaml:"include"`
+				} `yaml:"matrix"`
+			} `yaml:"strategy"`
+			Steps []WorkflowStep `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+
+	t.Run("enabled produces a platform matrix and per-platform build", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(map[string]interface{}{
+			"crossCompile": true,
+			"platforms":    "linux/amd64,darwin/arm64",
+		}), "default")
+		require.NoError(t, err)
+
+		var parsed parsedWorkflow
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		job := parsed.Jobs["build"]
+
+		require.NotNil(t, job.Strategy)
+		assert.Equal(t, []string{"linux/amd64", "darwin/arm64"}, job.Strategy.Matrix.Platform)
+		assert.Equal(t, []map[string]string{
+			{"platform": "linux/amd64", "goos": "linux", "goarch": "amd64"},
+			{"platform": "darwin/arm64", "goos": "darwin", "goarch": "arm64"},
+		}, job.Strategy.Matrix.Include)
+
+		build := requireStep(t, job.Steps, "Build service")
+		assert.Equal(t, map[string]string{
+			"GOOS":   "${{ matrix.goos }}",
+			"GOARCH": "${{ matrix.goarch }}",
+		}, build.Env)
+
+		upload := requireStep(t, job.Steps, "Upload build artifacts")
+		assert.Empty(t, upload.If)
+		assert.Equal(t, "service-${{ matrix.goos }}-${{ matrix.goarch }}", upload.With["name"])
+	})
+
+	t.Run("disabled keeps a single native build", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(map[string]interface{}{}), "default")
+		require.NoError(t, err)
+
+		var parsed parsedWorkflow
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		job := parsed.Jobs["build"]
+
+		assert.Nil(t, job.Strategy)
+		assert.Empty(t, requireStep(t, job.Steps, "Build service").Env)
+		assert.NotContains(t, stepNames(job.Steps), "Upload build artifacts")
+	})
+
+	t.Run("invalid platform is rejected", func(t *testing.T) {
+		_, err := generator.GenerateWorkflow(newManifest(map[string]interface{}{
+			"crossCompile": true,
+			"platforms":    "linux",
+		}), "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be in GOOS/GOARCH form")
+	})
+}
+
+func TestLintPermissions(t *testing.T) {
+	required := map[string]string{
+		"contents":        "read",
+		"security-events": "write",
+	}
+
+	tests := []struct {
+		name     string
+		declared *manifest.Permissions
+		expected []string
+	}{
+		{
+			name:     "no declared permissions",
+			declared: nil,
+		},
+		{
+			name:     "write-all warns with minimal suggestion",
+			declared: &manifest.Permissions{Shorthand: "write-all"},
+			expected: []string{"permissions: write-all grants write access to every scope; use minimal scopes instead: contents: read, security-events: write"},
+		},
+		{
+			name:     "read-all misses required write scope",
+			declared: &manifest.Permissions{Shorthand: "read-all"},
+			expected: []string{"permissions: read-all is missing security-events: write required by enabled features"},
+		},
+		{
+			name:     "none misses every required scope",
+			declared: &manifest.Permissions{Shorthand: "none"},
+			expected: []string{
+				"permissions: none is missing contents: read required by enabled features",
+				"permissions: none is missing security-events: write required by enabled features",
+			},
+		},
+		{
+			name:     "minimal derived set passes",
+			declared: &manifest.Permissions{Scopes: map[string]string{"contents": "read", "security-events": "write"}},
+		},
+		{
+			name: "unneeded write scopes warn",
+			declared: &manifest.Permissions{Scopes: map[string]string{
+				"contents":        "write",
+				"packages":        "write",
+				"security-events": "write",
+			}},
+			expected: []string{
+				"permission contents: write is broader than needed (required: read)",
+				"permission packages: write is broader than needed (required: none)",
+			},
+		},
+		{
+			name:     "missing required scope warns",
+			declared: &manifest.Permissions{Scopes: map[string]string{"contents": "read"}},
+			expected: []string{"permission security-events: write is required by enabled features but not granted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, LintPermissions(tt.declared, required))
+		})
+	}
+}
+
+func TestWorkflowGenerator_ExplicitPermissions(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	data := []byte(`apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: explicit-permissions
+spec:
+  template: go-service
+  permissions: write-all`)
+	m, err := manifest.ParseManifest(data)
+	require.NoError(t, err)
+
+	workflow, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+	assert.Contains(t, workflow, "permissions: write-all")
+
+	explanation, err := generator.ExplainWorkflow(m, "default")
+	require.NoError(t, err)
+	assert.Equal(t, "write-all", explanation.PermissionsShorthand)
+	require.Len(t, explanation.PermissionWarnings, 1)
+	assert.Contains(t, explanation.PermissionWarnings[0], "write-all grants write access")
+}
+
+func TestWorkflowGenerator_DefaultPermissions(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	parse := func(t *testing.T, permissions string) *manifest.Manifest {
+		t.Helper()
+		m, err := manifest.ParseManifest([]byte(`apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: plain
+spec:
+  template: go-service
+  inputs:
+    security:
+      trivy:
+        enabled: false
+` + permissions))
+		require.NoError(t, err)
+		return m
+	}
+
+	t.Run("plain manifest declares read-only contents", func(t *testing.T) {
+		m := parse(t, "")
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "    permissions:\n      contents: read\n")
+
+		explanation, err := generator.ExplainWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"contents": "read"}, explanation.Permissions)
+	})
+
+	t.Run("none renders an empty permissions block", func(t *testing.T) {
+		m := parse(t, "  permissions: none\n")
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "    permissions: {}\n")
+	})
+}
+
+func TestWorkflowGenerator_TemplatePermissions(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	t.Run("declared permissions are merged with feature permissions", func(t *testing.T) {
+		tmpl := &templates.Template{
+			Name: "deploy",
+			Permissions: map[string]string{
+				"id-token": "write",
+				"contents": "read",
+			},
+			Inputs: map[string]templates.Input{
+				"containerEnabled": {Type: models.InputTypeBoolean},
+			},
+		}
+
+		permissions := generator.getRequiredPermissions(tmpl, map[string]interface{}{
+			"containerEnabled": true,
+		})
+		assert.Equal(t, map[string]string{
+			"id-token": "write",
+			"contents": "read",
+			"packages": "write",
+		}, permissions)
+	})
+
+	t.Run("broader declared level is kept", func(t *testing.T) {
+		tmpl := &templates.Template{
+			Name:        "release",
+			Permissions: map[string]string{"contents": "write"},
+			Inputs: map[string]templates.Input{
+				"trivyScanEnabled": {Type: models.InputTypeBoolean},
+			},
+		}
+
+		permissions := generator.getRequiredPermissions(tmpl, map[string]interface{}{
+			"trivyScanEnabled": true,
+		})
+		assert.Equal(t, "write", permissions["contents"])
+		assert.Equal(t, "write", permissions["security-events"])
+	})
+
+	t.Run("built-in template contributes permissions to the job", func(t *testing.T) {
+		m := testManifest("node-app", map[string]interface{}{
+			"security": map[string]interface{}{
+				"trivy": map[string]interface{}{"enabled": false},
+			},
+		})
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]struct {
+				Permissions map[string]string `yaml:"permissions"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		assert.Equal(t, map[string]string{"contents": "read"}, parsed.Jobs["build"].Permissions)
+	})
+}
+
+func TestWorkflowGenerator_ValidateCustomStepTargets(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(customSteps []manifest.CustomStep, environments map[string]manifest.EnvironmentConfig) *manifest.Manifest {
+		m := testManifest("node-app", nil)
+		m.Spec.CustomSteps = customSteps
+		m.Spec.Environments = environments
+		return m
+	}
+
+	t.Run("valid target", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{Name: "Lint", Position: "after:test", Run: "npm run lint"},
+		}, nil)
+
+		assert.NoError(t, generator.ValidateCustomStepTargets(m))
+	})
+
+	t.Run("target replaced by an earlier custom step", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{Name: "Integration suite", Position: "replace:test", Run: "npm run integration"},
+			{Name: "Coverage", Position: "after:test", Run: "npm run coverage"},
+		}, nil)
+
+		err := generator.ValidateCustomStepTargets(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unreachable custom step in environment default")
+		assert.Contains(t, err.Error(), "Coverage")
+		assert.Contains(t, err.Error(), "target step not found: test")
+	})
+
+	t.Run("target missing from template in environment", func(t *testing.T) {
+		m := newManifest(nil, map[string]manifest.EnvironmentConfig{
+			"production": {
+				CustomSteps: []manifest.CustomStep{
+					{Name: "Notify", Position: "after:deploy", Run: "echo done"},
+				},
+			},
+		})
+
+		err := generator.ValidateCustomStepTargets(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unreachable custom step in environment production")
+		assert.Contains(t, err.Error(), "target step not found: deploy")
+	})
+
+	t.Run("unknown target lists the known step IDs", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{Name: "Lint", Position: "before:tset", Run: "npm run lint"},
+		}, nil)
+
+		err := generator.ValidateCustomStepTargets(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "target step not found: tset (known steps: checkout, setup-node, install, test")
+	})
+
+	t.Run("target is a preceding custom step", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{ID: "lint", Name: "Lint", Position: "after:test", Run: "npm run lint"},
+			{Name: "Lint report", Position: "after:lint", Run: "npm run lint:report"},
+		}, nil)
+
+		assert.NoError(t, generator.ValidateCustomStepTargets(m))
+	})
+
+	t.Run("target left out by an environment's inputs", func(t *testing.T) {
+		m := testManifest("go-service", map[string]interface{}{"crossCompile": true})
+		m.Spec.CustomSteps = []manifest.CustomStep{
+			{Name: "Sign binaries", Position: "after:upload-artifacts", Run: "make sign"},
+		}
+		m.Spec.Environments = map[string]manifest.EnvironmentConfig{
+			"staging": {Inputs: map[string]interface{}{"crossCompile": false}},
+		}
+
+		err := generator.ValidateCustomStepTargets(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unreachable custom step in environment staging")
+		assert.Contains(t, err.Error(), "target step not found: upload-artifacts")
+	})
+
+	t.Run("overrides are applied first", func(t *testing.T) {
+		m := newManifest(nil, map[string]manifest.EnvironmentConfig{
+			"production": {Overrides: map[string]manifest.StepOverride{"tset": {Run: "npm test"}}},
+		})
+
+		err := generator.ValidateCustomStepTargets(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "environment production: override for unknown step: tset")
+	})
+
+	t.Run("target is a custom step id with underscores", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{ID: "run_e2e", Name: "End-to-end tests", Position: "after:test", Run: "npm run e2e"},
+			{Name: "E2E report", Position: "after:run_e2e", Run: "npm run e2e:report"},
+		}, nil)
+		require.NoError(t, manifest.ValidateManifest(m))
+		require.NoError(t, generator.ValidateCustomStepTargets(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		names := stepNames(parseBuildSteps(t, workflow))
+		assert.Equal(t, slices.Index(names, "End-to-end tests")+1, slices.Index(names, "E2E report"))
+	})
+
+	t.Run("target is a later custom step", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{Name: "Lint report", Position: "after:lint", Run: "npm run lint:report"},
+			{ID: "lint", Name: "Lint", Position: "after:test", Run: "npm run lint"},
+		}, nil)
+
+		err := generator.ValidateCustomStepTargets(m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "target step not found: lint")
+	})
+}
+
+func BenchmarkWorkflowGenerator_GenerateWorkflow(b *testing.B) {
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "benchmark-app",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "node-app",
+			Inputs: map[string]interface{}{
+				"nodeVersion": "18",
+			},
+			Environments: map[string]manifest.EnvironmentConfig{
+				"dev":        {Inputs: map[string]interface{}{"nodeVersion": "20"}},
+				"staging":    {Inputs: map[string]interface{}{"testCommand": "npm run test:ci"}},
+				"production": {Inputs: map[string]interface{}{"nodeVersion": "22"}},
+			},
+		},
+	}
+	environments := []string{"default", "dev", "staging", "production"}
+	generator := NewWorkflowGenerator("")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, env := range environments {
+			if _, err := generator.GenerateWorkflow(m, env); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestWorkflowGenerator_MinimalTemplate(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	generator.templateManager.RegisterTemplate(&templates.Template{
+		Name:    "minimal",
+		Version: "1.0.0",
+		Inputs: map[string]templates.Input{
+			"testCommand": {Type: models.InputTypeString, Default: "make test"},
+		},
+		Steps: []templates.Step{
+			{ID: "checkout", Name: "Checkout code", Uses: templates.GitHubActionVersions.Checkout},
+			{ID: "test", Name: "Run tests", Run: "{{ .Inputs.testCommand }}"},
+		},
+	})
+
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata: &manifest.ManifestMetadata{
+			Name: "minimal-app",
+		},
+		Spec: manifest.ManifestSpec{
+			Template: "minimal",
+			Inputs: map[string]interface{}{
+				// Settings for features the template doesn't have are ignored
+				"containerEnabled": true,
+				"trivyScanEnabled": true,
+			},
+			Environments: map[string]manifest.EnvironmentConfig{
+				"production": {},
+			},
+		},
+	}
+
+	for _, env := range []string{"default", "production"} {
+		t.Run(env, func(t *testing.T) {
+			tmpl, err := generator.templateManager.LoadTemplate("minimal")
+			require.NoError(t, err)
+			assert.Empty(t, generator.getRequiredPermissions(tmpl, generator.getEffectiveInputs(m, env)))
+
+			workflow, err := generator.GenerateWorkflow(m, env)
+			require.NoError(t, err)
+
+			var parsed struct {
+				Jobs map[string]struct {
+					Permissions map[string]string `yaml:"permissions"`
+					Steps       []WorkflowStep    `yaml:"steps"`
+				} `yaml:"jobs"`
+			}
+			require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+			// Without features needing more, the job still declares read-only contents
+			assert.Equal(t, map[string]string{"contents": "read"}, parsed.Jobs["build"].Permissions)
+			require.Len(t, parsed.Jobs["build"].Steps, 2)
+			assert.Equal(t, "make test", parsed.Jobs["build"].Steps[1].Run)
+			assert.NotContains(t, workflow, "trivy")
+			assert.NotContains(t, workflow, "docker/")
+
+			explanation, err := generator.ExplainWorkflow(m, env)
+			require.NoError(t, err)
+			assert.False(t, explanation.SecurityEnabled)
+			assert.False(t, explanation.ContainerEnabled)
+			assert.False(t, explanation.ContainerPush)
+		})
+	}
+}
+
+func TestMergeWorkflow(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	m := testManifest("go-service", map[string]interface{}{
+		"testCommand": "go test -race ./...",
+	})
+
+	generated, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	existing := `# Generated by gpgen from template go-service. DO NOT EDIT.
+# gpgen-template-hash: 0000000000000000
+# Owned by the payments team
+name: merge-app
+run-name: Build ${{ github.ref_name }}
+on:
+  push:
+    branches:
+      - main
+permissions: write-all
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - name: Run tests
+        run: go test ./...
+  # Maintained by hand
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: golangci/golangci-lint-action@v6
+`
+
+	t.Run("replaces the build job and keeps other jobs", func(t *testing.T) {
+		merged, err := MergeWorkflow(existing, generated)
+		require.NoError(t, err)
+
+		var parsed struct {
+			RunName     string                 `yaml:"run-name"`
+			On          map[string]interface{} `yaml:"on"`
+			Permissions interface{}            `yaml:"permissions"`
+			Env         map[string]string      `yaml:"env"`
+			Jobs        map[string]struct {
+				Steps []WorkflowStep `yaml:"steps"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(merged), &parsed))
+		require.Contains(t, parsed.Jobs, "lint")
+		assert.Equal(t, "golangci/golangci-lint-action@v6", parsed.Jobs["lint"].Steps[0].Uses)
+		assert.Contains(t, merged, "# Maintained by hand")
+		assert.Contains(t, merged, "go test -race ./...")
+
+		assert.Contains(t, parsed.On, "pull_request", "generated triggers replace the existing ones")
+		assert.Nil(t, parsed.Permissions, "settings gpgen no longer generates are dropped")
+		assert.NotEmpty(t, parsed.Env[ManifestHashEnv], "new generated settings are added")
+		assert.Less(t, strings.Index(merged, "\nenv:"), strings.Index(merged, "\njobs:"))
+		assert.Equal(t, "Build ${{ github.ref_name }}", parsed.RunName, "hand-written settings are kept")
+		assert.Contains(t, merged, "DO NOT EDIT.\n# gpgen-template-hash: ")
+		assert.Contains(t, merged, "\n# Owned by the payments team\nname: test-app\n")
+		assert.Equal(t, 1, strings.Count(merged, "# Generated by gpgen"))
+
+		hash, err := generator.TemplateHash("go-service")
+		require.NoError(t, err)
+		assert.Equal(t, hash, TemplateHashFromWorkflow(merged))
+	})
+
+	t.Run("adds the build job when missing", func(t *testing.T) {
+		merged, err := MergeWorkflow("name: hand-written\non: push\njobs:\n  lint:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make lint\n", generated)
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]interface{} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(merged), &parsed))
+		assert.Contains(t, parsed.Jobs, "lint")
+		assert.Contains(t, parsed.Jobs, ManagedJobID)
+	})
+
+	t.Run("existing workflow without jobs", func(t *testing.T) {
+		_, err := MergeWorkflow("name: empty\n", generated)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no jobs mapping")
+	})
+}
+
+func TestWorkflowGenerator_MatrixRunsOn(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(matrix map[string][]string) *manifest.Manifest {
+		m := testManifest("node-app", nil)
+		m.Spec.Matrix = matrix
+		return m
+	}
+
+	parseJob := func(t *testing.T, workflow string) (string, map[string]interface{}) {
+		t.Helper()
+		var parsed struct {
+			Jobs map[string]struct {
+				RunsOn   string                 `yaml:"runs-on"`
+				Strategy map[string]interface{} `yaml:"strategy"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		return parsed.Jobs["build"].RunsOn, parsed.Jobs["build"].Strategy
+	}
+
+	t.Run("os matrix drives runs-on", func(t *testing.T) {
+		m := newManifest(map[string][]string{"os": {"ubuntu-latest", "macos-latest"}})
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		runsOn, strategy := parseJob(t, workflow)
+		assert.Equal(t, "${{ matrix.os }}", runsOn)
+		assert.Equal(t, map[string]interface{}{"os": []interface{}{"ubuntu-latest", "macos-latest"}}, strategy["matrix"])
+	})
+
+	t.Run("without os matrix runs on ubuntu", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(map[string][]string{"nodeVersion": {"18", "20"}}), "default")
+		require.NoError(t, err)
+
+		runsOn, _ := parseJob(t, workflow)
+		assert.Equal(t, "ubuntu-latest", runsOn)
+	})
+}
+
+func TestWorkflowGenerator_StepOverrides(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	twenty, fortyFive := 20, 45
+	continueOnError := true
+
+	newManifest := func() *manifest.Manifest {
+		return &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata: &manifest.ManifestMetadata{
+				Name: "override-service",
+			},
+			Spec: manifest.ManifestSpec{
+				Template: "go-service",
+				Overrides: map[string]manifest.StepOverride{
+					"test": {
+						TimeoutMinutes: &twenty,
+						Env:            map[string]string{"GO_TEST_TIMEOUT": "15m"},
+					},
+					"setup-go": {
+						With: map[string]string{"cache": "false"},
+					},
+				},
+				Environments: map[string]manifest.EnvironmentConfig{
+					"production": {
+						Overrides: map[string]manifest.StepOverride{
+							"test": {
+								TimeoutMinutes:  &fortyFive,
+								ContinueOnError: &continueOnError,
+								Env:             map[string]string{"GOFLAGS": "-count=1"},
+							},
+							"build": {
+								Run: "make release",
+								If:  "github.event_name == 'release'",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("base overrides apply to the matching template step", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(), "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "timeout-minutes: 20")
+
+		test := requireStep(t, generateTestSteps(t, generator, newManifest(), "default"), "Run tests")
+		assert.Equal(t, 20, test.TimeoutMins)
+		assert.Equal(t, map[string]string{"GO_TEST_TIMEOUT": "15m"}, test.Env)
+
+		setup := requireStep(t, generateTestSteps(t, generator, newManifest(), "default"), "Setup Go")
+		assert.Equal(t, "false", setup.With["cache"])
+		assert.NotEmpty(t, setup.With["go-version"], "unrelated with entries are kept")
+	})
+
+	t.Run("environment overrides layer on top of base overrides", func(t *testing.T) {
+		test := requireStep(t, generateTestSteps(t, generator, newManifest(), "production"), "Run tests")
+		assert.Equal(t, 45, test.TimeoutMins)
+		require.NotNil(t, test.ContinueOnError)
+		assert.True(t, *test.ContinueOnError)
+		assert.Equal(t, map[string]string{"GO_TEST_TIMEOUT": "15m", "GOFLAGS": "-count=1"}, test.Env)
+
+		build := requireStep(t, generateTestSteps(t, generator, newManifest(), "production"), "Build service")
+		assert.Equal(t, "make release", build.Run)
+		assert.Equal(t, "github.event_name == 'release'", build.If)
+	})
+
+	t.Run("uses override replaces the action", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Overrides["checkout"] = manifest.StepOverride{Uses: "actions/checkout@v3"}
+
+		checkout := requireStep(t, generateTestSteps(t, generator, m, "default"), "Checkout code")
+		assert.Equal(t, "actions/checkout@v3", checkout.Uses)
+	})
+
+	t.Run("uses override keeps with entries and drops run settings", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Overrides["setup-go"] = manifest.StepOverride{Uses: "actions/setup-go@v6"}
+		m.Spec.Overrides["build"] = manifest.StepOverride{
+			Uses: "goreleaser/goreleaser-action@v6",
+			With: map[string]string{"args": "release --clean"},
+		}
+
+		setup := requireStep(t, generateTestSteps(t, generator, m, "default"), "Setup Go")
+		assert.Equal(t, "actions/setup-go@v6", setup.Uses)
+		assert.NotEmpty(t, setup.With["go-version"])
+
+		build := requireStep(t, generateTestSteps(t, generator, m, "default"), "Build service")
+		assert.Equal(t, "goreleaser/goreleaser-action@v6", build.Uses)
+		assert.Empty(t, build.Run)
+		assert.Empty(t, build.Shell)
+		assert.Equal(t, map[string]string{"args": "release --clean"}, build.With)
+	})
+
+	t.Run("run override drops the action and its with entries", func(t *testing.T) {
+		m := newManifest()
+		delete(m.Spec.Overrides, "setup-go")
+		m.Spec.Overrides["checkout"] = manifest.StepOverride{Run: "git clone --depth 1 $REPO ."}
+
+		checkout := requireStep(t, generateTestSteps(t, generator, m, "default"), "Checkout code")
+		assert.Equal(t, "git clone --depth 1 $REPO .", checkout.Run)
+		assert.Empty(t, checkout.Uses)
+		assert.Empty(t, checkout.With)
+	})
+
+	t.Run("environment run replaces a base uses override", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Overrides["build"] = manifest.StepOverride{Uses: "goreleaser/goreleaser-action@v6"}
+
+		build := requireStep(t, generateTestSteps(t, generator, m, "production"), "Build service")
+		assert.Equal(t, "make release", build.Run)
+		assert.Empty(t, build.Uses)
+	})
+
+	t.Run("conflicting override fails", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Overrides["build"] = manifest.StepOverride{Uses: "goreleaser/goreleaser-action@v6", Run: "make release"}
+
+		_, err := generator.GenerateWorkflow(m, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "override cannot have both 'uses' and 'run'")
+	})
+
+	t.Run("with override on a run step fails", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Overrides["build"] = manifest.StepOverride{With: map[string]string{"args": "-v"}}
+
+		_, err := generator.GenerateWorkflow(m, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "override cannot set 'with' on a run step")
+	})
+
+	t.Run("override for unknown step fails", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Overrides["deploy"] = manifest.StepOverride{TimeoutMinutes: &twenty}
+
+		_, err := generator.GenerateWorkflow(m, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "override for unknown step: deploy")
+	})
+}
+
+func TestWorkflowGenerator_ContinueOnError(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m, err := manifest.ParseManifest([]byte(`apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: continue-on-error
+spec:
+  template: node-app
+  customSteps:
+    - name: flaky-smoke-test
+      position: after:test
+      run: npm run smoke
+      continue-on-error: true
+    - name: notify
+      position: after:flaky-smoke-test
+      run: echo done
+  overrides:
+    build:
+      continue-on-error: true
+`))
+	require.NoError(t, err)
+	require.NoError(t, manifest.ValidateManifest(m))
+
+	workflow, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	var parsed struct {
+		Jobs map[string]struct {
+			Steps []map[string]interface{} `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+
+	stepsByName := make(map[string]map[string]interface{})
+	for _, step := range parsed.Jobs[ManagedJobID].Steps {
+		stepsByName[step["name"].(string)] = step
+	}
+
+	t.Run("custom step value round-trips", func(t *testing.T) {
+		require.Contains(t, stepsByName, "flaky-smoke-test")
+		assert.Equal(t, true, stepsByName["flaky-smoke-test"]["continue-on-error"])
+	})
+
+	t.Run("override value round-trips", func(t *testing.T) {
+		require.Contains(t, stepsByName, "Build application")
+		assert.Equal(t, true, stepsByName["Build application"]["continue-on-error"])
+	})
+
+	t.Run("unset value emits no key", func(t *testing.T) {
+		require.Contains(t, stepsByName, "notify")
+		assert.NotContains(t, stepsByName["notify"], "continue-on-error")
+		assert.NotContains(t, stepsByName["Run tests"], "continue-on-error")
+	})
+}
+
+func TestWorkflowGenerator_EnvironmentDockerfile(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	m, err := manifest.ParseManifest([]byte(`apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: dockerfile-per-environment
+spec:
+  template: go-service
+  inputs:
+    container:
+      enabled: true
+      imageName: api
+  environments:
+    production:
+      inputs:
+        container:
+          dockerfile: Dockerfile.prod
+`))
+	require.NoError(t, err)
+	require.NoError(t, manifest.ValidateManifest(m))
+
+	buildPushFile := func(t *testing.T, environment string) string {
+		t.Helper()
+		return requireStep(t, generateTestSteps(t, generator, m, environment), "build-and-push").With["file"]
+	}
+
+	t.Run("default uses Dockerfile", func(t *testing.T) {
+		assert.Equal(t, "Dockerfile", buildPushFile(t, "default"))
+	})
+
+	t.Run("production override reaches build-push", func(t *testing.T) {
+		assert.Equal(t, "Dockerfile.prod", buildPushFile(t, "production"))
+	})
+
+	t.Run("environment override keeps sibling container inputs", func(t *testing.T) {
+		container := generator.getEffectiveInputs(m, "production")["container"].(map[string]interface{})
+		assert.Equal(t, true, container["enabled"])
+		assert.Equal(t, "api", container["imageName"])
+		assert.Equal(t, "Dockerfile.prod", container["dockerfile"])
+	})
+}
+
+func TestWorkflowGenerator_EnterpriseEnv(t *testing.T) {
+	originalEnv := config.Config.Jobs.Env
+	defer func() { config.Config.Jobs.Env = originalEnv }()
+
+	generator := NewWorkflowGenerator("")
+	m := testManifest("node-app", nil)
+
+	parseJob := func(t *testing.T) map[string]interface{} {
+		t.Helper()
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]map[string]interface{} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		return parsed.Jobs[ManagedJobID]
+	}
+
+	t.Run("no env configured", func(t *testing.T) {
+		config.Config.Jobs.Env = nil
+		assert.NotContains(t, parseJob(t), "env")
+	})
+
+	t.Run("configured env appears in the job env", func(t *testing.T) {
+		config.Config.Jobs.Env = map[string]string{
+			"HTTPS_PROXY":                     "http://proxy.corp.example:3128",
+			"ACTIONS_RUNNER_HOOK_JOB_STARTED": "/opt/runner/hooks/mirror.sh",
+		}
+
+		assert.Equal(t, map[string]interface{}{
+			"HTTPS_PROXY":                     "http://proxy.corp.example:3128",
+			"ACTIONS_RUNNER_HOOK_JOB_STARTED": "/opt/runner/hooks/mirror.sh",
+		}, parseJob(t)["env"])
+	})
+}
+
+func TestWorkflowGenerator_JobEnv(t *testing.T) {
+	originalEnv := config.Config.Jobs.Env
+	defer func() { config.Config.Jobs.Env = originalEnv }()
+	config.Config.Jobs.Env = map[string]string{"HTTPS_PROXY": "http://proxy.corp.example:3128", "GOFLAGS": "-mod=mod"}
+
+	generator := NewWorkflowGenerator("")
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata:   &manifest.ManifestMetadata{Name: "job-env"},
+		Spec: manifest.ManifestSpec{
+			Template: "go-service",
+			Env:      map[string]string{"WORKFLOW_LEVEL": "1"},
+			JobEnv: map[string]string{
+				"CGO_ENABLED": "0",
+				"GOFLAGS":     "-mod=readonly",
+				"TOKEN":       "GITHUB_TOKEN_PLACEHOLDER",
+			},
+			Jobs: map[string]manifest.JobSpec{
+				"release": {
+					Needs: []string{"build"},
+					Env:   map[string]string{"CGO_ENABLED": "1"},
+					Steps: []manifest.CustomStep{{Name: "Release", Run: "make release"}},
+				},
+			},
+		},
+	}
+
+	workflowYAML, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	var workflow GitHubActionsWorkflow
+	require.NoError(t, yaml.Unmarshal([]byte(workflowYAML), &workflow))
+
+	assert.Equal(t, map[string]string{
+		"HTTPS_PROXY": "http://proxy.corp.example:3128",
+		"CGO_ENABLED": "0",
+		"GOFLAGS":     "-mod=readonly",
+		"TOKEN":       "${{ secrets.GITHUB_TOKEN }}",
+	}, workflow.Jobs[ManagedJobID].Env, "jobEnv is layered over the configured env")
+	assert.Equal(t, "1", workflow.Jobs["release"].Env["CGO_ENABLED"], "a job's own env wins")
+	assert.Equal(t, "${{ secrets.GITHUB_TOKEN }}", workflow.Jobs["release"].Env["TOKEN"])
+	assert.Equal(t, "1", workflow.Env["WORKFLOW_LEVEL"], "spec.env stays at the workflow level")
+	assert.NotContains(t, workflow.Jobs[ManagedJobID].Env, "WORKFLOW_LEVEL")
+}
+
+func TestWorkflowGenerator_RunsOnInput(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(runsOn interface{}) *manifest.Manifest {
+		m := testManifest("go-service", map[string]interface{}{})
+		if runsOn != nil {
+			m.Spec.Inputs["runsOn"] = runsOn
+		}
+		return m
+	}
+
+	generateRunsOn := func(t *testing.T, m *manifest.Manifest) interface{} {
+		t.Helper()
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]struct {
+				RunsOn interface{} `yaml:"runs-on"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		return parsed.Jobs[ManagedJobID].RunsOn
+	}
+
+	t.Run("defaults to ubuntu-latest", func(t *testing.T) {
+		assert.Equal(t, "ubuntu-latest", generateRunsOn(t, newManifest(nil)))
+	})
+
+	t.Run("string form", func(t *testing.T) {
+		assert.Equal(t, "macos-latest", generateRunsOn(t, newManifest("macos-latest")))
+	})
+
+	t.Run("array form serializes as a list", func(t *testing.T) {
+		m := newManifest([]interface{}{"self-hosted", "linux", "x64"})
+		assert.Equal(t, []interface{}{"self-hosted", "linux", "x64"}, generateRunsOn(t, m))
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "runs-on:\n      - self-hosted\n      - linux\n      - x64\n")
+	})
+
+	t.Run("environments override the runner", func(t *testing.T) {
+		m := newManifest("ubuntu-latest")
+		m.Spec.Environments = map[string]manifest.EnvironmentConfig{
+			"production": {Inputs: map[string]interface{}{"runsOn": []interface{}{"self-hosted", "prod"}}},
+		}
+		require.NoError(t, manifest.ValidateManifest(m))
+
+		for env, expected := range map[string]interface{}{
+			"staging":    "ubuntu-latest",
+			"production": []interface{}{"self-hosted", "prod"},
+		} {
+			workflow, err := generator.GenerateWorkflow(m, env)
+			require.NoError(t, err)
+
+			var parsed struct {
+				Jobs map[string]struct {
+					RunsOn interface{} `yaml:"runs-on"`
+				} `yaml:"jobs"`
+			}
+			require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+			assert.Equal(t, expected, parsed.Jobs[ManagedJobID].RunsOn, env)
+		}
+	})
+
+	t.Run("invalid values fail", func(t *testing.T) {
+		for _, runsOn := range []interface{}{"", []interface{}{}, []interface{}{"self-hosted", 3}, 42} {
+			_, err := generator.GenerateWorkflow(newManifest(runsOn), "default")
+			require.Error(t, err, "runsOn %v", runsOn)
+			assert.Contains(t, err.Error(), "input 'runsOn' must be a runner label or a non-empty list of labels")
+		}
+	})
+
+	t.Run("conflicts with an os matrix", func(t *testing.T) {
+		m := newManifest("self-hosted")
+		m.Spec.Matrix = map[string][]string{"os": {"ubuntu-latest", "windows-latest"}}
+
+		_, err := generator.GenerateWorkflow(m, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot be combined with an os matrix dimension")
+	})
+}
+
+func TestWorkflowGenerator_Prune(t *testing.T) {
+	m := testManifest("go-service", map[string]interface{}{
+		"container": map[string]interface{}{"enabled": false},
+	})
+
+	containerSteps := []string{"Set up Docker Buildx", "Log in to Container Registry", "Build and push container image"}
+
+	t.Run("without prune the guarded steps remain", func(t *testing.T) {
+		workflow, err := NewWorkflowGenerator("").GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		for _, name := range containerSteps {
+			assert.Contains(t, workflow, "name: "+name)
+		}
+	})
+
+	t.Run("prune drops statically false steps", func(t *testing.T) {
+		generator := NewWorkflowGenerator("")
+		generator.EnablePruning()
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		for _, name := range containerSteps {
+			assert.NotContains(t, workflow, "name: "+name)
+		}
+		assert.NotContains(t, workflow, "name: Upload build artifacts")
+		assert.Contains(t, workflow, "name: Run tests")
+		assert.Contains(t, workflow, "name: Run Trivy vulnerability scanner")
+	})
+
+	t.Run("prune keeps enabled container steps", func(t *testing.T) {
+		enabled := *m
+		enabled.Spec.Inputs = map[string]interface{}{
+			"container": map[string]interface{}{"enabled": true},
+		}
+		generator := NewWorkflowGenerator("")
+		generator.EnablePruning()
+
+		workflow, err := generator.GenerateWorkflow(&enabled, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "name: Build and push container image")
+	})
+}
+
+func TestWorkflowGenerator_HardenScripts(t *testing.T) {
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata:   &manifest.ManifestMetadata{Name: "hardened"},
+		Spec: manifest.ManifestSpec{
+			Template: "node-app",
+			CustomSteps: []manifest.CustomStep{
+				{Name: "Seed database", Position: "before:test", Run: "./scripts/seed.sh\n./scripts/migrate.sh"},
+				{Name: "Already strict", Position: "after:test", Run: "set -euo pipefail\nnpm run e2e\nnpm run report"},
+			},
+		},
+	}
+
+	t.Run("scripts are left alone by default", func(t *testing.T) {
+		workflow, err := NewWorkflowGenerator("").GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "./scripts/seed.sh\n./scripts/migrate.sh", requireStep(t, parseBuildSteps(t, workflow), "Seed database").Run)
+	})
+
+	t.Run("harden-scripts prepends strict mode", func(t *testing.T) {
+		generator := NewWorkflowGenerator("")
+		generator.EnableScriptHardening()
+
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "set -euo pipefail\n./scripts/seed.sh\n./scripts/migrate.sh", requireStep(t, parseBuildSteps(t, workflow), "Seed database").Run)
+		assert.Equal(t, "set -euo pipefail\nnpm run e2e\nnpm run report", requireStep(t, parseBuildSteps(t, workflow), "Already strict").Run)
+		assert.NotContains(t, requireStep(t, parseBuildSteps(t, workflow), "Run tests").Run, manifest.StrictModeLine)
+	})
+
+	t.Run("template steps are not hardened", func(t *testing.T) {
+		overridden := *m
+		overridden.Spec.Overrides = map[string]manifest.StepOverride{"test": {Run: "npm ci\nnpm test"}}
+		generator := NewWorkflowGenerator("")
+		generator.EnableScriptHardening()
+
+		workflow, err := generator.GenerateWorkflow(&overridden, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "npm ci\nnpm test", requireStep(t, parseBuildSteps(t, workflow), "Run tests").Run)
+	})
+
+	t.Run("non-bash shells are not hardened", func(t *testing.T) {
+		pwsh := *m
+		pwsh.Spec.Defaults = &manifest.JobDefaults{Run: &manifest.RunDefaults{Shell: "pwsh"}}
+		generator := NewWorkflowGenerator("")
+		generator.EnableScriptHardening()
+
+		workflow, err := generator.GenerateWorkflow(&pwsh, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "./scripts/seed.sh\n./scripts/migrate.sh", requireStep(t, parseBuildSteps(t, workflow), "Seed database").Run)
+	})
+}
+
+func TestWorkflowGenerator_GoModuleCache(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	setupGo := func(t *testing.T, inputs map[string]interface{}) string {
+		t.Helper()
+		m := testManifest("go-service", inputs)
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		start := strings.Index(workflow, "uses: actions/setup-go")
+		require.NotEqual(t, -1, start)
+		end := strings.Index(workflow[start:], "- name:")
+		require.NotEqual(t, -1, end)
+		return workflow[start : start+end]
+	}
+
+	t.Run("caching is enabled by default", func(t *testing.T) {
+		step := setupGo(t, nil)
+		assert.Contains(t, step, `cache: "true"`)
+		assert.NotContains(t, step, "cache-dependency-path")
+	})
+
+	t.Run("dependency path is passed through", func(t *testing.T) {
+		step := setupGo(t, map[string]interface{}{"cacheDependencyPath": "**/go.sum"})
+		assert.Contains(t, step, `cache: "true"`)
+		assert.Contains(t, step, `cache-dependency-path: '**/go.sum'`)
+	})
+
+	t.Run("caching can be disabled", func(t *testing.T) {
+		step := setupGo(t, map[string]interface{}{"cacheEnabled": false, "cacheDependencyPath": "**/go.sum"})
+		assert.Contains(t, step, `cache: "false"`)
+		assert.NotContains(t, step, "cache-dependency-path")
+	})
+}
+
+func TestWorkflowGenerator_MatrixArtifactNames(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(matrix map[string][]string, inputs map[string]interface{}) *manifest.Manifest {
+		return &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata:   &manifest.ManifestMetadata{Name: "matrix-artifacts"},
+			Spec: manifest.ManifestSpec{
+				Template: "go-service",
+				Inputs:   inputs,
+				Matrix:   matrix,
+				CustomSteps: []manifest.CustomStep{
+					{
+						Name:     "Upload coverage",
+						Position: "after:test",
+						Uses:     "actions/upload-artifact@v4",
+						With:     map[string]string{"name": "coverage", "path": "coverage.out"},
+					},
+					{
+						Name:     "Upload per-version report",
+						Position: "after:test",
+						Uses:     "actions/upload-artifact@v4",
+						With:     map[string]string{"name": "report-${{ matrix.goVersion }}", "path": "report/"},
+					},
+					{
+						Name:     "Upload logs",
+						Position: "after:test",
+						Uses:     "actions/upload-artifact@v4",
+						With:     map[string]string{"path": "logs/"},
+					},
+				},
+			},
+		}
+	}
+	artifactNames := func(t *testing.T, m *manifest.Manifest) map[string]string {
+		t.Helper()
+		steps := generateTestSteps(t, generator, m, "default")
+
+		names := make(map[string]string)
+		for _, step := range steps {
+			if strings.HasPrefix(step.Uses, "actions/upload-artifact@") {
+				names[step.Name] = step.With["name"]
+			}
+		}
+		return names
+	}
+
+	t.Run("matrix builds suffix artifact names with matrix values", func(t *testing.T) {
+		m := newManifest(map[string][]string{"goVersion": {"1.23", "1.24"}, "os": {"ubuntu-latest", "macos-latest"}}, nil)
+		names := artifactNames(t, m)
+		assert.Equal(t, "coverage-${{ matrix.goVersion }}-${{ matrix.os }}", names["Upload coverage"])
+		assert.Equal(t, "report-${{ matrix.goVersion }}-${{ matrix.os }}", names["Upload per-version report"], "referenced dimensions are not repeated")
+		assert.Equal(t, "artifact-${{ matrix.goVersion }}-${{ matrix.os }}", names["Upload logs"], "unnamed artifacts get the default name")
+	})
+
+	t.Run("cross-compiled builds use GOOS and GOARCH", func(t *testing.T) {
+		m := newManifest(map[string][]string{"goVersion": {"1.23", "1.24"}}, map[string]interface{}{"crossCompile": true})
+		names := artifactNames(t, m)
+		assert.Equal(t, "coverage-${{ matrix.goVersion }}-${{ matrix.goos }}-${{ matrix.goarch }}", names["Upload coverage"])
+		assert.Equal(t, "service-${{ matrix.goos }}-${{ matrix.goarch }}-${{ matrix.goVersion }}", names["Upload build artifacts"])
+	})
+
+	t.Run("builds without a matrix keep artifact names", func(t *testing.T) {
+		names := artifactNames(t, newManifest(nil, nil))
+		assert.Equal(t, "coverage", names["Upload coverage"])
+		assert.Equal(t, "", names["Upload logs"])
+	})
+}
+
+func TestWorkflowGenerator_Artifacts(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(template string, artifacts interface{}) *manifest.Manifest {
+		inputs := map[string]interface{}{}
+		if artifacts != nil {
+			inputs["artifacts"] = artifacts
+		}
+		return testManifest(template, inputs)
+	}
+	t.Run("configured artifacts are uploaded after the build", func(t *testing.T) {
+		m := newManifest("node-app", map[string]interface{}{
+			"name":          "dist",
+			"paths":         []interface{}{"dist/", "coverage/lcov.info"},
+			"retentionDays": 14,
+		})
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		upload := requireStep(t, parseBuildSteps(t, workflow), "Upload artifacts")
+		assert.Equal(t, "actions/upload-artifact@v4", upload.Uses)
+		assert.Equal(t, "dist", upload.With["name"])
+		assert.Equal(t, "dist/\ncoverage/lcov.info", upload.With["path"])
+		assert.Equal(t, "14", upload.With["retention-days"])
+		assert.Equal(t, "true && !cancelled()", upload.If)
+		assert.Less(t, strings.Index(workflow, "name: Build application"), strings.Index(workflow, "name: Upload artifacts"))
+	})
+
+	t.Run("unset name and retention use the action defaults", func(t *testing.T) {
+		m := newManifest("python-app", map[string]interface{}{"paths": []interface{}{"coverage.xml"}})
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		upload := requireStep(t, parseBuildSteps(t, workflow), "Upload artifacts")
+		assert.Equal(t, map[string]string{"path": "coverage.xml"}, map[string]string(upload.With))
+	})
+
+	for _, template := range []string{"node-app", "go-service", "python-app", "rust-service", "java-app"} {
+		t.Run("no upload step without artifacts for "+template, func(t *testing.T) {
+			workflow, err := generator.GenerateWorkflow(newManifest(template, nil), "default")
+			require.NoError(t, err)
+			assert.NotContains(t, stepNames(parseBuildSteps(t, workflow)), "Upload artifacts")
+		})
+	}
+
+	t.Run("invalid artifacts are rejected", func(t *testing.T) {
+		tests := []struct {
+			artifacts interface{}
+			errorMsg  string
+		}{
+			{map[string]interface{}{"paths": "dist/"}, "invalid artifacts.paths: must be a list of paths"},
+			{map[string]interface{}{"paths": []interface{}{""}}, "invalid artifacts.paths[0]"},
+			{map[string]interface{}{"paths": []interface{}{"dist/"}, "retentionDays": 120}, "invalid artifacts.retentionDays 120"},
+		}
+		for _, tt := range tests {
+			_, err := generator.GenerateWorkflow(newManifest("node-app", tt.artifacts), "default")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		}
+	})
+}
+
+func TestWorkflowGenerator_SlackNotification(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(template string, notifications interface{}) *manifest.Manifest {
+		inputs := map[string]interface{}{}
+		if notifications != nil {
+			inputs["notifications"] = notifications
+		}
+		return testManifest(template, inputs)
+	}
+	t.Run("failures are posted to the webhook secret as the last step", func(t *testing.T) {
+		m := newManifest("go-service", map[string]interface{}{
+			"slack": map[string]interface{}{"webhookSecret": "SLACK_WEBHOOK_URL", "channel": "#ci"},
+		})
+		m.Spec.Inputs["container"] = map[string]interface{}{"enabled": true}
+		m.Spec.CustomSteps = []manifest.CustomStep{{Name: "Smoke test", Position: "after:build", Run: "make smoke"}}
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		jobSteps := parseBuildSteps(t, workflow)
+		notify := jobSteps[len(jobSteps)-1]
+		assert.Equal(t, "Notify Slack of failure", notify.Name)
+		assert.Equal(t, "slackapi/slack-github-action@v2", notify.Uses)
+		assert.Equal(t, "failure()", notify.If)
+		assert.Equal(t, map[string]string{
+			"webhook":      "${{ secrets.SLACK_WEBHOOK_URL }}",
+			"webhook-type": "incoming-webhook",
+			"payload": "channel: \"#ci\"\n" +
+				"text: \"${{ github.workflow }} failed: ${{ github.server_url }}/${{ github.repository }}/actions/runs/${{ github.run_id }}\"",
+		}, map[string]string(notify.With))
+	})
+
+	t.Run("the channel is optional", func(t *testing.T) {
+		m := newManifest("node-app", map[string]interface{}{
+			"slack": map[string]interface{}{"webhookSecret": "CI_SLACK_WEBHOOK"},
+		})
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		jobSteps := parseBuildSteps(t, workflow)
+		notify := jobSteps[len(jobSteps)-1]
+		assert.Equal(t, "${{ secrets.CI_SLACK_WEBHOOK }}", notify.With["webhook"])
+		assert.True(t, strings.HasPrefix(notify.With["payload"], "text: "))
+	})
+
+	for _, template := range []string{"node-app", "go-service", "python-app", "rust-service", "java-app"} {
+		t.Run("no notification step without notifications for "+template, func(t *testing.T) {
+			workflow, err := generator.GenerateWorkflow(newManifest(template, nil), "default")
+			require.NoError(t, err)
+			assert.NotContains(t, workflow, "slack-github-action")
+		})
+	}
+
+	t.Run("invalid notifications are rejected", func(t *testing.T) {
+		tests := []struct {
+			notifications interface{}
+			errorMsg      string
+		}{
+			{"slack", "invalid notifications: must be an object with slack"},
+			{map[string]interface{}{"slack": map[string]interface{}{"channel": "#ci"}}, "notifications.slack.webhookSecret is required"},
+			{map[string]interface{}{"slack": map[string]interface{}{"webhookSecret": "https://hooks.slack.com/services/x"}}, "invalid notifications.slack.webhookSecret"},
+			{map[string]interface{}{"slack": map[string]interface{}{"webhookSecret": "GITHUB_TOKEN"}}, "invalid notifications.slack.webhookSecret"},
+		}
+		for _, tt := range tests {
+			_, err := generator.GenerateWorkflow(newManifest("node-app", tt.notifications), "default")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		}
+	})
+}
+
+func TestWorkflowGenerator_ActionVersions(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(actionVersions map[string]string) *manifest.Manifest {
+		return &manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata:   &manifest.ManifestMetadata{Name: "pinned"},
+			Spec: manifest.ManifestSpec{
+				Template: "go-service",
+				CustomSteps: []manifest.CustomStep{
+					{Name: "Checkout docs", Position: "after:checkout", Uses: "actions/checkout@v4"},
+				},
+				ActionVersions: actionVersions,
+			},
+		}
+	}
+	t.Run("overrides replace the emitted uses of template steps", func(t *testing.T) {
+		sha := "aquasecurity/trivy-action@6e7b7d1fd3e4fef0c5fa8cce1229c54b2c9bd0d8"
+		workflow, err := generator.GenerateWorkflow(newManifest(map[string]string{
+			"checkout": "v5",
+			"trivy":    sha,
+		}), "default")
+		require.NoError(t, err)
+
+		steps := parseBuildSteps(t, workflow)
+		assert.Equal(t, "actions/checkout@v5", requireStep(t, steps, "Checkout code").Uses)
+		assert.Equal(t, sha, requireStep(t, steps, "Run Trivy vulnerability scanner").Uses)
+		assert.Equal(t, "actions/setup-go@v4", requireStep(t, steps, "Setup Go").Uses)
+		assert.Equal(t, "actions/checkout@v4", requireStep(t, steps, "Checkout docs").Uses, "custom steps keep the action they name")
+	})
+
+	t.Run("defaults apply without overrides", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest(nil), "default")
+		require.NoError(t, err)
+		assert.Equal(t, "actions/checkout@v4", requireStep(t, parseBuildSteps(t, workflow), "Checkout code").Uses)
+	})
+
+	t.Run("overrides are matched by action name", func(t *testing.T) {
+		original := templates.GitHubActionVersions
+		defer func() { templates.GitHubActionVersions = original }()
+		require.NoError(t, templates.SetActionVersions(map[string]string{"gosec": original.TrivyAction}))
+
+		sha := "aquasecurity/trivy-action@6e7b7d1fd3e4fef0c5fa8cce1229c54b2c9bd0d8"
+		workflow, err := NewWorkflowGenerator("").GenerateWorkflow(newManifest(map[string]string{"trivyAction": sha}), "default")
+		require.NoError(t, err)
+
+		steps := parseBuildSteps(t, workflow)
+		assert.Equal(t, sha, requireStep(t, steps, "Run Trivy vulnerability scanner").Uses)
+		assert.Equal(t, original.TrivyAction, requireStep(t, steps, "Run gosec security scanner").Uses, "gosec shares the reference but not the name")
+	})
+
+	t.Run("overridden actions are left alone", func(t *testing.T) {
+		m := newManifest(map[string]string{"checkout": "v5"})
+		m.Spec.Overrides = map[string]manifest.StepOverride{"checkout": {Uses: "actions/checkout@v3"}}
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "actions/checkout@v3", requireStep(t, parseBuildSteps(t, workflow), "Checkout code").Uses)
+	})
+
+	t.Run("unknown actions are rejected", func(t *testing.T) {
+		_, err := generator.GenerateWorkflow(newManifest(map[string]string{"checkot": "v5"}), "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid spec.actionVersions: unknown action "checkot" in actionVersions`)
+	})
+}
+
+func TestIsStaticallyFalse(t *testing.T) {
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{"false", true},
+		{"${{ false }}", true},
+		{"false && always()", true},
+		{"false && (false || github.event_name == 'pull_request')", true},
+		{"(false || false) && github.event_name == 'push'", true},
+		{"github.event_name == 'push' && false", true},
+		{"false || false && always()", true},
+		{"true", false},
+		{"", false},
+		{"true && always()", false},
+		{"false && github.ref == 'refs/heads/main' || github.event_name == 'release'", false},
+		{"(false) || success()", false},
+		{"(false || true) && always()", false},
+		{"github.event.head_commit.message == 'false && skip'", false},
+		{"contains(github.ref, 'false')", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isStaticallyFalse(tt.condition))
+		})
+	}
+}
+
+func TestWorkflowGenerator_LanguageVersionMatrix(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	newManifest := func(versions ...string) *manifest.Manifest {
+		m := testManifest("node-app", nil)
+		m.Spec.Matrix = map[string][]string{"nodeVersion": versions}
+		return m
+	}
+
+	t.Run("matrix becomes the job strategy", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(newManifest("18", "20", "22"), "default")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]struct {
+				Strategy map[string]interface{} `yaml:"strategy"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+
+		job := parsed.Jobs[ManagedJobID]
+		assert.Contains(t, workflow, "strategy:")
+		assert.Equal(t, map[string]interface{}{"nodeVersion": []interface{}{"18", "20", "22"}}, job.Strategy["matrix"])
+
+		setupNode := requireStep(t, parseBuildSteps(t, workflow), "Setup Node.js")
+		assert.Equal(t, "${{ matrix.nodeVersion }}", setupNode.With["node-version"])
+	})
+
+	t.Run("every version is checked against the configured versions", func(t *testing.T) {
+		versions := config.Config.Languages[config.LanguageNode].Versions
+		require.NotContains(t, versions, "12")
+
+		_, err := generator.GenerateWorkflow(newManifest(versions[0], "12"), "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid matrix value")
+	})
+}
+
+func TestWorkflowGenerator_PackageManagerMatrix(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+
+	generate := func(t *testing.T, template string, matrix map[string][]string) (map[string]interface{}, []WorkflowStep) {
+		t.Helper()
+		m := testManifest(template, nil)
+		m.Spec.Matrix = matrix
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+
+		var parsed struct {
+			Jobs map[string]struct {
+				Strategy map[string]interface{} `yaml:"strategy"`
+				Steps    []WorkflowStep         `yaml:"steps"`
+			} `yaml:"jobs"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte(workflow), &parsed))
+		job := parsed.Jobs[ManagedJobID]
+		return job.Strategy, job.Steps
+	}
+
+	t.Run("node installs with the matrix package manager", func(t *testing.T) {
+		strategy, steps := generate(t, "node-app", map[string][]string{"packageManager": {"npm", "yarn", "pnpm"}})
+
+		assert.Equal(t, map[string]interface{}{"packageManager": []interface{}{"npm", "yarn", "pnpm"}}, strategy["matrix"])
+		assert.Equal(t, "${{ matrix.packageManager }}", requireStep(t, steps, "Setup Node.js").With["cache"])
+		assert.Equal(t, "case \"${{ matrix.packageManager }}\" in\n"+
+			"  npm) npm ci ;;\n"+
+			"  yarn) yarn install --frozen-lockfile ;;\n"+
+			"  pnpm) pnpm install --frozen-lockfile ;;\n"+
+			"  *) echo \"Unsupported package manager: ${{ matrix.packageManager }}\" >&2; exit 1 ;;\n"+
+			"esac", requireStep(t, steps, "Install dependencies").Run)
+	})
+
+	t.Run("python installs with the matrix package manager", func(t *testing.T) {
+		_, steps := generate(t, "python-app", map[string][]string{"packageManager": {"pip", "poetry"}})
+
+		assert.Equal(t, "${{ matrix.packageManager }}", requireStep(t, steps, "Setup Python").With["cache"])
+		assert.Contains(t, requireStep(t, steps, "Install dependencies").Run, "  pip) pip install -r requirements.txt ;;\n  poetry) poetry install ;;\n")
+	})
+
+	t.Run("a single package manager keeps its plain install command", func(t *testing.T) {
+		_, steps := generate(t, "node-app", nil)
+		assert.Equal(t, "npm ci", requireStep(t, steps, "Install dependencies").Run)
+	})
+
+	t.Run("every package manager is checked against the supported ones", func(t *testing.T) {
+		_, err := generator.GenerateWorkflow(&manifest.Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Metadata:   &manifest.ManifestMetadata{Name: "library"},
+			Spec: manifest.ManifestSpec{
+				Template: "node-app",
+				Matrix:   map[string][]string{"packageManager": {"npm", "bun"}},
+			},
+		}, "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid matrix value")
+	})
+}
+
+func TestWorkflowGenerator_LanguageDefaultRunner(t *testing.T) {
+	original := config.Config.Languages[config.LanguageGo]
+	defer func() { config.Config.Languages[config.LanguageGo] = original }()
+
+	generator := NewWorkflowGenerator("")
+	m := testManifest("go-service", map[string]interface{}{})
+
+	assert.Equal(t, "ubuntu-latest", generator.getRunsOn(m, m.Spec.Inputs))
+
+	macos := original
+	macos.DefaultRunner = "macos-latest"
+	config.Config.Languages[config.LanguageGo] = macos
+
+	t.Run("language default applies", func(t *testing.T) {
+		workflow, err := generator.GenerateWorkflow(m, "default")
+		require.NoError(t, err)
+		assert.Contains(t, workflow, "runs-on: macos-latest")
+	})
+
+	t.Run("runsOn input wins", func(t *testing.T) {
+		m.Spec.Inputs["runsOn"] = "self-hosted"
+		defer delete(m.Spec.Inputs, "runsOn")
+
+		assert.Equal(t, "self-hosted", generator.getRunsOn(m, m.Spec.Inputs))
+	})
+
+	t.Run("other languages are unaffected", func(t *testing.T) {
+		node := *m
+		node.Spec.Template = "node-app"
+		assert.Equal(t, "ubuntu-latest", generator.getRunsOn(&node, nil))
+	})
+}
+
+func TestWorkflowGenerator_Jobs(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(jobs map[string]manifest.JobSpec) *manifest.Manifest {
+		m := testManifest("node-app", nil)
+		m.Spec.Jobs = jobs
+		return m
+	}
+	m := newManifest(map[string]manifest.JobSpec{
+		"test":  {TemplateSteps: []string{"test"}},
+		"build": {Needs: []string{"test"}},
+		"deploy": {
+			Needs:  []string{"build"},
+			RunsOn: "self-hosted",
+			Steps:  []manifest.CustomStep{{Name: "Deploy", Run: "./deploy.sh"}},
+		},
+	})
+	workflowYAML, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	var workflow GitHubActionsWorkflow
+	require.NoError(t, yaml.Unmarshal([]byte(workflowYAML), &workflow))
+
+	t.Run("jobs are written in dependency order", func(t *testing.T) {
+		var doc yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte(workflowYAML), &doc))
+		jobsNode := mappingValue(doc.Content[0], "jobs")
+		require.NotNil(t, jobsNode)
+
+		var order []string
+		for i := 0; i < len(jobsNode.Content); i += 2 {
+			order = append(order, jobsNode.Content[i].Value)
+		}
+		assert.Equal(t, []string{"test", "build", "deploy"}, order)
+	})
+
+	t.Run("needs are emitted", func(t *testing.T) {
+		assert.Empty(t, workflow.Jobs["test"].Needs)
+		assert.Equal(t, []string{"test"}, workflow.Jobs["build"].Needs)
+		assert.Equal(t, []string{"build"}, workflow.Jobs["deploy"].Needs)
+	})
+
+	t.Run("template steps move with the workspace steps", func(t *testing.T) {
+		assert.Equal(t, []string{"Checkout code", "Setup Node.js", "Install dependencies", "Run tests"}, stepNames(workflow.Jobs["test"].Steps))
+		assert.Equal(t, workflow.Jobs["build"].RunsOn, workflow.Jobs["test"].RunsOn)
+
+		buildSteps := stepNames(workflow.Jobs["build"].Steps)
+		assert.Contains(t, buildSteps, "Checkout code")
+		assert.Contains(t, buildSteps, "Build application")
+		assert.NotContains(t, buildSteps, "Run tests")
+	})
+
+	t.Run("jobs with only their own steps", func(t *testing.T) {
+		deploy := workflow.Jobs["deploy"]
+		assert.Equal(t, []string{"Checkout code", "Deploy"}, stepNames(deploy.Steps))
+		assert.Equal(t, "self-hosted", deploy.RunsOn)
+		assert.Nil(t, deploy.Strategy)
+	})
+
+	t.Run("custom steps follow their target", func(t *testing.T) {
+		m := newManifest(map[string]manifest.JobSpec{
+			"test": {TemplateSteps: []string{"test"}},
+		})
+		m.Spec.CustomSteps = []manifest.CustomStep{
+			{ID: "coverage", Name: "Coverage", Position: "after:test", Run: "npm run coverage"},
+			{Name: "Report", Position: "after:coverage", Run: "npm run report"},
+			{Name: "Lint", Position: "before:build", Run: "npm run lint"},
+		}
+		workflow, err := generator.GenerateWorkflowStruct(m, "default")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Checkout code", "Setup Node.js", "Install dependencies", "Run tests", "Coverage", "Report"}, stepNames(workflow.Jobs["test"].Steps))
+		buildSteps := stepNames(workflow.Jobs[ManagedJobID].Steps)
+		assert.Contains(t, buildSteps, "Lint")
+		assert.NotContains(t, buildSteps, "Coverage")
+		assert.NotContains(t, buildSteps, "Report")
+	})
+
+	t.Run("every job runs in the deployment environment", func(t *testing.T) {
+		m := newManifest(map[string]manifest.JobSpec{
+			"deploy": {Needs: []string{ManagedJobID}, Steps: []manifest.CustomStep{{Name: "Deploy", Run: "./deploy.sh"}}},
+		})
+		m.Spec.Environments = map[string]manifest.EnvironmentConfig{
+			"production": {Environment: &manifest.DeploymentEnvironment{Name: "production"}},
+		}
+		workflow, err := generator.GenerateWorkflowStruct(m, "production")
+		require.NoError(t, err)
+
+		require.NotNil(t, workflow.Jobs["deploy"].Environment)
+		assert.Equal(t, "production", workflow.Jobs["deploy"].Environment.Name)
+		assert.Equal(t, workflow.Jobs[ManagedJobID].Environment, workflow.Jobs["deploy"].Environment)
+	})
+
+	t.Run("without jobs only the build job is generated", func(t *testing.T) {
+		workflowYAML, err := generator.GenerateWorkflow(newManifest(nil), "default")
+		require.NoError(t, err)
+
+		var workflow GitHubActionsWorkflow
+		require.NoError(t, yaml.Unmarshal([]byte(workflowYAML), &workflow))
+		assert.Len(t, workflow.Jobs, 1)
+		assert.Contains(t, stepNames(workflow.Jobs[ManagedJobID].Steps), "Run tests")
+		assert.Empty(t, workflow.Jobs[ManagedJobID].Needs)
+	})
+
+	t.Run("invalid template steps", func(t *testing.T) {
+		_, err := generator.GenerateWorkflow(newManifest(map[string]manifest.JobSpec{
+			"e2e": {TemplateSteps: []string{"e2e"}},
+		}), "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "job e2e: unknown template step: e2e")
+
+		_, err = generator.GenerateWorkflow(newManifest(map[string]manifest.JobSpec{
+			"test": {TemplateSteps: []string{"checkout", "test"}},
+		}), "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "template step checkout is repeated in every job")
+	})
+
+	t.Run("merging replaces every generated job", func(t *testing.T) {
+		existing := "name: multi-job\non:\n  push: {}\njobs:\n  test:\n    runs-on: ubuntu-latest\n    steps:\n      - run: npm test\n  lint:\n    runs-on: ubuntu-latest\n    steps:\n      - run: npm run lint\n"
+		merged, err := MergeWorkflow(existing, workflowYAML)
+		require.NoError(t, err)
+
+		var parsed GitHubActionsWorkflow
+		require.NoError(t, yaml.Unmarshal([]byte(merged), &parsed))
+		assert.Len(t, parsed.Jobs, 4)
+		assert.Equal(t, stepNames(workflow.Jobs["test"].Steps), stepNames(parsed.Jobs["test"].Steps))
+		assert.Contains(t, parsed.Jobs, "lint")
+	})
+
+	t.Run("merging removes jobs no longer generated", func(t *testing.T) {
+		existing := workflowYAML + "  lint:\n    runs-on: ubuntu-latest\n    steps:\n      - run: npm run lint\n"
+		fewer, err := generator.GenerateWorkflow(newManifest(map[string]manifest.JobSpec{
+			"test": {TemplateSteps: []string{"test"}},
+		}), "default")
+		require.NoError(t, err)
+
+		merged, err := MergeWorkflow(existing, fewer)
+		require.NoError(t, err)
+
+		var parsed GitHubActionsWorkflow
+		require.NoError(t, yaml.Unmarshal([]byte(merged), &parsed))
+		assert.Contains(t, parsed.Jobs, "test")
+		assert.Contains(t, parsed.Jobs, ManagedJobID)
+		assert.Contains(t, parsed.Jobs, "lint", "hand-written jobs are kept")
+		assert.NotContains(t, parsed.Jobs, "deploy")
+		assert.Contains(t, merged, "# gpgen-jobs: build, test\n")
+	})
+}
+
+func TestWorkflowGenerator_CustomStepDefaultNames(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	m := &manifest.Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Metadata:   &manifest.ManifestMetadata{Name: "step-names"},
+		Spec: manifest.ManifestSpec{
+			Template: "node-app",
+			CustomSteps: []manifest.CustomStep{
+				{ID: "run-e2e", Position: "after:test", Run: "npm run e2e"},
+				{Position: "before:build", Run: "npm run lint"},
+				{Name: "Publish coverage", Position: "after:test", Run: "npm run coverage"},
+			},
+		},
+	}
+
+	workflowYAML, err := generator.GenerateWorkflow(m, "default")
+	require.NoError(t, err)
+
+	steps := make(map[string]WorkflowStep)
+	for _, step := range parseBuildSteps(t, workflowYAML) {
+		steps[step.Run] = step
+	}
+
+	assert.Equal(t, "Run E2e", steps["npm run e2e"].Name, "named after the step id")
+	assert.Equal(t, "run-e2e", steps["npm run e2e"].ID)
+	assert.Equal(t, "Before Build", steps["npm run lint"].Name, "named after the position")
+	assert.Empty(t, steps["npm run lint"].ID)
+	assert.Equal(t, "Publish coverage", steps["npm run coverage"].Name, "explicit names are unchanged")
+}
@@ -0,0 +1,223 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/terrpan/gpgen/pkg/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// LockFileName is the name of the lockfile written next to the generated workflows
+const LockFileName = ".gpgen.lock"
+
+// lockHeader is the comment written at the top of lockfiles
+const lockHeader = "# Generated by gpgen. DO NOT EDIT.\n# Records the gpgen version, template and effective inputs the workflows were generated from.\n"
+
+// LockFile is the content of a lockfile. Every manifest generating workflows into the same
+// directory records its lock in it, keyed by LockKey.
+type LockFile struct {
+	Manifests map[string]*Lock `yaml:"manifests"`
+}
+
+// LockKey returns the key a manifest's lock is recorded under: its metadata.name, which also
+// names its workflow files, so two manifests sharing an output directory can't collide
+func LockKey(m *manifest.Manifest) string {
+	return m.Metadata.Name
+}
+
+// Lock records what a manifest's workflows were generated from, for reproducibility audits
+type Lock struct {
+	GpgenVersion string                     `yaml:"gpgenVersion"`
+	Template     LockTemplate               `yaml:"template"`
+	Environments map[string]LockEnvironment `yaml:"environments"`
+}
+
+// LockTemplate identifies the template workflows were generated from
+type LockTemplate struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Hash    string `yaml:"hash"`
+}
+
+// LockEnvironment holds the effective inputs an environment's workflow was generated with
+type LockEnvironment struct {
+	Inputs map[string]interface{} `yaml:"inputs"`
+}
+
+// BuildLock resolves the effective inputs of each environment into a lock
+func (g *WorkflowGenerator) BuildLock(m *manifest.Manifest, environments []string, gpgenVersion string) (*Lock, error) {
+	tmpl, err := g.templateManager.LoadTemplate(m.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+	templateHash, err := g.TemplateHash(m.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash template: %w", err)
+	}
+
+	lock := &Lock{
+		GpgenVersion: gpgenVersion,
+		Template: LockTemplate{
+			Name:    m.Spec.Template,
+			Version: tmpl.Version,
+			Hash:    templateHash,
+		},
+		Environments: make(map[string]LockEnvironment, len(environments)),
+	}
+	for _, env := range environments {
+		// Round trip the inputs so they compare equal to a lock read back from disk
+		data, err := yaml.Marshal(g.getEffectiveInputs(m, env))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode inputs for %s: %w", env, err)
+		}
+		var inputs map[string]interface{}
+		if err := yaml.Unmarshal(data, &inputs); err != nil {
+			return nil, fmt.Errorf("failed to decode inputs for %s: %w", env, err)
+		}
+		lock.Environments[env] = LockEnvironment{Inputs: inputs}
+	}
+	return lock, nil
+}
+
+// ParseLockFile parses lockfile content
+func ParseLockFile(data []byte) (*LockFile, error) {
+	var file LockFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+	if file.Manifests == nil {
+		file.Manifests = make(map[string]*Lock)
+	}
+	for key, lock := range file.Manifests {
+		if lock == nil {
+			lock = &Lock{}
+			file.Manifests[key] = lock
+		}
+		if lock.Environments == nil {
+			lock.Environments = make(map[string]LockEnvironment)
+		}
+	}
+	return &file, nil
+}
+
+// Marshal renders the lockfile behind its header
+func (f *LockFile) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(lockHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return nil, fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Update replaces the lock's versions and the environments recorded in current, keeping
+// environments current doesn't cover (e.g. when generating a single environment)
+func (l *Lock) Update(current *Lock) {
+	l.GpgenVersion = current.GpgenVersion
+	l.Template = current.Template
+	for env, locked := range current.Environments {
+		l.Environments[env] = locked
+	}
+}
+
+// Drift describes how current differs from the lock for the environments current covers:
+// a different template, or inputs that were added, removed or changed. The gpgen version
+// is informational and not compared.
+func (l *Lock) Drift(current *Lock) []string {
+	var drift []string
+	if l.Template.Name != current.Template.Name {
+		drift = append(drift, fmt.Sprintf("template changed from %s to %s", l.Template.Name, current.Template.Name))
+	} else if l.Template.Version != current.Template.Version || l.Template.Hash != current.Template.Hash {
+		drift = append(drift, fmt.Sprintf("template %s changed from version %s (%s) to %s (%s)",
+			current.Template.Name, l.Template.Version, l.Template.Hash, current.Template.Version, current.Template.Hash))
+	}
+
+	envs := make([]string, 0, len(current.Environments))
+	for env := range current.Environments {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	for _, env := range envs {
+		locked, exists := l.Environments[env]
+		if !exists {
+			drift = append(drift, fmt.Sprintf("environment %s is not locked", env))
+			continue
+		}
+		drift = append(drift, inputDrift(env, "", locked.Inputs, current.Environments[env].Inputs)...)
+	}
+	return drift
+}
+
+// inputDrift describes the differences between locked and current inputs, descending into
+// nested inputs so a change names the exact key (e.g. security.trivy.severity)
+func inputDrift(env, prefix string, locked, current map[string]interface{}) []string {
+	keys := make(map[string]bool, len(locked)+len(current))
+	for key := range locked {
+		keys[key] = true
+	}
+	for key := range current {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var drift []string
+	for _, key := range sortedKeys {
+		path := prefix + key
+		lockedValue, wasLocked := locked[key]
+		currentValue, isCurrent := current[key]
+		switch {
+		case !wasLocked:
+			drift = append(drift, fmt.Sprintf("environment %s: input %s was added (%s)", env, path, formatLockValue(currentValue)))
+		case !isCurrent:
+			drift = append(drift, fmt.Sprintf("environment %s: input %s was removed (was %s)", env, path, formatLockValue(lockedValue)))
+		default:
+			lockedMap, lockedIsMap := lockedValue.(map[string]interface{})
+			currentMap, currentIsMap := currentValue.(map[string]interface{})
+			if lockedIsMap && currentIsMap {
+				drift = append(drift, inputDrift(env, path+".", lockedMap, currentMap)...)
+			} else if !reflect.DeepEqual(lockedValue, currentValue) {
+				drift = append(drift, fmt.Sprintf("environment %s: input %s changed from %s to %s", env, path, formatLockValue(lockedValue), formatLockValue(currentValue)))
+			}
+		}
+	}
+	return drift
+}
+
+// formatLockValue renders an input value on one line for drift messages
+func formatLockValue(value interface{}) string {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err == nil && len(node.Content) > 0 {
+		// Flow style keeps lists and mappings on one line
+		setFlowStyle(node.Content[0])
+		if flow, err := yaml.Marshal(node.Content[0]); err == nil {
+			data = flow
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// setFlowStyle renders a node and its children in flow style
+func setFlowStyle(node *yaml.Node) {
+	node.Style |= yaml.FlowStyle
+	for _, child := range node.Content {
+		setFlowStyle(child)
+	}
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+func TestLock(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	newManifest := func(severity string) *manifest.Manifest {
+		return testManifest("go-service", map[string]interface{}{
+			"security": map[string]interface{}{
+				"trivy": map[string]interface{}{"severity": severity},
+			},
+		})
+	}
+
+	lock, err := generator.BuildLock(newManifest("CRITICAL,HIGH"), []string{"default", "production"}, "1.2.3")
+	require.NoError(t, err)
+
+	t.Run("round trips through the lockfile", func(t *testing.T) {
+		data := mustMarshalLock(t, lock)
+		assert.Contains(t, string(data), "# Generated by gpgen. DO NOT EDIT.")
+
+		parsed, err := ParseLockFile(data)
+		require.NoError(t, err)
+		require.Contains(t, parsed.Manifests, "test-app")
+		assert.Equal(t, lock, parsed.Manifests["test-app"])
+		assert.Empty(t, parsed.Manifests["test-app"].Drift(lock))
+	})
+
+	t.Run("manifests sharing a lockfile keep their own entries", func(t *testing.T) {
+		other := testManifest("node-app", nil)
+		other.Metadata.Name = "web"
+		otherLock, err := generator.BuildLock(other, []string{"default"}, "1.2.3")
+		require.NoError(t, err)
+
+		file := &LockFile{Manifests: map[string]*Lock{
+			LockKey(newManifest("CRITICAL,HIGH")): lock,
+			LockKey(other):                        otherLock,
+		}}
+		data, err := file.Marshal()
+		require.NoError(t, err)
+
+		parsed, err := ParseLockFile(data)
+		require.NoError(t, err)
+		assert.Equal(t, "go-service", parsed.Manifests["test-app"].Template.Name)
+		assert.Equal(t, "node-app", parsed.Manifests["web"].Template.Name)
+	})
+
+	t.Run("nested input changes name the exact key", func(t *testing.T) {
+		current, err := generator.BuildLock(newManifest("CRITICAL"), []string{"default"}, "1.2.4")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			`environment default: input security.trivy.severity changed from CRITICAL,HIGH to CRITICAL`,
+		}, lock.Drift(current), "only the checked environments are compared and the gpgen version is ignored")
+	})
+
+	t.Run("unlocked environments and template changes", func(t *testing.T) {
+		current, err := generator.BuildLock(newManifest("CRITICAL,HIGH"), []string{"staging"}, "1.2.3")
+		require.NoError(t, err)
+		current.Template.Version = "2.0.0"
+
+		drift := lock.Drift(current)
+		require.Len(t, drift, 2)
+		assert.Contains(t, drift[0], "template go-service changed from version 1.0.0")
+		assert.Equal(t, "environment staging is not locked", drift[1])
+	})
+
+	t.Run("update keeps environments that weren't regenerated", func(t *testing.T) {
+		current, err := generator.BuildLock(newManifest("LOW"), []string{"default"}, "1.2.4")
+		require.NoError(t, err)
+
+		parsed, err := ParseLockFile(mustMarshalLock(t, lock))
+		require.NoError(t, err)
+		updated := parsed.Manifests["test-app"]
+		updated.Update(current)
+
+		assert.Equal(t, "1.2.4", updated.GpgenVersion)
+		assert.Contains(t, updated.Environments, "production")
+		assert.Empty(t, updated.Drift(current))
+	})
+}
+
+// mustMarshalLock renders a lockfile holding the lock as test-app's entry
+func mustMarshalLock(t *testing.T, lock *Lock) []byte {
+	t.Helper()
+	data, err := (&LockFile{Manifests: map[string]*Lock{"test-app": lock}}).Marshal()
+	require.NoError(t, err)
+	return data
+}
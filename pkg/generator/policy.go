@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/terrpan/gpgen/pkg/config"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+// CheckPolicy generates the workflow for an environment and returns how it violates the
+// policy: steps in any job that use a disallowed action, required steps that are missing from
+// every job or can never run, and custom steps whose id reuses a required step's. A nil result
+// means the workflow complies.
+func (g *WorkflowGenerator) CheckPolicy(m *manifest.Manifest, environment string, policy config.Policy) ([]string, error) {
+	workflow, err := g.GenerateWorkflowStruct(m, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	for _, jobID := range workflow.Jobs.order() {
+		for _, step := range workflow.Jobs[jobID].Steps {
+			if step.Uses == "" {
+				continue
+			}
+			if banned, disallowed := policy.DisallowedAction(step.Uses); disallowed {
+				violations = append(violations, fmt.Sprintf("%s in job %s uses disallowed action %s (disallowed by %s)",
+					describePolicyStep(step), jobID, step.Uses, banned))
+			}
+		}
+	}
+
+	// Required steps are template steps: only the template step ID counts, so a custom step
+	// with the same id or name can't stand in for one. With security.trivy.scans set, the
+	// first scan keeps the security-scan and upload-sarif IDs.
+	for _, required := range policy.RequiredSteps {
+		found, enabled := false, false
+		for _, jobID := range workflow.Jobs.order() {
+			for _, step := range workflow.Jobs[jobID].Steps {
+				switch {
+				case step.templateID == required:
+					found = true
+					enabled = enabled || step.If == "" || !isStaticallyFalse(step.If)
+				case step.templateID == "" && step.ID == required:
+					violations = append(violations, fmt.Sprintf("%s in job %s reuses the id of required step %s",
+						describePolicyStep(step), jobID, required))
+				}
+			}
+		}
+		switch {
+		case !found:
+			violations = append(violations, fmt.Sprintf("required step %s is missing from every job", required))
+		case !enabled:
+			violations = append(violations, fmt.Sprintf("required step %s is disabled: its condition is always false", required))
+		}
+	}
+
+	return violations, nil
+}
+
+// describePolicyStep names a step for a policy violation, noting when it doesn't come from
+// the template
+func describePolicyStep(step WorkflowStep) string {
+	if step.templateID == "" {
+		return fmt.Sprintf("step %q (customSteps)", step.Name)
+	}
+	return fmt.Sprintf("step %q", step.Name)
+}
@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/config"
+	"github.com/terrpan/gpgen/pkg/manifest"
+)
+
+func TestWorkflowGenerator_CheckPolicy(t *testing.T) {
+	generator := NewWorkflowGenerator("")
+	policy := config.Policy{
+		DisallowedActions: []string{"untrusted/*"},
+		RequiredSteps:     []string{"security-scan"},
+	}
+
+	newManifest := func(customSteps []manifest.CustomStep, inputs map[string]interface{}) *manifest.Manifest {
+		m := testManifest("node-app", inputs)
+		m.Spec.CustomSteps = customSteps
+		return m
+	}
+
+	t.Run("compliant manifest passes", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{Name: "Lint", Position: "after:test", Uses: "acme/lint@v1"},
+		}, nil)
+
+		violations, err := generator.CheckPolicy(m, "default", policy)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("banned action in a custom step", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{Name: "Deploy preview", Position: "after:test", Uses: "untrusted/deploy@v1"},
+		}, nil)
+
+		violations, err := generator.CheckPolicy(m, "default", policy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`step "Deploy preview" (customSteps) in job build uses disallowed action untrusted/deploy@v1 (disallowed by untrusted/*)`,
+		}, violations)
+	})
+
+	t.Run("banned action in a template step", func(t *testing.T) {
+		violations, err := generator.CheckPolicy(newManifest(nil, nil), "default", config.Policy{
+			DisallowedActions: []string{"aquasecurity/trivy-action@master"},
+		})
+		require.NoError(t, err)
+		require.Len(t, violations, 1)
+		assert.Contains(t, violations[0], `step "Run Trivy vulnerability scanner" in job build uses disallowed action aquasecurity/trivy-action@master`)
+	})
+
+	t.Run("required step replaced by a custom step", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{Name: "Run Trivy vulnerability scanner", Position: "replace:security-scan", Run: "echo skipped"},
+		}, nil)
+
+		violations, err := generator.CheckPolicy(m, "default", policy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"required step security-scan is missing from every job"}, violations)
+	})
+
+	t.Run("custom step reusing a required id", func(t *testing.T) {
+		m := newManifest([]manifest.CustomStep{
+			{ID: "security-scan", Name: "Fake scan", Position: "replace:security-scan", Run: "echo skipped"},
+		}, nil)
+
+		violations, err := generator.CheckPolicy(m, "default", policy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`step "Fake scan" (customSteps) in job build reuses the id of required step security-scan`,
+			"required step security-scan is missing from every job",
+		}, violations)
+	})
+
+	t.Run("required step moved to another job", func(t *testing.T) {
+		m := newManifest(nil, nil)
+		m.Spec.Jobs = map[string]manifest.JobSpec{
+			"scan": {Needs: []string{ManagedJobID}, TemplateSteps: []string{"security-scan", "upload-sarif"}},
+		}
+
+		violations, err := generator.CheckPolicy(m, "default", policy)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("required step disabled by inputs", func(t *testing.T) {
+		m := newManifest(nil, map[string]interface{}{
+			"security": map[string]interface{}{
+				"trivy": map[string]interface{}{"enabled": false},
+			},
+		})
+
+		violations, err := generator.CheckPolicy(m, "default", policy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"required step security-scan is disabled: its condition is always false"}, violations)
+	})
+}
@@ -0,0 +1,174 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Editor edits a manifest's YAML in place, keeping the user's comments, key order and
+// formatting choices that a round trip through the Manifest struct would discard
+type Editor struct {
+	doc yaml.Node
+}
+
+// NewEditor parses manifest YAML for editing
+func NewEditor(data []byte) (*Editor, error) {
+	var e Editor
+	if err := yaml.Unmarshal(data, &e.doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if e.doc.Kind != yaml.DocumentNode || len(e.doc.Content) == 0 || e.doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("manifest is not a mapping")
+	}
+	return &e, nil
+}
+
+// Get returns the node at path, or nil when any part of it is missing
+func (e *Editor) Get(path ...string) *yaml.Node {
+	node := e.doc.Content[0]
+	for _, key := range path {
+		_, node = lookupKey(node, key)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Set stores value at path, creating intermediate mappings as needed. An existing key keeps
+// its position and comments.
+func (e *Editor) Set(value *yaml.Node, path ...string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("path cannot be empty")
+	}
+
+	node := e.doc.Content[0]
+	for i, key := range path {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("cannot set %v: %s is not a mapping", path, key)
+		}
+		keyNode, child := lookupKey(node, key)
+		if i == len(path)-1 {
+			if child != nil {
+				if value.LineComment == "" {
+					value.LineComment = child.LineComment
+				}
+				*child = *value
+				return nil
+			}
+			keyNode = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+			node.Content = append(node.Content, keyNode, value)
+			return nil
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		node = child
+	}
+	return nil
+}
+
+// Delete removes the key at path, returning its key and value nodes (with their comments),
+// or nils when it doesn't exist
+func (e *Editor) Delete(path ...string) (*yaml.Node, *yaml.Node) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	parent := e.Get(path[:len(path)-1]...)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			key, value := parent.Content[i], parent.Content[i+1]
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return key, value
+		}
+	}
+	return nil, nil
+}
+
+// Bytes renders the edited manifest
+func (e *Editor) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&e.doc); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupKey returns the key and value nodes for key in a mapping node, or nils
+func lookupKey(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// legacyInputPaths maps deprecated flat inputs to the nested inputs that replace them
+var legacyInputPaths = map[string][]string{
+	"trivyScanEnabled":   {"security", "trivy", "enabled"},
+	"trivySeverity":      {"security", "trivy", "severity"},
+	"containerEnabled":   {"container", "enabled"},
+	"containerRegistry":  {"container", "registry"},
+	"containerImageName": {"container", "imageName"},
+	"containerImageTag":  {"container", "imageTag"},
+}
+
+// MigrateLegacyInputs moves deprecated flat inputs (e.g. trivyScanEnabled) to their nested
+// replacements in spec.inputs and every environment's inputs, carrying comments along.
+// Legacy values win over nested ones, as they do during generation. It returns a
+// description of each change.
+func MigrateLegacyInputs(e *Editor) ([]string, error) {
+	inputPaths := [][]string{{"spec", "inputs"}}
+	if environments := e.Get("spec", "environments"); environments != nil && environments.Kind == yaml.MappingNode {
+		for i := 0; i < len(environments.Content); i += 2 {
+			inputPaths = append(inputPaths, []string{"spec", "environments", environments.Content[i].Value, "inputs"})
+		}
+	}
+
+	legacyNames := make([]string, 0, len(legacyInputPaths))
+	for name := range legacyInputPaths {
+		legacyNames = append(legacyNames, name)
+	}
+	sort.Strings(legacyNames)
+
+	var changes []string
+	for _, inputsPath := range inputPaths {
+		for _, name := range legacyNames {
+			key, value := e.Delete(append(append([]string(nil), inputsPath...), name)...)
+			if key == nil {
+				continue
+			}
+
+			target := append(append([]string(nil), inputsPath...), legacyInputPaths[name]...)
+			if value.LineComment == "" {
+				value.LineComment = key.LineComment
+			}
+			if err := e.Set(value, target...); err != nil {
+				return changes, err
+			}
+			if key.HeadComment != "" {
+				newKey, _ := lookupKey(e.Get(target[:len(target)-1]...), target[len(target)-1])
+				newKey.HeadComment = key.HeadComment
+			}
+			changes = append(changes, fmt.Sprintf("%s.%s -> %s", strings.Join(inputsPath, "."), name, strings.Join(target, ".")))
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,97 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateLegacyInputs(t *testing.T) {
+	original := `# Pipeline for the payments service
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+metadata:
+  name: payments # owned by the payments team
+spec:
+  template: go-service
+  inputs:
+    goVersion: "1.22"
+    # Scan everything before merging
+    trivyScanEnabled: true
+    trivySeverity: CRITICAL,HIGH # tightened in production
+    security:
+      gosec:
+        enabled: true
+  environments:
+    production:
+      inputs:
+        # Publish release images
+        containerEnabled: true
+`
+
+	editor, err := NewEditor([]byte(original))
+	require.NoError(t, err)
+
+	changes, err := MigrateLegacyInputs(editor)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"spec.inputs.trivyScanEnabled -> spec.inputs.security.trivy.enabled",
+		"spec.inputs.trivySeverity -> spec.inputs.security.trivy.severity",
+		"spec.environments.production.inputs.containerEnabled -> spec.environments.production.inputs.container.enabled",
+	}, changes)
+
+	migrated, err := editor.Bytes()
+	require.NoError(t, err)
+	content := string(migrated)
+
+	t.Run("comments survive", func(t *testing.T) {
+		assert.Contains(t, content, "# Pipeline for the payments service")
+		assert.Contains(t, content, "name: payments # owned by the payments team")
+		assert.Contains(t, content, "# Scan everything before merging")
+		assert.Contains(t, content, "severity: CRITICAL,HIGH # tightened in production")
+		assert.Contains(t, content, "# Publish release images")
+	})
+
+	t.Run("legacy inputs are moved", func(t *testing.T) {
+		m, err := ParseManifest(migrated)
+		require.NoError(t, err)
+
+		assert.NotContains(t, m.Spec.Inputs, "trivyScanEnabled")
+		assert.NotContains(t, m.Spec.Inputs, "trivySeverity")
+		security := m.Spec.Inputs["security"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"enabled": true, "severity": "CRITICAL,HIGH"}, security["trivy"])
+		assert.Equal(t, map[string]interface{}{"enabled": true}, security["gosec"])
+
+		production := m.Spec.Environments["production"].Inputs
+		assert.Equal(t, map[string]interface{}{"enabled": true}, production["container"])
+	})
+
+	t.Run("migrating again changes nothing", func(t *testing.T) {
+		editor, err := NewEditor(migrated)
+		require.NoError(t, err)
+
+		changes, err := MigrateLegacyInputs(editor)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+
+		again, err := editor.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, content, string(again))
+	})
+}
+
+func TestEditor(t *testing.T) {
+	editor, err := NewEditor([]byte("spec:\n  template: node-app # the template\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "node-app", editor.Get("spec", "template").Value)
+	assert.Nil(t, editor.Get("spec", "inputs", "nodeVersion"))
+
+	key, value := editor.Delete("spec", "template")
+	require.NotNil(t, key)
+	assert.Equal(t, "node-app", value.Value)
+
+	_, err = NewEditor([]byte("- not\n- a mapping\n"))
+	assert.Error(t, err)
+}
@@ -3,8 +3,14 @@ package manifest
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 
+	"github.com/terrpan/gpgen/pkg/config"
 	"gopkg.in/yaml.v3"
 )
 
@@ -37,11 +43,132 @@ type ManifestSpec struct {
 	CustomSteps  []CustomStep                 `yaml:"customSteps,omitempty" json:"customSteps,omitempty"`
 	Overrides    map[string]StepOverride      `yaml:"overrides,omitempty" json:"overrides,omitempty"`
 	Environments map[string]EnvironmentConfig `yaml:"environments,omitempty" json:"environments,omitempty"`
+	Jobs         map[string]JobSpec           `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+
+	Env            map[string]string   `yaml:"env,omitempty" json:"env,omitempty"`
+	JobEnv         map[string]string   `yaml:"jobEnv,omitempty" json:"jobEnv,omitempty"`
+	Matrix         map[string][]string `yaml:"matrix,omitempty" json:"matrix,omitempty"`
+	Concurrency    *ConcurrencyConfig  `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	TimeoutMinutes *int                `yaml:"timeoutMinutes,omitempty" json:"timeoutMinutes,omitempty"`
+	Permissions    *Permissions        `yaml:"permissions,omitempty" json:"permissions,omitempty"`
+	Defaults       *JobDefaults        `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+	StepDefaults   *StepDefaults       `yaml:"stepDefaults,omitempty" json:"stepDefaults,omitempty"`
+	Triggers       *TriggersConfig     `yaml:"triggers,omitempty" json:"triggers,omitempty"`
+	SkipIf         string              `yaml:"skipIf,omitempty" json:"skipIf,omitempty"`
+	Schedule       []string            `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+
+	WorkflowNameTemplate string `yaml:"workflowNameTemplate,omitempty" json:"workflowNameTemplate,omitempty"`
+
+	// ActionVersions overrides the actions used by template steps, keyed by action name
+	// (e.g. "checkout") like the configuration file's actionVersions
+	ActionVersions map[string]string `yaml:"actionVersions,omitempty" json:"actionVersions,omitempty"`
+}
+
+// MatrixKeyOS is the matrix dimension that selects the runner each leg runs on
+const MatrixKeyOS = "os"
+
+// Permission shorthands accepted in place of per-scope permissions. PermissionsNone
+// renders as an empty permissions block, revoking every scope.
+const (
+	PermissionsReadAll  = "read-all"
+	PermissionsWriteAll = "write-all"
+	PermissionsNone     = "none"
+)
+
+// Permissions represents an explicit job permissions block: either a shorthand
+// ("read-all", "write-all" or "none") or access levels keyed by scope
+type Permissions struct {
+	Shorthand string
+	Scopes    map[string]string
+}
+
+// UnmarshalYAML accepts either a shorthand string or a scope mapping
+func (p *Permissions) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&p.Shorthand)
+	}
+	return value.Decode(&p.Scopes)
+}
+
+// MarshalYAML renders the shorthand or the scope mapping
+func (p Permissions) MarshalYAML() (interface{}, error) {
+	if p.Shorthand == PermissionsNone {
+		return map[string]string{}, nil
+	}
+	if p.Shorthand != "" {
+		return p.Shorthand, nil
+	}
+	return p.Scopes, nil
+}
+
+// ConcurrencyConfig represents workflow-level concurrency settings
+type ConcurrencyConfig struct {
+	Group            string `yaml:"group,omitempty" json:"group,omitempty"`
+	CancelInProgress *bool  `yaml:"cancel-in-progress,omitempty" json:"cancel-in-progress,omitempty"`
+}
+
+// JobDefaults represents job-level defaults applied to run steps
+type JobDefaults struct {
+	Run *RunDefaults `yaml:"run,omitempty" json:"run,omitempty"`
+}
+
+// RunDefaults represents the shell and working directory used by run steps
+type RunDefaults struct {
+	Shell            string `yaml:"shell,omitempty" json:"shell,omitempty"`
+	WorkingDirectory string `yaml:"working-directory,omitempty" json:"working-directory,omitempty"`
+}
+
+// TriggersConfig represents overrides for the events generated workflows run on. Setting
+// any of push, pull_request, schedule or workflow_dispatch replaces the environment's
+// default triggers; release alone only changes the production release types.
+type TriggersConfig struct {
+	Release          *ReleaseTrigger     `yaml:"release,omitempty" json:"release,omitempty"`
+	Push             *PushTrigger        `yaml:"push,omitempty" json:"push,omitempty"`
+	PullRequest      *PullRequestTrigger `yaml:"pull_request,omitempty" json:"pull_request,omitempty"`
+	Schedule         []ScheduleTrigger   `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	WorkflowDispatch bool                `yaml:"workflow_dispatch,omitempty" json:"workflow_dispatch,omitempty"`
+}
+
+// ReplacesDefaults reports whether the triggers declare events that replace the
+// environment's default triggers
+func (t *TriggersConfig) ReplacesDefaults() bool {
+	return t != nil && (t.Push != nil || t.PullRequest != nil || len(t.Schedule) > 0 || t.WorkflowDispatch)
+}
+
+// PushTrigger represents the branches and tags whose pushes trigger the workflow
+type PushTrigger struct {
+	Branches []string `yaml:"branches,omitempty" json:"branches,omitempty"`
+	Tags     []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// PullRequestTrigger represents the base branches whose pull requests trigger the workflow
+type PullRequestTrigger struct {
+	Branches []string `yaml:"branches,omitempty" json:"branches,omitempty"`
+}
+
+// ScheduleTrigger represents a cron schedule the workflow runs on
+type ScheduleTrigger struct {
+	Cron string `yaml:"cron" json:"cron"`
+}
+
+// ReleaseTrigger represents the release activity types that trigger production workflows
+type ReleaseTrigger struct {
+	Types []string `yaml:"types,omitempty" json:"types,omitempty"`
+}
+
+// StepDefaults represents settings applied to every generated step that doesn't set its own
+type StepDefaults struct {
+	ContinueOnError  *bool  `yaml:"continueOnError,omitempty" json:"continueOnError,omitempty"`
+	TimeoutMinutes   *int   `yaml:"timeoutMinutes,omitempty" json:"timeoutMinutes,omitempty"`
+	Shell            string `yaml:"shell,omitempty" json:"shell,omitempty"`
+	WorkingDirectory string `yaml:"workingDirectory,omitempty" json:"workingDirectory,omitempty"`
 }
 
 // CustomStep represents a custom step in the pipeline
 type CustomStep struct {
-	Name            string            `yaml:"name" json:"name"`
+	// ID is written as the step's id so later steps can read its outputs
+	ID              string            `yaml:"id,omitempty" json:"id,omitempty"`
+	Name            string            `yaml:"name,omitempty" json:"name,omitempty"`
 	Position        string            `yaml:"position" json:"position"`
 	Uses            string            `yaml:"uses,omitempty" json:"uses,omitempty"`
 	Run             string            `yaml:"run,omitempty" json:"run,omitempty"`
@@ -50,6 +177,28 @@ type CustomStep struct {
 	If              string            `yaml:"if,omitempty" json:"if,omitempty"`
 	TimeoutMinutes  *int              `yaml:"timeout-minutes,omitempty" json:"timeout-minutes,omitempty"`
 	ContinueOnError *bool             `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"`
+	// OnEnvironment limits the step to the events of a gpgen environment (staging or production)
+	OnEnvironment string `yaml:"onEnvironment,omitempty" json:"onEnvironment,omitempty"`
+}
+
+// EffectiveName returns the step's name or, when it has none, a title-cased name derived
+// from its id or position, e.g. "run-e2e" becomes "Run E2e" and "after:test" "After Test"
+func (s CustomStep) EffectiveName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	source := s.ID
+	if source == "" {
+		source = s.Position
+	}
+
+	words := strings.FieldsFunc(source, func(r rune) bool {
+		return r == '-' || r == '_' || r == ':' || r == ' '
+	})
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
 }
 
 // StepOverride represents overrides for existing template steps
@@ -69,13 +218,49 @@ type EnvironmentConfig struct {
 	Inputs      map[string]interface{}  `yaml:"inputs,omitempty" json:"inputs,omitempty"`
 	CustomSteps []CustomStep            `yaml:"customSteps,omitempty" json:"customSteps,omitempty"`
 	Overrides   map[string]StepOverride `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+	Environment *DeploymentEnvironment  `yaml:"environment,omitempty" json:"environment,omitempty"`
+	Triggers    *TriggersConfig         `yaml:"triggers,omitempty" json:"triggers,omitempty"`
+}
+
+// DeploymentEnvironment names the GitHub environment a job runs in. Its protection rules
+// (required reviewers, wait timers) are configured in the repository settings, not by gpgen.
+type DeploymentEnvironment struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
+// BuildJobID is the id of the job that runs the template's steps
+const BuildJobID = "build"
+
+// JobSpec represents a job generated next to the build job. Listing template steps moves
+// them out of the build job; the checkout, toolchain setup and install steps are copied
+// along so the job starts from a prepared workspace. The build job's own entry may only
+// set needs and env.
+type JobSpec struct {
+	Needs         []string          `yaml:"needs,omitempty" json:"needs,omitempty"`
+	RunsOn        string            `yaml:"runsOn,omitempty" json:"runsOn,omitempty"`
+	Env           map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	TemplateSteps []string          `yaml:"templateSteps,omitempty" json:"templateSteps,omitempty"`
+	Steps         []CustomStep      `yaml:"steps,omitempty" json:"steps,omitempty"`
 }
 
 var (
-	validAPIVersions = []string{"gpgen.dev/v1"}
-	validKinds       = []string{"Pipeline"}
-	validTemplates   = []string{"node-app", "go-service", "python-app"}
-	positionRegex    = regexp.MustCompile(`^(before|after|replace):[a-z0-9-]+$`)
+	validAPIVersions  = []string{"gpgen.dev/v1"}
+	validKinds        = []string{"Pipeline"}
+	validTemplates    = []string{"node-app", "go-service", "python-app", "rust-service", "java-app"}
+	validAccessLevels = []string{"read", "write", "none"}
+	validStepEnvs     = []string{"staging", "production"}
+	validReleaseTypes = []string{"published", "unpublished", "created", "edited", "deleted", "prereleased", "released"}
+	validRunnerLabels = []string{
+		"ubuntu-latest", "ubuntu-24.04", "ubuntu-22.04", "ubuntu-20.04",
+		"macos-latest", "macos-15", "macos-14", "macos-13",
+		"windows-latest", "windows-2025", "windows-2022", "windows-2019",
+	}
+	positionRegex    = regexp.MustCompile(`^(before|after|replace):[A-Za-z0-9_-]+$`)
+	identifierRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	environmentRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	jobIDRegex       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	stepIDRegex      = jobIDRegex
 )
 
 // ParseManifest parses a YAML manifest into a Manifest struct
@@ -107,9 +292,49 @@ func ParseManifest(data []byte) (*Manifest, error) {
 		return nil, fmt.Errorf("template is required")
 	}
 
+	// Keep the source text of float inputs that decoding would change
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err == nil && len(root.Content) > 0 {
+		spec := mappingValue(root.Content[0], "spec")
+		keepFloatText(manifest.Spec.Inputs, mappingValue(spec, "inputs"))
+		environments := mappingValue(spec, "environments")
+		for envName, envConfig := range manifest.Spec.Environments {
+			keepFloatText(envConfig.Inputs, mappingValue(mappingValue(environments, envName), "inputs"))
+		}
+	}
+
 	return &manifest, nil
 }
 
+// keepFloatText replaces float inputs whose YAML text does not survive decoding with that
+// text, so goVersion: 1.20 stays "1.20" rather than becoming 1.2. Number inputs are
+// coerced back from the string when the workflow is generated.
+func keepFloatText(inputs map[string]interface{}, node *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		f, isFloat := inputs[key].(float64)
+		if isFloat && value.Kind == yaml.ScalarNode && strconv.FormatFloat(f, 'f', -1, 64) != value.Value {
+			inputs[key] = value.Value
+		}
+	}
+}
+
+// mappingValue returns the value node for key in a YAML mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // ValidateManifest validates a parsed manifest according to the schema rules
 func ValidateManifest(manifest *Manifest) error {
 	// Validate API version
@@ -130,6 +355,109 @@ func ValidateManifest(manifest *Manifest) error {
 			manifest.Spec.Template, validTemplates)
 	}
 
+	// Validate job timeout if specified
+	if manifest.Spec.TimeoutMinutes != nil && (*manifest.Spec.TimeoutMinutes < 1 || *manifest.Spec.TimeoutMinutes > 360) {
+		return fmt.Errorf("timeoutMinutes must be between 1 and 360")
+	}
+
+	// Validate step default timeout if specified
+	if stepDefaults := manifest.Spec.StepDefaults; stepDefaults != nil && stepDefaults.TimeoutMinutes != nil &&
+		(*stepDefaults.TimeoutMinutes < 1 || *stepDefaults.TimeoutMinutes > 360) {
+		return fmt.Errorf("stepDefaults.timeoutMinutes must be between 1 and 360")
+	}
+
+	// Validate triggers, including each environment's replacements
+	if err := validateTriggers(manifest.Spec.Triggers); err != nil {
+		return err
+	}
+	for i, cron := range manifest.Spec.Schedule {
+		if err := validateCron(cron); err != nil {
+			return fmt.Errorf("schedule[%d]: %w", i, err)
+		}
+	}
+	for envName, envConfig := range manifest.Spec.Environments {
+		if err := validateTriggers(envConfig.Triggers); err != nil {
+			return fmt.Errorf("environment %s: %w", envName, err)
+		}
+	}
+
+	// Validate workflow name template syntax
+	if manifest.Spec.WorkflowNameTemplate != "" {
+		if _, err := template.New("workflowName").Parse(manifest.Spec.WorkflowNameTemplate); err != nil {
+			return fmt.Errorf("invalid workflowNameTemplate: %w", err)
+		}
+	}
+
+	// Validate concurrency group expressions, which are emitted verbatim
+	if manifest.Spec.Concurrency != nil {
+		if strings.TrimSpace(manifest.Spec.Concurrency.Group) == "" {
+			return fmt.Errorf("concurrency group cannot be empty")
+		}
+		if err := validateExpressionBraces(manifest.Spec.Concurrency.Group); err != nil {
+			return fmt.Errorf("invalid concurrency group: %w", err)
+		}
+	}
+
+	// Validate the skip condition, which is negated into every job's if
+	if manifest.Spec.SkipIf != "" {
+		if err := validateExpressionBraces(manifest.Spec.SkipIf); err != nil {
+			return fmt.Errorf("invalid skipIf: %w", err)
+		}
+	}
+
+	// Validate explicit permissions
+	if err := validatePermissions(manifest.Spec.Permissions); err != nil {
+		return err
+	}
+
+	// Validate pinned actions, keyed by the logical action name or its alias
+	if _, err := config.ResolveActionNames(manifest.Spec.ActionVersions); err != nil {
+		return err
+	}
+
+	// Validate environment names, which end up in workflow file names
+	for envName, envConfig := range manifest.Spec.Environments {
+		if !environmentRegex.MatchString(envName) {
+			return fmt.Errorf("invalid environment name: %q, must match pattern '^[a-z0-9][a-z0-9-]*$'", envName)
+		}
+		if envConfig.Environment != nil && envConfig.Environment.Name == "" {
+			return fmt.Errorf("environment %s: deployment environment name cannot be empty", envName)
+		}
+	}
+
+	// Validate workflow and job env names
+	if err := validateEnvNames(manifest.Spec.Env); err != nil {
+		return err
+	}
+	if err := validateEnvNames(manifest.Spec.JobEnv); err != nil {
+		return fmt.Errorf("jobEnv: %w", err)
+	}
+
+	// Validate matrix dimensions
+	for name, values := range manifest.Spec.Matrix {
+		if !identifierRegex.MatchString(name) {
+			return fmt.Errorf("invalid matrix key: %s, must match pattern '^[A-Za-z_][A-Za-z0-9_]*$'", name)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("matrix key %s must have at least one value", name)
+		}
+		if name == MatrixKeyOS {
+			for _, value := range values {
+				if !contains(validRunnerLabels, value) {
+					return fmt.Errorf("invalid matrix os: %s, must be one of %v", value, validRunnerLabels)
+				}
+			}
+		}
+		if _, exists := manifest.Spec.Inputs[name]; exists {
+			return fmt.Errorf("input %s is set both in inputs and in matrix, remove one to resolve the ambiguity", name)
+		}
+		for envName, envConfig := range manifest.Spec.Environments {
+			if _, exists := envConfig.Inputs[name]; exists {
+				return fmt.Errorf("input %s in environment %s is also set in matrix, remove one to resolve the ambiguity", name, envName)
+			}
+		}
+	}
+
 	// Validate custom steps
 	for i, step := range manifest.Spec.CustomSteps {
 		if err := validateCustomStep(&step); err != nil {
@@ -146,21 +474,169 @@ func ValidateManifest(manifest *Manifest) error {
 		}
 	}
 
+	// Validate additional jobs and their dependencies
+	if err := validateJobs(manifest.Spec.Jobs); err != nil {
+		return err
+	}
+
+	// Validate step overrides
+	for stepID, override := range manifest.Spec.Overrides {
+		if err := validateStepOverride(&override); err != nil {
+			return fmt.Errorf("invalid override for step %s: %w", stepID, err)
+		}
+	}
+	for envName, envConfig := range manifest.Spec.Environments {
+		for stepID, override := range envConfig.Overrides {
+			if err := validateStepOverride(&override); err != nil {
+				return fmt.Errorf("invalid override for step %s in environment %s: %w", stepID, envName, err)
+			}
+		}
+	}
+
 	return nil
 }
 
-// validateCustomStep validates a custom step
-func validateCustomStep(step *CustomStep) error {
-	// Validate step name is not empty
-	if step.Name == "" {
-		return fmt.Errorf("step name cannot be empty")
+// validateEnvNames checks that env variable names are valid identifiers
+func validateEnvNames(env map[string]string) error {
+	for name := range env {
+		if !identifierRegex.MatchString(name) {
+			return fmt.Errorf("invalid env name: %s, must match pattern '^[A-Za-z_][A-Za-z0-9_]*$'", name)
+		}
+	}
+	return nil
+}
+
+// validatePermissions validates an explicit permissions block
+func validatePermissions(permissions *Permissions) error {
+	if permissions == nil {
+		return nil
 	}
 
+	if permissions.Shorthand != "" {
+		if permissions.Shorthand != PermissionsReadAll && permissions.Shorthand != PermissionsWriteAll && permissions.Shorthand != PermissionsNone {
+			return fmt.Errorf("invalid permissions: %s, must be %s, %s, %s or a mapping of scopes",
+				permissions.Shorthand, PermissionsReadAll, PermissionsWriteAll, PermissionsNone)
+		}
+		return nil
+	}
+
+	for scope, level := range permissions.Scopes {
+		if !contains(validAccessLevels, level) {
+			return fmt.Errorf("invalid permission level for %s: %s, must be one of %v", scope, level, validAccessLevels)
+		}
+	}
+	return nil
+}
+
+// validateJobs validates additional jobs: their ids, steps and needs, which must name
+// defined jobs without forming a cycle
+func validateJobs(jobs map[string]JobSpec) error {
+	jobIDs := make([]string, 0, len(jobs))
+	for jobID := range jobs {
+		jobIDs = append(jobIDs, jobID)
+	}
+	sort.Strings(jobIDs)
+
+	movedBy := make(map[string]string)
+	for _, jobID := range jobIDs {
+		job := jobs[jobID]
+		if !jobIDRegex.MatchString(jobID) {
+			return fmt.Errorf("invalid job id: %s, must match pattern '^[A-Za-z_][A-Za-z0-9_-]*$'", jobID)
+		}
+		if jobID == BuildJobID {
+			if job.RunsOn != "" || len(job.TemplateSteps) > 0 || len(job.Steps) > 0 {
+				return fmt.Errorf("job %s: only needs and env can be set for the build job", jobID)
+			}
+		} else if len(job.TemplateSteps) == 0 && len(job.Steps) == 0 {
+			return fmt.Errorf("job %s: must have templateSteps or steps", jobID)
+		}
+
+		if err := validateEnvNames(job.Env); err != nil {
+			return fmt.Errorf("job %s: %w", jobID, err)
+		}
+
+		for _, need := range job.Needs {
+			if _, exists := jobs[need]; !exists && need != BuildJobID {
+				return fmt.Errorf("job %s: needs unknown job %s", jobID, need)
+			}
+		}
+
+		for _, stepID := range job.TemplateSteps {
+			if other, exists := movedBy[stepID]; exists {
+				return fmt.Errorf("job %s: template step %s is already moved to job %s", jobID, stepID, other)
+			}
+			movedBy[stepID] = jobID
+		}
+
+		for i, step := range job.Steps {
+			if err := validateJobStep(&step); err != nil {
+				return fmt.Errorf("job %s: invalid step at index %d: %w", jobID, i, err)
+			}
+		}
+	}
+
+	// Walk each job's needs depth first; reaching a job already on the path is a cycle
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(jobs))
+	var visit func(jobID string, path []string) error
+	visit = func(jobID string, path []string) error {
+		switch state[jobID] {
+		case visiting:
+			return fmt.Errorf("jobs have a needs cycle: %s", strings.Join(append(path, jobID), " -> "))
+		case visited:
+			return nil
+		}
+		state[jobID] = visiting
+		for _, need := range jobs[jobID].Needs {
+			if err := visit(need, append(path, jobID)); err != nil {
+				return err
+			}
+		}
+		state[jobID] = visited
+		return nil
+	}
+	for _, jobID := range jobIDs {
+		if err := visit(jobID, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCustomStep validates a custom step
+func validateCustomStep(step *CustomStep) error {
 	// Validate position format
 	if err := validatePosition(step.Position); err != nil {
 		return err
 	}
 
+	return validateStepContent(step)
+}
+
+// validateJobStep validates a step of an additional job, which is appended to the job
+// unless it sets a position
+func validateJobStep(step *CustomStep) error {
+	if step.Name == "" && step.ID == "" && step.Position == "" {
+		return fmt.Errorf("step must have a name, id or position")
+	}
+	if step.Position != "" {
+		if err := validatePosition(step.Position); err != nil {
+			return err
+		}
+	}
+	return validateStepContent(step)
+}
+
+// validateStepContent validates what a custom step runs and how
+func validateStepContent(step *CustomStep) error {
+	if step.ID != "" && !stepIDRegex.MatchString(step.ID) {
+		return fmt.Errorf("invalid step id: %s, must match pattern '^[A-Za-z_][A-Za-z0-9_-]*$'", step.ID)
+	}
+
 	// Validate that step has either uses or run, but not both
 	hasUses := step.Uses != ""
 	hasRun := step.Run != ""
@@ -173,18 +649,141 @@ func validateCustomStep(step *CustomStep) error {
 		return fmt.Errorf("step cannot have both 'uses' and 'run'")
 	}
 
+	if IsLocalAction(step.Uses) {
+		if err := validateLocalAction(step.Uses); err != nil {
+			return err
+		}
+	}
+
 	// Validate timeout if specified
 	if step.TimeoutMinutes != nil && (*step.TimeoutMinutes < 1 || *step.TimeoutMinutes > 360) {
 		return fmt.Errorf("timeout-minutes must be between 1 and 360")
 	}
 
+	if step.OnEnvironment != "" && !contains(validStepEnvs, step.OnEnvironment) {
+		return fmt.Errorf("invalid onEnvironment: %s, must be one of %v", step.OnEnvironment, validStepEnvs)
+	}
+
+	return nil
+}
+
+// validateStepOverride validates an override of a template step. An override may replace the
+// step's body with either uses or run, but not both.
+func validateStepOverride(override *StepOverride) error {
+	if override.Uses != "" && override.Run != "" {
+		return fmt.Errorf("override cannot have both 'uses' and 'run'")
+	}
+	if override.Run != "" && len(override.With) > 0 {
+		return fmt.Errorf("override cannot set 'with' when replacing the step with 'run'")
+	}
+
+	if IsLocalAction(override.Uses) {
+		if err := validateLocalAction(override.Uses); err != nil {
+			return err
+		}
+	}
+
+	if override.TimeoutMinutes != nil && (*override.TimeoutMinutes < 1 || *override.TimeoutMinutes > 360) {
+		return fmt.Errorf("timeout-minutes must be between 1 and 360")
+	}
+
+	return nil
+}
+
+// IsLocalAction reports whether uses references an action in the repository itself
+func IsLocalAction(uses string) bool {
+	return strings.HasPrefix(uses, "./")
+}
+
+// validateLocalAction checks that a local action reference is a directory inside the repository
+func validateLocalAction(uses string) error {
+	actionPath := strings.TrimPrefix(uses, "./")
+	if actionPath == "" || strings.HasSuffix(actionPath, "/") {
+		return fmt.Errorf("invalid local action: %s, must reference an action directory like './.github/actions/setup'", uses)
+	}
+	if strings.Contains(actionPath, "@") {
+		return fmt.Errorf("invalid local action: %s, local actions cannot be pinned to a version", uses)
+	}
+	for _, segment := range strings.Split(actionPath, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid local action: %s, path must stay inside the repository", uses)
+		}
+	}
+	return nil
+}
+
+// validateTriggers checks release activity types against GitHub's and every schedule's cron
+// expression
+func validateTriggers(triggers *TriggersConfig) error {
+	if triggers == nil {
+		return nil
+	}
+
+	if triggers.Release != nil {
+		for _, releaseType := range triggers.Release.Types {
+			if !contains(validReleaseTypes, releaseType) {
+				return fmt.Errorf("invalid release trigger type: %s, must be one of %v", releaseType, validReleaseTypes)
+			}
+		}
+	}
+
+	for i, schedule := range triggers.Schedule {
+		if err := validateCron(schedule.Cron); err != nil {
+			return fmt.Errorf("triggers.schedule[%d]: %w", i, err)
+		}
+	}
 	return nil
 }
 
+// validateCron checks that a schedule is a five-field POSIX cron expression, the only form
+// GitHub Actions accepts
+func validateCron(cron string) error {
+	fields := strings.Fields(cron)
+	if len(fields) == 0 {
+		return fmt.Errorf("cron cannot be empty")
+	}
+	if len(fields) != 5 {
+		return fmt.Errorf("invalid cron %q: expected 5 fields (minute hour day-of-month month day-of-week), got %d", cron, len(fields))
+	}
+	return nil
+}
+
+// validateExpressionBraces checks that every ${{ in value is closed by a matching }} and
+// that expressions are neither nested nor empty
+func validateExpressionBraces(value string) error {
+	rest := value
+	for {
+		open := strings.Index(rest, "${{")
+		closing := strings.Index(rest, "}}")
+		if open < 0 {
+			if closing >= 0 {
+				return fmt.Errorf("%q has '}}' without a matching '${{'", value)
+			}
+			return nil
+		}
+		if closing >= 0 && closing < open {
+			return fmt.Errorf("%q has '}}' without a matching '${{'", value)
+		}
+
+		expression := rest[open+len("${{"):]
+		end := strings.Index(expression, "}}")
+		if end < 0 {
+			return fmt.Errorf("%q has an unclosed '${{'", value)
+		}
+		if strings.Contains(expression[:end], "${{") {
+			return fmt.Errorf("%q has a nested '${{'", value)
+		}
+		if strings.TrimSpace(expression[:end]) == "" {
+			return fmt.Errorf("%q has an empty expression", value)
+		}
+		rest = expression[end+len("}}"):]
+	}
+}
+
 // validatePosition validates the position string format
 func validatePosition(position string) error {
 	if !positionRegex.MatchString(position) {
-		return fmt.Errorf("invalid position format: %s, must match pattern '^(before|after|replace):[a-z0-9-]+$'", position)
+		return fmt.Errorf("invalid position format: %s, must match pattern '^(before|after|replace):[A-Za-z0-9_-]+$'", position)
 	}
 	return nil
 }
@@ -208,6 +807,199 @@ func GetValidationMode(manifest *Manifest) ValidationMode {
 	}
 }
 
+// CheckDeploymentEnvironments recommends a GitHub deployment environment for every
+// production-like environment without one, so deploys can be gated by protection rules
+func CheckDeploymentEnvironments(manifest *Manifest) []string {
+	envNames := make([]string, 0, len(manifest.Spec.Environments))
+	for envName := range manifest.Spec.Environments {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+
+	var warnings []string
+	for _, envName := range envNames {
+		if !strings.HasPrefix(envName, "prod") || manifest.Spec.Environments[envName].Environment != nil {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"environment %s has no deployment environment; set environments.%s.environment.name and configure its protection rules in GitHub so deploys are gated",
+			envName, envName))
+	}
+	return warnings
+}
+
+// StrictModeLine is the line that makes bash stop at the first failing command, unset
+// variable or failing pipeline stage
+const StrictModeLine = "set -euo pipefail"
+
+// IsBashShell reports whether a step shell runs its script with bash. An empty shell is
+// GitHub's default, which is bash on Linux and macOS runners.
+func IsBashShell(shell string) bool {
+	return shell == "" || shell == "bash" || strings.HasPrefix(shell, "bash ")
+}
+
+// NeedsStrictMode reports whether a run script spans several lines without enabling bash
+// strict mode (set -e, -u and -o pipefail) before its first command. The options may be
+// spread over several set lines.
+func NeedsStrictMode(script string) bool {
+	script = strings.TrimSpace(script)
+	if !strings.Contains(script, "\n") {
+		return false
+	}
+
+	enabled := map[string]bool{}
+	strict := func() bool {
+		return enabled["errexit"] && enabled["nounset"] && enabled["pipefail"]
+	}
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if fields[0] != "set" {
+			return !strict()
+		}
+		setShellOptions(enabled, fields[1:])
+	}
+	return false
+}
+
+// shellOptionFlags maps the set flags of the strict mode options to their long names
+var shellOptionFlags = map[rune]string{'e': "errexit", 'u': "nounset"}
+
+// setShellOptions records the options a set command's arguments enable or disable, e.g.
+// "-euo pipefail" or "+e"
+func setShellOptions(enabled map[string]bool, args []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || (arg[0] != '-' && arg[0] != '+') {
+			continue
+		}
+		on := arg[0] == '-'
+		for _, flag := range arg[1:] {
+			if flag == 'o' && i+1 < len(args) {
+				i++
+				enabled[args[i]] = on
+			} else if name, ok := shellOptionFlags[flag]; ok {
+				enabled[name] = on
+			}
+		}
+	}
+}
+
+// CheckScriptStrictMode recommends set -euo pipefail for every multi-line bash script in the
+// manifest's custom steps and step overrides, so a failing command doesn't go unnoticed.
+// With hardened, custom steps are skipped, as generate --harden-scripts rewrites them.
+func CheckScriptStrictMode(manifest *Manifest, hardened bool) []string {
+	shell := ""
+	if manifest.Spec.Defaults != nil && manifest.Spec.Defaults.Run != nil {
+		shell = manifest.Spec.Defaults.Run.Shell
+	}
+	if manifest.Spec.StepDefaults != nil && manifest.Spec.StepDefaults.Shell != "" {
+		shell = manifest.Spec.StepDefaults.Shell
+	}
+	if !IsBashShell(shell) {
+		return nil
+	}
+
+	var warnings []string
+	check := func(prefix string, customSteps []CustomStep, overrides map[string]StepOverride) {
+		for _, step := range customSteps {
+			if !hardened && NeedsStrictMode(step.Run) {
+				warnings = append(warnings, fmt.Sprintf("%scustom step %q runs a multi-line script without %q; add it as the first line or generate with --harden-scripts", prefix, step.EffectiveName(), StrictModeLine))
+			}
+		}
+		ids := make([]string, 0, len(overrides))
+		for id := range overrides {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if NeedsStrictMode(overrides[id].Run) {
+				warnings = append(warnings, fmt.Sprintf("%soverride for step %s runs a multi-line script without %q; add it as the first line", prefix, id, StrictModeLine))
+			}
+		}
+	}
+
+	check("", manifest.Spec.CustomSteps, manifest.Spec.Overrides)
+	envNames := make([]string, 0, len(manifest.Spec.Environments))
+	for envName := range manifest.Spec.Environments {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+	for _, envName := range envNames {
+		envConfig := manifest.Spec.Environments[envName]
+		check(fmt.Sprintf("environment %s: ", envName), envConfig.CustomSteps, envConfig.Overrides)
+	}
+	jobIDs := make([]string, 0, len(manifest.Spec.Jobs))
+	for jobID := range manifest.Spec.Jobs {
+		jobIDs = append(jobIDs, jobID)
+	}
+	sort.Strings(jobIDs)
+	for _, jobID := range jobIDs {
+		check(fmt.Sprintf("job %s: ", jobID), manifest.Spec.Jobs[jobID].Steps, nil)
+	}
+	return warnings
+}
+
+// CheckRequirementsFiles verifies that python-app requirements files exist relative to baseDir.
+// It returns one error per missing file; paths containing templating or expressions are skipped.
+func CheckRequirementsFiles(manifest *Manifest, baseDir string) []error {
+	if manifest.Spec.Template != "python-app" {
+		return nil
+	}
+
+	pythonConfig := config.Config.Languages[config.LanguagePython]
+
+	// Resolve the effective inputs for the default and every named environment
+	envNames := make([]string, 0, len(manifest.Spec.Environments))
+	for envName := range manifest.Spec.Environments {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+
+	effective := []map[string]interface{}{manifest.Spec.Inputs}
+	for _, envName := range envNames {
+		merged := make(map[string]interface{})
+		for k, v := range manifest.Spec.Inputs {
+			merged[k] = v
+		}
+		for k, v := range manifest.Spec.Environments[envName].Inputs {
+			merged[k] = v
+		}
+		effective = append(effective, merged)
+	}
+
+	var errs []error
+	checked := make(map[string]bool)
+	for _, inputs := range effective {
+		// Requirements files are only installed by pip
+		manager, _ := inputs[string(config.InputFieldPackageManager)].(string)
+		if manager == "" {
+			manager = string(pythonConfig.DefaultManager)
+		}
+		if manager != string(config.PackageManagerPip) {
+			continue
+		}
+
+		path, _ := inputs[string(config.InputFieldRequirements)].(string)
+		if path == "" {
+			path = pythonConfig.DefaultReqFile
+		}
+		if checked[path] || strings.Contains(path, "{{") {
+			continue
+		}
+		checked[path] = true
+
+		if _, err := os.Stat(filepath.Join(baseDir, path)); os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("requirements file not found: %s", path))
+		}
+	}
+
+	return errs
+}
+
 // contains checks if a slice contains a string
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -112,6 +112,104 @@ spec:
 	assert.Equal(t, 2, staging.Inputs["replicas"])
 }
 
+func TestParseManifest_Permissions(t *testing.T) {
+	t.Run("shorthand", func(t *testing.T) {
+		manifest, err := ParseManifest([]byte(`
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+spec:
+  template: "go-service"
+  permissions: write-all
+`))
+		require.NoError(t, err)
+		require.NotNil(t, manifest.Spec.Permissions)
+		assert.Equal(t, PermissionsWriteAll, manifest.Spec.Permissions.Shorthand)
+		assert.Nil(t, manifest.Spec.Permissions.Scopes)
+	})
+
+	t.Run("scopes", func(t *testing.T) {
+		manifest, err := ParseManifest([]byte(`
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+spec:
+  template: "go-service"
+  permissions:
+    contents: read
+    packages: write
+`))
+		require.NoError(t, err)
+		require.NotNil(t, manifest.Spec.Permissions)
+		assert.Empty(t, manifest.Spec.Permissions.Shorthand)
+		assert.Equal(t, map[string]string{"contents": "read", "packages": "write"}, manifest.Spec.Permissions.Scopes)
+	})
+}
+
+func TestParseManifest_Defaults(t *testing.T) {
+	manifest, err := ParseManifest([]byte(`
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+spec:
+  template: "go-service"
+  defaults:
+    run:
+      shell: bash
+      working-directory: services/api
+`))
+	require.NoError(t, err)
+	require.NotNil(t, manifest.Spec.Defaults)
+	require.NotNil(t, manifest.Spec.Defaults.Run)
+	assert.Equal(t, "bash", manifest.Spec.Defaults.Run.Shell)
+	assert.Equal(t, "services/api", manifest.Spec.Defaults.Run.WorkingDirectory)
+}
+
+func TestParseManifest_KeepsFloatText(t *testing.T) {
+	manifest, err := ParseManifest([]byte(`
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+spec:
+  template: "go-service"
+  inputs:
+    goVersion: 1.20
+    coverage: 1.5
+  environments:
+    staging:
+      inputs:
+        goVersion: 1.10
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "1.20", manifest.Spec.Inputs["goVersion"])
+	assert.Equal(t, 1.5, manifest.Spec.Inputs["coverage"])
+	assert.Equal(t, "1.10", manifest.Spec.Environments["staging"].Inputs["goVersion"])
+}
+
+func TestParseManifest_StepDefaults(t *testing.T) {
+	manifest, err := ParseManifest([]byte(`
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+spec:
+  template: "go-service"
+  stepDefaults:
+    continueOnError: true
+    timeoutMinutes: 20
+    shell: bash
+    workingDirectory: services/api
+`))
+	require.NoError(t, err)
+	require.NotNil(t, manifest.Spec.StepDefaults)
+	require.NotNil(t, manifest.Spec.StepDefaults.ContinueOnError)
+	assert.True(t, *manifest.Spec.StepDefaults.ContinueOnError)
+	require.NotNil(t, manifest.Spec.StepDefaults.TimeoutMinutes)
+	assert.Equal(t, 20, *manifest.Spec.StepDefaults.TimeoutMinutes)
+	assert.Equal(t, "bash", manifest.Spec.StepDefaults.Shell)
+	assert.Equal(t, "services/api", manifest.Spec.StepDefaults.WorkingDirectory)
+
+	timeout := 0
+	manifest.Spec.StepDefaults.TimeoutMinutes = &timeout
+	err = ValidateManifest(manifest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stepDefaults.timeoutMinutes must be between 1 and 360")
+}
+
 func TestParseManifest_InvalidYAML(t *testing.T) {
 	invalidYAML := `
 apiVersion: gpgen.dev/v1
@@ -142,191 +240,737 @@ spec:
 			errorMsg: "apiVersion is required",
 		},
 		{
-			name: "missing kind",
-			yaml: `
-apiVersion: gpgen.dev/v1
-spec:
-  template: "go-service"
-`,
-			errorMsg: "kind is required",
+			name: "missing kind",
+			yaml: `
+apiVersion: gpgen.dev/v1
+spec:
+  template: "go-service"
+`,
+			errorMsg: "kind is required",
+		},
+		{
+			name: "missing spec",
+			yaml: `
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+`,
+			errorMsg: "spec is required",
+		},
+		{
+			name: "missing template",
+			yaml: `
+apiVersion: gpgen.dev/v1
+kind: Pipeline
+spec:
+  inputs:
+    goVersion: "1.24"
+`,
+			errorMsg: "template is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseManifest([]byte(tt.yaml))
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestValidateManifest_ValidManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest *Manifest
+	}{
+		{
+			name: "minimal valid manifest",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Inputs:   map[string]interface{}{"goVersion": "1.24"},
+				},
+			},
+		},
+		{
+			name: "job timeout at the range bounds",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template:       "go-service",
+					TimeoutMinutes: intPtr(360),
+					CustomSteps: []CustomStep{
+						{
+							Name:           "smoke",
+							Position:       "after:test",
+							Run:            "echo hello",
+							TimeoutMinutes: intPtr(1),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "manifest with custom steps",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "node-app",
+					Inputs:   map[string]interface{}{"nodeVersion": "20"},
+					CustomSteps: []CustomStep{
+						{
+							Name:     "test-step",
+							Position: "after:test",
+							Run:      "echo hello",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "manifest with additional jobs",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "node-app",
+					Jobs: map[string]JobSpec{
+						"test":  {TemplateSteps: []string{"test"}},
+						"build": {Needs: []string{"test"}},
+						"deploy": {
+							Needs:  []string{"build"},
+							RunsOn: "ubuntu-latest",
+							Steps:  []CustomStep{{Name: "Deploy", Run: "./deploy.sh"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "python template",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "python-app",
+					Inputs:   map[string]interface{}{"pythonVersion": "3.12"},
+				},
+			},
+		},
+		{
+			name: "safe environment names",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Environments: map[string]EnvironmentConfig{
+						"staging":    {},
+						"production": {},
+						"eu-west-1":  {},
+						"qa2":        {},
+					},
+				},
+			},
+		},
+		{
+			name: "matrix only input",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Inputs:   map[string]interface{}{"testCommand": "go test ./..."},
+					Matrix: map[string][]string{
+						"goVersion": {"1.22", "1.23"},
+					},
+				},
+			},
+		},
+		{
+			name: "nightly schedule",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Schedule: []string{"0 2 * * *", "30 4 * * 1-5"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateManifest(tt.manifest)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateManifest_InvalidManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		errorMsg string
+	}{
+		{
+			name: "invalid API version",
+			manifest: &Manifest{
+				APIVersion: "v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+				},
+			},
+			errorMsg: "invalid apiVersion",
+		},
+		{
+			name: "invalid kind",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Workflow",
+				Spec: ManifestSpec{
+					Template: "go-service",
+				},
+			},
+			errorMsg: "invalid kind",
+		},
+		{
+			name: "invalid template",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "invalid-template",
+				},
+			},
+			errorMsg: "invalid template",
+		},
+		{
+			name: "invalid env name",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Env: map[string]string{
+						"GO-VERSION": "1.22",
+					},
+				},
+			},
+			errorMsg: "invalid env name",
+		},
+		{
+			name: "matrix key without values",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Matrix: map[string][]string{
+						"goVersion": {},
+					},
+				},
+			},
+			errorMsg: "matrix key goVersion must have at least one value",
+		},
+		{
+			name: "matrix os with unknown runner label",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Matrix: map[string][]string{
+						"os": {"ubuntu-latest", "solaris-latest"},
+					},
+				},
+			},
+			errorMsg: "invalid matrix os: solaris-latest",
+		},
+		{
+			name: "matrix key also set as input",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Inputs:   map[string]interface{}{"goVersion": "1.22"},
+					Matrix: map[string][]string{
+						"goVersion": {"1.22", "1.23"},
+					},
+				},
+			},
+			errorMsg: "input goVersion is set both in inputs and in matrix",
+		},
+		{
+			name: "matrix key also set as environment input",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Matrix: map[string][]string{
+						"goVersion": {"1.22", "1.23"},
+					},
+					Environments: map[string]EnvironmentConfig{
+						"production": {
+							Inputs: map[string]interface{}{"goVersion": "1.23"},
+						},
+					},
+				},
+			},
+			errorMsg: "input goVersion in environment production is also set in matrix",
+		},
+		{
+			name: "invalid permissions shorthand",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template:    "go-service",
+					Permissions: &Permissions{Shorthand: "admin"},
+				},
+			},
+			errorMsg: "invalid permissions: admin",
+		},
+		{
+			name: "invalid permission level",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template:    "go-service",
+					Permissions: &Permissions{Scopes: map[string]string{"contents": "admin"}},
+				},
+			},
+			errorMsg: "invalid permission level for contents: admin",
+		},
+		{
+			name: "job timeout out of range",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template:       "go-service",
+					TimeoutMinutes: intPtr(500),
+				},
+			},
+			errorMsg: "timeoutMinutes must be between 1 and 360",
+		},
+		{
+			name: "invalid custom step onEnvironment",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					CustomSteps: []CustomStep{
+						{
+							Name:          "deploy",
+							Position:      "after:build",
+							Run:           "make deploy",
+							OnEnvironment: "qa",
+						},
+					},
+				},
+			},
+			errorMsg: "invalid onEnvironment: qa",
+		},
+		{
+			name: "deployment environment without name",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Environments: map[string]EnvironmentConfig{
+						"production": {Environment: &DeploymentEnvironment{URL: "https://example.com"}},
+					},
+				},
+			},
+			errorMsg: "environment production: deployment environment name cannot be empty",
+		},
+		{
+			name: "environment name with space",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Environments: map[string]EnvironmentConfig{
+						"pre prod": {},
+					},
+				},
+			},
+			errorMsg: `invalid environment name: "pre prod"`,
+		},
+		{
+			name: "environment name with slash",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Environments: map[string]EnvironmentConfig{
+						"team/staging": {},
+					},
+				},
+			},
+			errorMsg: `invalid environment name: "team/staging"`,
+		},
+		{
+			name: "environment name with uppercase",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Environments: map[string]EnvironmentConfig{
+						"Production": {},
+					},
+				},
+			},
+			errorMsg: `invalid environment name: "Production"`,
+		},
+		{
+			name: "malformed workflow name template",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template:             "go-service",
+					WorkflowNameTemplate: "{{ .Metadata.Name",
+				},
+			},
+			errorMsg: "invalid workflowNameTemplate",
+		},
+		{
+			name: "invalid position format",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					CustomSteps: []CustomStep{
+						{
+							Name:     "test-step",
+							Position: "invalid-position",
+							Run:      "echo hello",
+						},
+					},
+				},
+			},
+			errorMsg: "invalid position format",
+		},
+		{
+			name: "step with neither uses nor run",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					CustomSteps: []CustomStep{
+						{
+							Name:     "test-step",
+							Position: "after:test",
+						},
+					},
+				},
+			},
+			errorMsg: "step must have either 'uses' or 'run'",
+		},
+		{
+			name: "step with both uses and run",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					CustomSteps: []CustomStep{
+						{
+							Name:     "test-step",
+							Position: "after:test",
+							Uses:     "actions/checkout@v4",
+							Run:      "echo hello",
+						},
+					},
+				},
+			},
+			errorMsg: "step cannot have both 'uses' and 'run'",
+		},
+		{
+			name: "local action outside the repository",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					CustomSteps: []CustomStep{
+						{
+							Name:     "setup",
+							Position: "after:test",
+							Uses:     "./../shared/actions/setup",
+						},
+					},
+				},
+			},
+			errorMsg: "invalid local action",
+		},
+		{
+			name: "concurrency group with unclosed expression",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Concurrency: &ConcurrencyConfig{
+						Group: "pr-${{ github.event.pull_request.number",
+					},
+				},
+			},
+			errorMsg: "invalid concurrency group",
+		},
+		{
+			name: "concurrency without group",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Concurrency: &ConcurrencyConfig{
+						Group: " ",
+					},
+				},
+			},
+			errorMsg: "concurrency group cannot be empty",
+		},
+		{
+			name: "override timeout out of range",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Environments: map[string]EnvironmentConfig{
+						"production": {
+							Overrides: map[string]StepOverride{
+								"test": {TimeoutMinutes: intPtr(0)},
+							},
+						},
+					},
+				},
+			},
+			errorMsg: "invalid override for step test in environment production: timeout-minutes must be between 1 and 360",
+		},
+		{
+			name: "override with both uses and run",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Overrides: map[string]StepOverride{
+						"build": {Uses: "goreleaser/goreleaser-action@v6", Run: "make release"},
+					},
+				},
+			},
+			errorMsg: "invalid override for step build: override cannot have both 'uses' and 'run'",
+		},
+		{
+			name: "override with run and with",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Overrides: map[string]StepOverride{
+						"setup-go": {Run: "make tools", With: map[string]string{"cache": "false"}},
+					},
+				},
+			},
+			errorMsg: "invalid override for step setup-go: override cannot set 'with' when replacing the step with 'run'",
+		},
+		{
+			name: "unknown release trigger type",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Triggers: &TriggersConfig{
+						Release: &ReleaseTrigger{Types: []string{"prereleased", "shipped"}},
+					},
+				},
+			},
+			errorMsg: "invalid release trigger type: shipped",
 		},
 		{
-			name: "missing spec",
-			yaml: `
-apiVersion: gpgen.dev/v1
-kind: Pipeline
-`,
-			errorMsg: "spec is required",
+			name: "schedule cron with too few fields",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Schedule: []string{"0 2 * * *", "0 2 * *"},
+				},
+			},
+			errorMsg: "schedule[1]: invalid cron \"0 2 * *\": expected 5 fields",
 		},
 		{
-			name: "missing template",
-			yaml: `
-apiVersion: gpgen.dev/v1
-kind: Pipeline
-spec:
-  inputs:
-    goVersion: "1.24"
-`,
-			errorMsg: "template is required",
+			name: "schedule cron with seconds field",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Schedule: []string{"0 0 2 * * *"},
+				},
+			},
+			errorMsg: "got 6",
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			_, err := ParseManifest([]byte(tt.yaml))
-			assert.Error(t, err)
-			assert.Contains(t, err.Error(), tt.errorMsg)
-		})
-	}
-}
-
-func TestValidateManifest_ValidManifests(t *testing.T) {
-	tests := []struct {
-		name     string
-		manifest *Manifest
-	}{
 		{
-			name: "minimal valid manifest",
+			name: "trigger schedule cron with too few fields",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
 					Template: "go-service",
-					Inputs:   map[string]interface{}{"goVersion": "1.24"},
+					Triggers: &TriggersConfig{
+						Schedule: []ScheduleTrigger{{Cron: "@daily"}},
+					},
 				},
 			},
+			errorMsg: "triggers.schedule[0]: invalid cron",
 		},
 		{
-			name: "manifest with custom steps",
+			name: "job with invalid id",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
 					Template: "node-app",
-					Inputs:   map[string]interface{}{"nodeVersion": "20"},
-					CustomSteps: []CustomStep{
-						{
-							Name:     "test-step",
-							Position: "after:test",
-							Run:      "echo hello",
-						},
+					Jobs: map[string]JobSpec{
+						"deploy app": {Steps: []CustomStep{{Name: "Deploy", Run: "./deploy.sh"}}},
 					},
 				},
 			},
+			errorMsg: "invalid job id: deploy app",
 		},
 		{
-			name: "python template",
+			name: "job needing unknown job",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
-					Template: "python-app",
-					Inputs:   map[string]interface{}{"pythonVersion": "3.12"},
+					Template: "node-app",
+					Jobs: map[string]JobSpec{
+						"deploy": {Needs: []string{"release"}, Steps: []CustomStep{{Name: "Deploy", Run: "./deploy.sh"}}},
+					},
 				},
 			},
+			errorMsg: "job deploy: needs unknown job release",
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateManifest(tt.manifest)
-			assert.NoError(t, err)
-		})
-	}
-}
-
-func TestValidateManifest_InvalidManifests(t *testing.T) {
-	tests := []struct {
-		name     string
-		manifest *Manifest
-		errorMsg string
-	}{
 		{
-			name: "invalid API version",
+			name: "jobs with a needs cycle",
 			manifest: &Manifest{
-				APIVersion: "v1",
+				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
-					Template: "go-service",
+					Template: "node-app",
+					Jobs: map[string]JobSpec{
+						"test":   {Needs: []string{"deploy"}, TemplateSteps: []string{"test"}},
+						"deploy": {Needs: []string{"test"}, Steps: []CustomStep{{Name: "Deploy", Run: "./deploy.sh"}}},
+					},
 				},
 			},
-			errorMsg: "invalid apiVersion",
+			errorMsg: "jobs have a needs cycle: deploy -> test -> deploy",
 		},
 		{
-			name: "invalid kind",
+			name: "job without steps",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
-				Kind:       "Workflow",
+				Kind:       "Pipeline",
 				Spec: ManifestSpec{
-					Template: "go-service",
+					Template: "node-app",
+					Jobs: map[string]JobSpec{
+						"deploy": {Needs: []string{"build"}},
+					},
 				},
 			},
-			errorMsg: "invalid kind",
+			errorMsg: "job deploy: must have templateSteps or steps",
 		},
 		{
-			name: "invalid template",
+			name: "build job with steps",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
-					Template: "invalid-template",
+					Template: "node-app",
+					Jobs: map[string]JobSpec{
+						"build": {TemplateSteps: []string{"test"}},
+					},
 				},
 			},
-			errorMsg: "invalid template",
+			errorMsg: "job build: only needs and env can be set for the build job",
 		},
 		{
-			name: "invalid position format",
+			name: "invalid job env name",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
 					Template: "go-service",
-					CustomSteps: []CustomStep{
-						{
-							Name:     "test-step",
-							Position: "invalid-position",
-							Run:      "echo hello",
-						},
-					},
+					JobEnv:   map[string]string{"CGO-ENABLED": "0"},
 				},
 			},
-			errorMsg: "invalid position format",
+			errorMsg: "jobEnv: invalid env name: CGO-ENABLED",
 		},
 		{
-			name: "step with neither uses nor run",
+			name: "template step moved twice",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
-					Template: "go-service",
-					CustomSteps: []CustomStep{
-						{
-							Name:     "test-step",
-							Position: "after:test",
-						},
+					Template: "node-app",
+					Jobs: map[string]JobSpec{
+						"lint": {TemplateSteps: []string{"test"}},
+						"test": {TemplateSteps: []string{"test"}},
 					},
 				},
 			},
-			errorMsg: "step must have either 'uses' or 'run'",
+			errorMsg: "job test: template step test is already moved to job lint",
 		},
 		{
-			name: "step with both uses and run",
+			name: "job step with both uses and run",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
 				Spec: ManifestSpec{
-					Template: "go-service",
-					CustomSteps: []CustomStep{
-						{
-							Name:     "test-step",
-							Position: "after:test",
-							Uses:     "actions/checkout@v4",
-							Run:      "echo hello",
-						},
+					Template: "node-app",
+					Jobs: map[string]JobSpec{
+						"deploy": {Steps: []CustomStep{{Name: "Deploy", Uses: "actions/deploy@v1", Run: "./deploy.sh"}}},
 					},
 				},
 			},
-			errorMsg: "step cannot have both 'uses' and 'run'",
+			errorMsg: "job deploy: invalid step at index 0: step cannot have both 'uses' and 'run'",
+		},
+		{
+			name: "unknown pinned action",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template:       "go-service",
+					ActionVersions: map[string]string{"actions/checkout@v4": "v5"},
+				},
+			},
+			errorMsg: `unknown action "actions/checkout@v4" in actionVersions`,
 		},
 	}
 
@@ -353,8 +997,10 @@ func TestValidatePosition(t *testing.T) {
 		{"after:", false},
 		{":test", false},
 		{"during:test", false},
-		{"after:Test", false},      // uppercase not allowed
-		{"after:test_case", false}, // underscore not allowed
+		{"after:Test", true},     // step IDs may be uppercase
+		{"after:run_e2e", true},  // and contain underscores
+		{"after:run e2e", false}, // but not spaces
+		{"after:run.e2e", false},
 	}
 
 	for _, tt := range tests {
@@ -369,6 +1015,63 @@ func TestValidatePosition(t *testing.T) {
 	}
 }
 
+func TestValidateLocalAction(t *testing.T) {
+	tests := []struct {
+		uses  string
+		valid bool
+	}{
+		{"./.github/actions/setup", true},
+		{"./actions/build-cache", true},
+		{"./", false},
+		{"./.github/actions/setup/", false},
+		{"./.github/actions/setup@v1", false},
+		{"./.github/../../setup", false},
+		{"./.github//setup", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uses, func(t *testing.T) {
+			assert.True(t, IsLocalAction(tt.uses))
+			err := validateLocalAction(tt.uses)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	assert.False(t, IsLocalAction("actions/checkout@v4"))
+}
+
+func TestValidateExpressionBraces(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"deploy", true},
+		{"${{ github.workflow }}-${{ github.ref }}", true},
+		{"pr-${{ github.event.pull_request.number || github.ref }}", true},
+		{"${{ format('{0}-{1}', github.workflow, github.ref) }}", true},
+		{"deploy-${{ github.ref", false},
+		{"deploy-${{ github.ref }} }}", false},
+		{"deploy-github.ref }}", false},
+		{"${{ ${{ github.ref }} }}", false},
+		{"deploy-${{ }}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			err := validateExpressionBraces(tt.value)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
 func TestGetValidationMode(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -421,6 +1124,76 @@ func TestGetValidationMode(t *testing.T) {
 	}
 }
 
+func TestCheckRequirementsFiles(t *testing.T) {
+	newManifest := func(inputs map[string]interface{}) *Manifest {
+		return &Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Spec: ManifestSpec{
+				Template: "python-app",
+				Inputs:   inputs,
+			},
+		}
+	}
+
+	t.Run("missing requirements file", func(t *testing.T) {
+		errs := CheckRequirementsFiles(newManifest(map[string]interface{}{
+			"requirements": "requirements/prod.txt",
+		}), t.TempDir())
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), "requirements file not found: requirements/prod.txt")
+	})
+
+	t.Run("missing default requirements file", func(t *testing.T) {
+		errs := CheckRequirementsFiles(newManifest(nil), t.TempDir())
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), "requirements.txt")
+	})
+
+	t.Run("present requirements file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("pytest\n"), 0644))
+
+		errs := CheckRequirementsFiles(newManifest(map[string]interface{}{
+			"requirements": "requirements.txt",
+		}), dir)
+		assert.Empty(t, errs)
+	})
+
+	t.Run("environment override is checked", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "requirements.txt"), []byte("pytest\n"), 0644))
+
+		m := newManifest(nil)
+		m.Spec.Environments = map[string]EnvironmentConfig{
+			"production": {Inputs: map[string]interface{}{"requirements": "requirements-prod.txt"}},
+		}
+		errs := CheckRequirementsFiles(m, dir)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), "requirements-prod.txt")
+	})
+
+	t.Run("templated path is skipped", func(t *testing.T) {
+		errs := CheckRequirementsFiles(newManifest(map[string]interface{}{
+			"requirements": "{{ .Inputs.reqFile }}",
+		}), t.TempDir())
+		assert.Empty(t, errs)
+	})
+
+	t.Run("non-pip package manager is skipped", func(t *testing.T) {
+		errs := CheckRequirementsFiles(newManifest(map[string]interface{}{
+			"packageManager": "poetry",
+		}), t.TempDir())
+		assert.Empty(t, errs)
+	})
+
+	t.Run("other templates are skipped", func(t *testing.T) {
+		m := newManifest(nil)
+		m.Spec.Template = "go-service"
+		assert.Empty(t, CheckRequirementsFiles(m, t.TempDir()))
+	})
+}
+
 func TestLoadManifestFromFile_Success(t *testing.T) {
 	// Create a temporary manifest file
 	content := `
@@ -476,6 +1249,18 @@ func TestValidateManifest_EdgeCases(t *testing.T) {
 			},
 			errorMsg: "timeout-minutes must be between 1 and 360",
 		},
+		{
+			name: "job timeout too low",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template:       "go-service",
+					TimeoutMinutes: intPtr(0),
+				},
+			},
+			errorMsg: "timeoutMinutes must be between 1 and 360",
+		},
 		{
 			name: "timeout too high",
 			manifest: &Manifest{
@@ -496,7 +1281,7 @@ func TestValidateManifest_EdgeCases(t *testing.T) {
 			errorMsg: "timeout-minutes must be between 1 and 360",
 		},
 		{
-			name: "empty step name",
+			name: "invalid step id",
 			manifest: &Manifest{
 				APIVersion: "gpgen.dev/v1",
 				Kind:       "Pipeline",
@@ -504,14 +1289,28 @@ func TestValidateManifest_EdgeCases(t *testing.T) {
 					Template: "go-service",
 					CustomSteps: []CustomStep{
 						{
-							Name:     "",
+							ID:       "run e2e",
 							Position: "after:test",
 							Run:      "echo hello",
 						},
 					},
 				},
 			},
-			errorMsg: "step name cannot be empty",
+			errorMsg: "invalid step id: run e2e",
+		},
+		{
+			name: "job step without name, id or position",
+			manifest: &Manifest{
+				APIVersion: "gpgen.dev/v1",
+				Kind:       "Pipeline",
+				Spec: ManifestSpec{
+					Template: "go-service",
+					Jobs: map[string]JobSpec{
+						"deploy": {Steps: []CustomStep{{Run: "./deploy.sh"}}},
+					},
+				},
+			},
+			errorMsg: "job deploy: invalid step at index 0: step must have a name, id or position",
 		},
 	}
 
@@ -581,3 +1380,124 @@ spec:
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestCustomStep_EffectiveName(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     CustomStep
+		expected string
+	}{
+		{name: "explicit name is kept", step: CustomStep{ID: "e2e", Name: "End-to-end tests", Position: "after:test"}, expected: "End-to-end tests"},
+		{name: "derived from id", step: CustomStep{ID: "run-e2e_suite", Position: "after:test"}, expected: "Run E2e Suite"},
+		{name: "derived from position", step: CustomStep{Position: "before:setup-node"}, expected: "Before Setup Node"},
+		{name: "nothing to derive from", step: CustomStep{Run: "make"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.step.EffectiveName())
+		})
+	}
+}
+
+func TestNeedsStrictMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		expected bool
+	}{
+		{"single line", "make test", false},
+		{"multi-line without strict mode", "make deps\nmake test\n", true},
+		{"strict mode first", "set -euo pipefail\nmake deps\nmake test", false},
+		{"separate flags", "set -e -u -o pipefail\nmake test\n", false},
+		{"long options", "set -o errexit -o nounset -o pipefail\nmake test", false},
+		{"after shebang and blank lines", "#!/usr/bin/env bash\n\nset -euxo pipefail\nmake test", false},
+		{"missing nounset", "set -eo pipefail\nmake test", true},
+		{"strict mode after a command", "make deps\nset -euo pipefail\nmake test", true},
+		{"options on separate lines", "set -e\nset -u\nset -o pipefail\nmake test", false},
+		{"pipefail on its own line", "set -eu\nset -o pipefail\nmake test", false},
+		{"errexit disabled again", "set -euo pipefail\nset +e\nmake test", true},
+		{"only set lines", "set -e\nset -u", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NeedsStrictMode(tt.script))
+		})
+	}
+}
+
+func TestCheckScriptStrictMode(t *testing.T) {
+	newManifest := func() *Manifest {
+		return &Manifest{
+			APIVersion: "gpgen.dev/v1",
+			Kind:       "Pipeline",
+			Spec: ManifestSpec{
+				Template: "node-app",
+				CustomSteps: []CustomStep{
+					{Name: "Seed database", Position: "before:test", Run: "./scripts/seed.sh\n./scripts/migrate.sh"},
+					{Name: "Lint", Position: "before:test", Run: "npm run lint"},
+					{Name: "Strict", Position: "after:test", Run: "set -euo pipefail\nnpm run e2e\nnpm run report"},
+				},
+				Environments: map[string]EnvironmentConfig{
+					"production": {
+						Overrides: map[string]StepOverride{
+							"test": {Run: "npm ci\nnpm test"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("multi-line bash scripts without strict mode warn", func(t *testing.T) {
+		warnings := CheckScriptStrictMode(newManifest(), false)
+		require.Len(t, warnings, 2)
+		assert.Contains(t, warnings[0], `custom step "Seed database" runs a multi-line script without "set -euo pipefail"`)
+		assert.Contains(t, warnings[0], "--harden-scripts")
+		assert.Contains(t, warnings[1], "environment production: override for step test")
+		assert.NotContains(t, warnings[1], "--harden-scripts", "hardening leaves overrides alone")
+	})
+
+	t.Run("hardened custom steps are skipped", func(t *testing.T) {
+		warnings := CheckScriptStrictMode(newManifest(), true)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "environment production: override for step test")
+	})
+
+	t.Run("job steps are checked", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Jobs = map[string]JobSpec{
+			"deploy": {Steps: []CustomStep{{Name: "Deploy", Run: "./build.sh\n./deploy.sh"}}},
+		}
+		warnings := CheckScriptStrictMode(m, false)
+		require.Len(t, warnings, 3)
+		assert.Contains(t, warnings[2], `job deploy: custom step "Deploy"`)
+	})
+
+	t.Run("non-bash shells are skipped", func(t *testing.T) {
+		m := newManifest()
+		m.Spec.Defaults = &JobDefaults{Run: &RunDefaults{Shell: "pwsh"}}
+		assert.Empty(t, CheckScriptStrictMode(m, false))
+	})
+}
+
+func TestCheckDeploymentEnvironments(t *testing.T) {
+	manifest := &Manifest{
+		APIVersion: "gpgen.dev/v1",
+		Kind:       "Pipeline",
+		Spec: ManifestSpec{
+			Template: "go-service",
+			Environments: map[string]EnvironmentConfig{
+				"staging":    {},
+				"production": {},
+				"prod-eu":    {Environment: &DeploymentEnvironment{Name: "prod-eu"}},
+			},
+		},
+	}
+
+	warnings := CheckDeploymentEnvironments(manifest)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "environment production has no deployment environment")
+	assert.Contains(t, warnings[0], "environments.production.environment.name")
+}
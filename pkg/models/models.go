@@ -9,6 +9,14 @@ type Template struct {
 	Tags        []string         `yaml:"tags"`
 	Inputs      map[string]Input `yaml:"inputs"`
 	Steps       []Step           `yaml:"steps"`
+
+	// Permissions the template always needs, merged with feature-derived job permissions
+	Permissions map[string]string `yaml:"permissions,omitempty"`
+
+	// DefaultEnvironments are the input overrides of the environments init scaffolds for the
+	// template, keyed by environment. Generation falls back to them for environments the
+	// manifest doesn't configure, for the inputs the manifest doesn't set.
+	DefaultEnvironments map[string]map[string]interface{} `yaml:"defaultEnvironments,omitempty"`
 }
 
 // Input defines a parameter for a template with stronger typing
@@ -19,6 +27,9 @@ type Input struct {
 	Required    bool        `yaml:"required"`
 	Options     []string    `yaml:"options,omitempty"`
 	Pattern     string      `yaml:"pattern,omitempty"`
+
+	// ValueType is the type of each value of an object input, when they share one
+	ValueType InputType `yaml:"valueType,omitempty"`
 }
 
 // InputType represents the type of an input parameter
@@ -30,6 +41,10 @@ const (
 	InputTypeBoolean InputType = "boolean"
 	InputTypeArray   InputType = "array"
 	InputTypeObject  InputType = "object"
+
+	// InputTypeDuration accepts Go-style durations ("15m", "1h30m") or plain minutes,
+	// normalized to whole minutes
+	InputTypeDuration InputType = "duration"
 )
 
 // Step represents a GitHub Actions workflow step
@@ -43,11 +58,30 @@ type Step struct {
 	If          string            `yaml:"if,omitempty"`
 	TimeoutMins int               `yaml:"timeout-minutes,omitempty"`
 	Position    string            `yaml:"position,omitempty"`
+
+	// Action is the logical name of the action Uses refers to (e.g. "checkout"), the key
+	// actionVersions pins it by
+	Action string `yaml:"action,omitempty"`
+
+	// TimeoutInput names a duration input that, when set, replaces TimeoutMins
+	TimeoutInput string `yaml:"timeoutInput,omitempty"`
+
+	// ContinueOnError is rendered like If; "true" marks the step as advisory so its
+	// failure doesn't fail the job
+	ContinueOnError string `yaml:"continueOnError,omitempty"`
 }
 
+// FetchDepthAuto is the special fetchDepth value that fetches full history only for tag refs
+const FetchDepthAuto = "auto"
+
+// FetchDepthReleaseExpression is the checkout depth expression used for FetchDepthAuto
+const FetchDepthReleaseExpression = "${{ (startsWith(github.ref, 'refs/tags/') && '0') || '1' }}"
+
 // SecurityConfig represents security scanning configuration
 type SecurityConfig struct {
-	Trivy TrivyConfig `yaml:"trivy" json:"trivy"`
+	Trivy  TrivyConfig  `yaml:"trivy" json:"trivy"`
+	Gosec  GosecConfig  `yaml:"gosec" json:"gosec"`
+	Bandit BanditConfig `yaml:"bandit" json:"bandit"`
 }
 
 // TrivyConfig represents Trivy vulnerability scanner configuration
@@ -55,19 +89,73 @@ type TrivyConfig struct {
 	Enabled  bool   `yaml:"enabled" json:"enabled"`
 	Severity string `yaml:"severity" json:"severity"`
 	ExitCode string `yaml:"exitCode" json:"exitCode"`
+
+	// Format is the report format; only sarif results are uploaded to the Security tab
+	Format string `yaml:"format" json:"format"`
+
+	// Scans replaces the single filesystem scan with one scan (and SARIF upload) per entry
+	Scans []TrivyScan `yaml:"scans,omitempty" json:"scans,omitempty"`
+}
+
+// Trivy report formats supported in TrivyConfig.Format
+const (
+	TrivyFormatSARIF = "sarif"
+	TrivyFormatTable = "table"
+	TrivyFormatJSON  = "json"
+)
+
+// TrivyFormats lists the supported Trivy report formats
+var TrivyFormats = []string{TrivyFormatSARIF, TrivyFormatTable, TrivyFormatJSON}
+
+// TrivyOutputExtension returns the file extension for a Trivy report format
+func TrivyOutputExtension(format string) string {
+	if format == TrivyFormatTable {
+		return "txt"
+	}
+	return format
+}
+
+// Trivy scan types supported in TrivyConfig.Scans
+const (
+	TrivyScanFilesystem = "fs"
+	TrivyScanImage      = "image"
+	TrivyScanRepository = "repo"
+	TrivyScanConfig     = "config"
+)
+
+// TrivyScan represents one Trivy scan; Ref and Output default per scan type
+type TrivyScan struct {
+	ScanType string `yaml:"scanType" json:"scanType"`
+	Ref      string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	Output   string `yaml:"output,omitempty" json:"output,omitempty"`
+}
+
+// GosecConfig represents gosec static analysis configuration (Go only)
+type GosecConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// BanditConfig represents bandit static analysis configuration (Python only)
+type BanditConfig struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	Args       string `yaml:"args,omitempty" json:"args,omitempty"`
+	ConfigFile string `yaml:"configFile,omitempty" json:"configFile,omitempty"`
 }
 
 // ContainerConfig represents container building and registry configuration
 type ContainerConfig struct {
-	Enabled      bool        `yaml:"enabled" json:"enabled"`
-	Registry     string      `yaml:"registry" json:"registry"`
-	ImageName    string      `yaml:"imageName" json:"imageName"`
-	ImageTag     string      `yaml:"imageTag" json:"imageTag"`
-	Dockerfile   string      `yaml:"dockerfile" json:"dockerfile"`
-	BuildContext string      `yaml:"buildContext" json:"buildContext"`
-	BuildArgs    string      `yaml:"buildArgs" json:"buildArgs"`
-	Push         PushConfig  `yaml:"push" json:"push"`
-	Build        BuildConfig `yaml:"build" json:"build"`
+	Enabled          bool        `yaml:"enabled" json:"enabled"`
+	Registry         string      `yaml:"registry" json:"registry"`
+	ImageName        string      `yaml:"imageName" json:"imageName"`
+	ImageTag         string      `yaml:"imageTag" json:"imageTag"`
+	Dockerfile       string      `yaml:"dockerfile" json:"dockerfile"`
+	BuildContext     string      `yaml:"buildContext" json:"buildContext"`
+	BuildArgs        string      `yaml:"buildArgs" json:"buildArgs"`
+	Target           string      `yaml:"target" json:"target"`
+	Platforms        string      `yaml:"platforms" json:"platforms"`
+	InsecureRegistry bool        `yaml:"insecureRegistry" json:"insecureRegistry"`
+	Push             PushConfig  `yaml:"push" json:"push"`
+	Build            BuildConfig `yaml:"build" json:"build"`
 }
 
 // PushConfig represents container push configuration
@@ -84,6 +172,32 @@ type BuildConfig struct {
 	OnProduction bool `yaml:"onProduction" json:"onProduction"`
 }
 
+// ArtifactsConfig represents build outputs uploaded as a workflow artifact after the build
+type ArtifactsConfig struct {
+	// Enabled is set by the input processor when any paths are configured
+	Enabled       bool     `yaml:"enabled" json:"enabled"`
+	Name          string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Paths         []string `yaml:"paths,omitempty" json:"paths,omitempty"`
+	RetentionDays int      `yaml:"retentionDays,omitempty" json:"retentionDays,omitempty"`
+}
+
+// MaxArtifactRetentionDays is the longest artifact retention GitHub allows
+const MaxArtifactRetentionDays = 90
+
+// NotificationsConfig represents the notifications sent when a workflow run fails
+type NotificationsConfig struct {
+	Slack SlackNotificationConfig `yaml:"slack" json:"slack"`
+}
+
+// SlackNotificationConfig represents a Slack message posted through an incoming webhook
+type SlackNotificationConfig struct {
+	// Enabled is set by the input processor when a webhook secret is configured
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// WebhookSecret is the name of the repository secret holding the webhook URL
+	WebhookSecret string `yaml:"webhookSecret,omitempty" json:"webhookSecret,omitempty"`
+	Channel       string `yaml:"channel,omitempty" json:"channel,omitempty"`
+}
+
 // WorkflowInputs represents all possible workflow inputs with strong typing
 type WorkflowInputs struct {
 	// Language/Runtime inputs
@@ -101,12 +215,26 @@ type WorkflowInputs struct {
 	LintCommand  string `json:"lintCommand,omitempty"`
 
 	// Configurations
-	Security  SecurityConfig  `json:"security,omitempty"`
-	Container ContainerConfig `json:"container,omitempty"`
+	Security      SecurityConfig      `json:"security,omitempty"`
+	Container     ContainerConfig     `json:"container,omitempty"`
+	Artifacts     ArtifactsConfig     `json:"artifacts"`
+	Notifications NotificationsConfig `json:"notifications"`
 
 	// Build platforms (Go specific)
 	Platforms string `json:"platforms,omitempty"`
 
+	// Build per-platform binaries in a matrix over Platforms (Go specific)
+	CrossCompile bool `json:"crossCompile"`
+
+	// Fail the job when linting fails instead of treating lint as advisory
+	FailOnLint bool `json:"failOnLint"`
+
+	// Per-step timeout overrides in minutes, keyed by template step ID
+	Timeouts map[string]int `json:"timeouts,omitempty"`
+
+	// Checkout depth (number of commits, an expression, or "auto")
+	FetchDepth string `json:"fetchDepth,omitempty"`
+
 	// Legacy compatibility fields (deprecated)
 	TrivyScanEnabled   *bool  `json:"trivyScanEnabled,omitempty"`
 	TrivySeverity      string `json:"trivySeverity,omitempty"`
@@ -123,6 +251,7 @@ func DefaultSecurityConfig() SecurityConfig {
 			Enabled:  true,
 			Severity: "CRITICAL,HIGH",
 			ExitCode: "1",
+			Format:   TrivyFormatSARIF,
 		},
 	}
 }
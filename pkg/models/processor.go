@@ -34,6 +34,12 @@ func (p *InputProcessor) hasInput(keys ...string) bool {
 	return true
 }
 
+// HasInput reports whether a nested input field was explicitly provided in the inputs
+// last passed to ProcessInputs (values filled in from defaults don't count)
+func (p *InputProcessor) HasInput(keys ...string) bool {
+	return p.hasInput(keys...)
+}
+
 // NewInputProcessor creates a new input processor
 func NewInputProcessor() *InputProcessor {
 	return &InputProcessor{}
@@ -73,6 +79,15 @@ func (p *InputProcessor) normalizeInputs(inputs *WorkflowInputs) {
 	// Normalize container configuration
 	p.normalizeContainerConfig(inputs)
 
+	// Normalize checkout configuration
+	p.normalizeCheckoutConfig(inputs)
+
+	// Artifacts are uploaded only when there is something to upload
+	inputs.Artifacts.Enabled = len(inputs.Artifacts.Paths) > 0
+
+	// Failure notifications need a webhook to post to
+	inputs.Notifications.Slack.Enabled = inputs.Notifications.Slack.WebhookSecret != ""
+
 	// Apply default values where needed
 	p.applyDefaults(inputs)
 }
@@ -96,6 +111,10 @@ func (p *InputProcessor) normalizeSecurityConfig(inputs *WorkflowInputs) {
 	if inputs.Security.Trivy.ExitCode == "" {
 		inputs.Security.Trivy.ExitCode = "1"
 	}
+
+	if inputs.Security.Trivy.Format == "" {
+		inputs.Security.Trivy.Format = TrivyFormatSARIF
+	}
 }
 
 // normalizeContainerConfig handles container configuration normalization
@@ -143,6 +162,14 @@ func (p *InputProcessor) normalizeContainerConfig(inputs *WorkflowInputs) {
 	}
 }
 
+// normalizeCheckoutConfig resolves special checkout values into GitHub Actions expressions
+func (p *InputProcessor) normalizeCheckoutConfig(inputs *WorkflowInputs) {
+	// "auto" fetches full history only when building a tag (e.g. for release tooling)
+	if inputs.FetchDepth == FetchDepthAuto {
+		inputs.FetchDepth = FetchDepthReleaseExpression
+	}
+}
+
 // applyDefaults applies default values for any unset fields
 func (p *InputProcessor) applyDefaults(inputs *WorkflowInputs) {
 	// Set default security config if empty
@@ -198,10 +225,10 @@ func (p *InputProcessor) ToMap(inputs *WorkflowInputs) map[string]interface{} {
 		knownFields := map[string]bool{
 			"nodeVersion": true, "goVersion": true, "pythonVersion": true,
 			"packageManager": true, "testCommand": true, "buildCommand": true,
-			"lintCommand": true, "requirements": true, "platforms": true,
+			"lintCommand": true, "requirements": true, "platforms": true, "crossCompile": true, "failOnLint": true, "fetchDepth": true, "timeouts": true,
 			"containerEnabled": true, "containerRegistry": true, "containerImageName": true,
 			"containerImageTag": true, "trivyScanEnabled": true, "trivySeverity": true,
-			"security": true, "container": true,
+			"security": true, "container": true, "artifacts": true, "notifications": true,
 		}
 
 		for k, v := range p.originalInputs {
@@ -235,6 +262,8 @@ func (inputs *WorkflowInputs) GetString(field string) string {
 		return inputs.Requirements
 	case "platforms":
 		return inputs.Platforms
+	case "fetchDepth":
+		return inputs.FetchDepth
 	default:
 		return ""
 	}
@@ -245,6 +274,12 @@ func (inputs *WorkflowInputs) GetBool(field string) bool {
 	switch field {
 	case "security.trivy.enabled":
 		return inputs.Security.Trivy.Enabled
+	case "security.gosec.enabled":
+		return inputs.Security.Gosec.Enabled
+	case "crossCompile":
+		return inputs.CrossCompile
+	case "failOnLint":
+		return inputs.FailOnLint
 	case "container.enabled":
 		return inputs.Container.Enabled
 	case "container.push.enabled":
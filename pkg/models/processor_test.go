@@ -63,3 +63,26 @@ func TestApplyDefaults_DisablePush(t *testing.T) {
 	assert.False(t, inputs.Container.Push.Enabled)
 	assert.Equal(t, def.Push.OnProduction, inputs.Container.Push.OnProduction)
 }
+
+func TestNormalizeCheckoutConfig_FetchDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "auto resolves to release expression", value: FetchDepthAuto, expected: FetchDepthReleaseExpression},
+		{name: "numeric depth is kept", value: "0", expected: "0"},
+		{name: "custom expression is kept", value: "${{ inputs.depth }}", expected: "${{ inputs.depth }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewInputProcessor()
+			inputs, err := p.ProcessInputs(map[string]interface{}{"fetchDepth": tt.value})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, inputs.FetchDepth)
+			assert.Equal(t, tt.expected, p.ToMap(inputs)["fetchDepth"])
+		})
+	}
+}
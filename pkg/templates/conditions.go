@@ -2,7 +2,10 @@ package templates
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/terrpan/gpgen/pkg/config"
 )
 
 // GitHubEventConditions contains type-safe constants for GitHub event conditions
@@ -29,34 +32,132 @@ const (
 
 // GitHubActionVersions contains centralized action version constants
 var GitHubActionVersions = struct {
-	Checkout         string
-	SetupNode        string
-	SetupGo          string
-	SetupPython      string
+	Checkout          string
+	SetupNode         string
+	SetupGo           string
+	SetupPython       string
+	SetupJava         string
+	RustToolchain     string
+	DockerSetupQEMU   string
 	DockerSetupBuildx string
-	DockerLogin      string
-	DockerBuildPush  string
+	DockerLogin       string
+	DockerBuildPush   string
 	CodeQLUploadSARIF string
-	TrivyAction      string
+	TrivyAction       string
+	Gosec             string
+	UploadArtifact    string
+	SlackGitHubAction string
 }{
-	Checkout:         "actions/checkout@v4",
-	SetupNode:        "actions/setup-node@v4",
-	SetupGo:          "actions/setup-go@v4",
-	SetupPython:      "actions/setup-python@v4",
+	Checkout:          "actions/checkout@v4",
+	SetupNode:         "actions/setup-node@v4",
+	SetupGo:           "actions/setup-go@v4",
+	SetupPython:       "actions/setup-python@v4",
+	SetupJava:         "actions/setup-java@v4",
+	RustToolchain:     "dtolnay/rust-toolchain@master",
+	DockerSetupQEMU:   "docker/setup-qemu-action@v3",
 	DockerSetupBuildx: "docker/setup-buildx-action@v3",
-	DockerLogin:      "docker/login-action@v3",
-	DockerBuildPush:  "docker/build-push-action@v5",
+	DockerLogin:       "docker/login-action@v3",
+	DockerBuildPush:   "docker/build-push-action@v5",
 	CodeQLUploadSARIF: "github/codeql-action/upload-sarif@v3",
-	TrivyAction:      "aquasecurity/trivy-action@master",
+	TrivyAction:       "aquasecurity/trivy-action@master",
+	Gosec:             "securego/gosec@master",
+	UploadArtifact:    "actions/upload-artifact@v4",
+	SlackGitHubAction: "slackapi/slack-github-action@v2",
+}
+
+// actionVersionFields maps action names used in config files to the GitHubActionVersions fields
+func actionVersionFields() map[string]*string {
+	return map[string]*string{
+		"checkout":          &GitHubActionVersions.Checkout,
+		"setupNode":         &GitHubActionVersions.SetupNode,
+		"setupGo":           &GitHubActionVersions.SetupGo,
+		"setupPython":       &GitHubActionVersions.SetupPython,
+		"setupJava":         &GitHubActionVersions.SetupJava,
+		"rustToolchain":     &GitHubActionVersions.RustToolchain,
+		"dockerSetupQemu":   &GitHubActionVersions.DockerSetupQEMU,
+		"dockerSetupBuildx": &GitHubActionVersions.DockerSetupBuildx,
+		"dockerLogin":       &GitHubActionVersions.DockerLogin,
+		"dockerBuildPush":   &GitHubActionVersions.DockerBuildPush,
+		"codeqlUploadSarif": &GitHubActionVersions.CodeQLUploadSARIF,
+		"trivyAction":       &GitHubActionVersions.TrivyAction,
+		"gosec":             &GitHubActionVersions.Gosec,
+		"uploadArtifact":    &GitHubActionVersions.UploadArtifact,
+		"slackGithubAction": &GitHubActionVersions.SlackGitHubAction,
+	}
+}
+
+// SetActionVersions overrides the built-in action versions used by templates. Values are
+// either a full reference ("actions/checkout@v3") or just a version ("v3" or "@v3").
+// Templates loaded afterwards use the overridden versions.
+func SetActionVersions(overrides map[string]string) error {
+	fields := actionVersionFields()
+
+	// Validate everything before applying so a bad entry leaves the defaults untouched
+	resolved, err := resolveActionVersions(fields, overrides)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range resolved {
+		*fields[name] = value
+	}
+	return nil
+}
+
+// ResolveActionVersions expands each override to a full action reference keyed by action
+// name, accepting the same names and values as SetActionVersions, e.g. {"checkout": "v5"}
+// yields {"checkout": "actions/checkout@v5"}. It leaves the built-in versions untouched.
+func ResolveActionVersions(overrides map[string]string) (map[string]string, error) {
+	return resolveActionVersions(actionVersionFields(), overrides)
+}
+
+// resolveActionVersions expands each override to a full action reference keyed by name.
+// Overrides may use the names' aliases (see config.ActionAliases).
+func resolveActionVersions(fields map[string]*string, overrides map[string]string) (map[string]string, error) {
+	overrides, err := config.ResolveActionNames(overrides)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]string, len(overrides))
+	for _, name := range names {
+		field := fields[name]
+		value := strings.TrimSpace(overrides[name])
+		if value == "" || value == "@" {
+			return nil, fmt.Errorf("action version for %q cannot be empty", name)
+		}
+		if !strings.Contains(value, "/") {
+			repo, _, _ := strings.Cut(*field, "@")
+			value = repo + "@" + strings.TrimPrefix(value, "@")
+		}
+		resolved[name] = value
+	}
+	return resolved, nil
 }
 
 // GitHubPlaceholders contains centralized placeholder constants
 var GitHubPlaceholders = struct {
-	ActorPlaceholder string
-	TokenPlaceholder string
+	ActorPlaceholder        string
+	TokenPlaceholder        string
+	MatrixGOOSPlaceholder   string
+	MatrixGOARCHPlaceholder string
+	WorkflowPlaceholder     string
+	RunURLPlaceholder       string
+	// SecretPlaceholderPrefix is followed by a secret name, e.g. GITHUB_SECRET_PLACEHOLDER_SLACK_WEBHOOK
+	SecretPlaceholderPrefix string
 }{
-	ActorPlaceholder: "GITHUB_ACTOR_PLACEHOLDER",
-	TokenPlaceholder: "GITHUB_TOKEN_PLACEHOLDER",
+	ActorPlaceholder:        "GITHUB_ACTOR_PLACEHOLDER",
+	TokenPlaceholder:        "GITHUB_TOKEN_PLACEHOLDER",
+	MatrixGOOSPlaceholder:   "MATRIX_GOOS_PLACEHOLDER",
+	MatrixGOARCHPlaceholder: "MATRIX_GOARCH_PLACEHOLDER",
+	WorkflowPlaceholder:     "GITHUB_WORKFLOW_PLACEHOLDER",
+	RunURLPlaceholder:       "GITHUB_RUN_URL_PLACEHOLDER",
+	SecretPlaceholderPrefix: "GITHUB_SECRET_PLACEHOLDER_",
 }
 
 // ConditionBuilder helps construct complex GitHub Actions conditional expressions
@@ -89,6 +190,12 @@ func (cb *ConditionBuilder) WithRefStartsWith(prefix string) *ConditionBuilder {
 	return cb
 }
 
+// WithNotRefStartsWith adds a negated ref prefix condition
+func (cb *ConditionBuilder) WithNotRefStartsWith(prefix string) *ConditionBuilder {
+	cb.parts = append(cb.parts, fmt.Sprintf("!startsWith(%s, '%s')", GitHubRef, prefix))
+	return cb
+}
+
 // WithAlways adds the always() function
 func (cb *ConditionBuilder) WithAlways() *ConditionBuilder {
 	cb.parts = append(cb.parts, "always()")
@@ -141,23 +248,9 @@ func (cc *ContainerConditions) BuildCondition() string {
 		And()
 
 	// Build on production condition (tags or releases)
-	productionEventCondition := NewConditionBuilder().
-		WithEventEquals(EventPush).
-		WithRefStartsWith(RefTagsPrefix).
-		And()
-
-	releaseCondition := NewConditionBuilder().
-		WithEventEquals(EventRelease).
-		And()
-
-	productionEvents := NewConditionBuilder().
-		WithCustomCondition(productionEventCondition).
-		WithCustomCondition(releaseCondition).
-		Or()
-
 	onProductionCondition := NewConditionBuilder().
 		WithInputCondition("container.build.onProduction").
-		WithCustomCondition(productionEvents).
+		WithCustomCondition(EnvironmentCond.ProductionEvents()).
 		And()
 
 	// Combine all build conditions
@@ -175,31 +268,17 @@ func (cc *ContainerConditions) BuildCondition() string {
 }
 
 // PushCondition creates the standard container push condition
-// Covers: push.enabled && (alwaysPush || (onProduction && (push+tags || release)))
+// Covers: push.enabled && (build.alwaysPush || (onProduction && (push+tags || release)))
 func (cc *ContainerConditions) PushCondition() string {
-	// Always push condition
+	// Always push condition (the flag lives in the build config, see models.BuildConfig)
 	alwaysPush := NewConditionBuilder().
-		WithInputCondition("container.push.alwaysPush").
+		WithInputCondition("container.build.alwaysPush").
 		And()
 
 	// Push on production condition (tags or releases)
-	productionEventCondition := NewConditionBuilder().
-		WithEventEquals(EventPush).
-		WithRefStartsWith(RefTagsPrefix).
-		And()
-
-	releaseCondition := NewConditionBuilder().
-		WithEventEquals(EventRelease).
-		And()
-
-	productionEvents := NewConditionBuilder().
-		WithCustomCondition(productionEventCondition).
-		WithCustomCondition(releaseCondition).
-		Or()
-
 	onProductionCondition := NewConditionBuilder().
 		WithInputCondition("container.push.onProduction").
-		WithCustomCondition(productionEvents).
+		WithCustomCondition(EnvironmentCond.ProductionEvents()).
 		And()
 
 	// Combine push conditions
@@ -234,8 +313,116 @@ func (sc *SecurityConditions) TrivyUploadCondition() string {
 		And()
 }
 
+// GosecScanCondition creates the standard gosec scan condition
+func (sc *SecurityConditions) GosecScanCondition() string {
+	return NewConditionBuilder().
+		WithInputCondition("security.gosec.enabled").
+		And()
+}
+
+// GosecUploadCondition creates the gosec SARIF upload condition (runs even on failure)
+func (sc *SecurityConditions) GosecUploadCondition() string {
+	return NewConditionBuilder().
+		WithInputCondition("security.gosec.enabled").
+		WithAlways().
+		And()
+}
+
+// BanditScanCondition creates the standard bandit scan condition
+func (sc *SecurityConditions) BanditScanCondition() string {
+	return NewConditionBuilder().
+		WithInputCondition("security.bandit.enabled").
+		And()
+}
+
+// BanditUploadCondition creates the bandit SARIF upload condition (runs even on failure)
+func (sc *SecurityConditions) BanditUploadCondition() string {
+	return NewConditionBuilder().
+		WithInputCondition("security.bandit.enabled").
+		WithAlways().
+		And()
+}
+
+// BuildConditions provides pre-built condition builders for build scenarios
+type BuildConditions struct{}
+
+// CrossCompileCondition creates the condition for per-platform cross-compilation steps
+func (bc *BuildConditions) CrossCompileCondition() string {
+	return NewConditionBuilder().
+		WithInputCondition("crossCompile").
+		And()
+}
+
+// ArtifactsCondition creates the condition for the build artifact upload. Artifacts are
+// uploaded after failed steps too, so test reports are available, but not when cancelled.
+func (bc *BuildConditions) ArtifactsCondition() string {
+	return NewConditionBuilder().
+		WithInputCondition("artifacts.enabled").
+		WithCustomCondition("!cancelled()").
+		And()
+}
+
+// NotificationCondition creates the condition for the failure notification: it only
+// runs when an earlier step failed
+func (bc *BuildConditions) NotificationCondition() string {
+	return NewConditionBuilder().
+		WithCustomCondition("failure()").
+		And()
+}
+
+// EnvironmentConditions provides conditions matching the events each gpgen environment runs for
+type EnvironmentConditions struct{}
+
+// Environment names with a known event condition
+const (
+	EnvironmentStaging    = "staging"
+	EnvironmentProduction = "production"
+)
+
+// ProductionEvents creates the production event condition
+// Covers: push+tags || release
+func (ec *EnvironmentConditions) ProductionEvents() string {
+	tagPush := NewConditionBuilder().
+		WithEventEquals(EventPush).
+		WithRefStartsWith(RefTagsPrefix).
+		And()
+
+	return NewConditionBuilder().
+		WithCustomCondition(tagPush).
+		WithEventEquals(EventRelease).
+		Or()
+}
+
+// StagingEvents creates the staging event condition
+// Covers: pull_request || push to a branch
+func (ec *EnvironmentConditions) StagingEvents() string {
+	branchPush := NewConditionBuilder().
+		WithEventEquals(EventPush).
+		WithNotRefStartsWith(RefTagsPrefix).
+		And()
+
+	return NewConditionBuilder().
+		WithEventEquals(EventPullRequest).
+		WithCustomCondition(branchPush).
+		Or()
+}
+
+// ForEnvironment returns the event condition for a gpgen environment name
+func (ec *EnvironmentConditions) ForEnvironment(environment string) (string, error) {
+	switch environment {
+	case EnvironmentProduction:
+		return ec.ProductionEvents(), nil
+	case EnvironmentStaging:
+		return ec.StagingEvents(), nil
+	default:
+		return "", fmt.Errorf("no event condition for environment %q, must be %s or %s", environment, EnvironmentStaging, EnvironmentProduction)
+	}
+}
+
 // Global instances for easy access
 var (
-	ContainerCond = &ContainerConditions{}
-	SecurityCond  = &SecurityConditions{}
+	ContainerCond   = &ContainerConditions{}
+	SecurityCond    = &SecurityConditions{}
+	BuildCond       = &BuildConditions{}
+	EnvironmentCond = &EnvironmentConditions{}
 )
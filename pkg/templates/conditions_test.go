@@ -4,33 +4,35 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/config"
 )
 
 // Test constants to avoid duplicate literal warnings
 const (
 	// Input condition strings
-	testContainerEnabledInput = "container.enabled"
-	testContainerEnabledTemplate = "{{ .Inputs.container.enabled }}"
-	testContainerBuildAlwaysBuildTemplate = "{{ .Inputs.container.build.alwaysBuild }}"
-	testContainerBuildOnPRTemplate = "{{ .Inputs.container.build.onPR }}"
-	testContainerBuildOnProductionTemplate = "{{ .Inputs.container.build.onProduction }}"
-	testContainerPushEnabledTemplate = "{{ .Inputs.container.push.enabled }}"
-	testContainerPushAlwaysPushTemplate = "{{ .Inputs.container.push.alwaysPush }}"
-	testContainerPushOnProductionTemplate = "{{ .Inputs.container.push.onProduction }}"
-	testSecurityTrivyEnabledInput = "security.trivy.enabled"
-	testSecurityTrivyEnabledTemplate = "{{ .Inputs.security.trivy.enabled }}"
+	testContainerEnabledInput                  = "container.enabled"
+	testContainerEnabledTemplate               = "{{ .Inputs.container.enabled }}"
+	testContainerBuildAlwaysBuildTemplate      = "{{ .Inputs.container.build.alwaysBuild }}"
+	testContainerBuildOnPRTemplate             = "{{ .Inputs.container.build.onPR }}"
+	testContainerBuildOnProductionTemplate     = "{{ .Inputs.container.build.onProduction }}"
+	testContainerPushEnabledTemplate           = "{{ .Inputs.container.push.enabled }}"
+	testContainerBuildAlwaysPushTemplate       = "{{ .Inputs.container.build.alwaysPush }}"
+	testContainerPushOnProductionTemplate      = "{{ .Inputs.container.push.onProduction }}"
+	testSecurityTrivyEnabledInput              = "security.trivy.enabled"
+	testSecurityTrivyEnabledTemplate           = "{{ .Inputs.security.trivy.enabled }}"
 	testSecurityTrivyEnabledWithAlwaysTemplate = "{{ .Inputs.security.trivy.enabled }} && always()"
 
 	// GitHub event condition strings
-	testEventPushCondition = "github.event_name == 'push'"
-	testEventReleaseCondition = "github.event_name == 'release'"
+	testEventPushCondition        = "github.event_name == 'push'"
+	testEventReleaseCondition     = "github.event_name == 'release'"
 	testEventPullRequestCondition = "github.event_name == 'pull_request'"
 
 	// GitHub ref condition strings
 	testRefTagsStartsWithCondition = "startsWith(github.ref, 'refs/tags/')"
 
 	// Common event names for testing
-	testEventPush = "push"
+	testEventPush    = "push"
 	testEventRelease = "release"
 
 	// Ref patterns for testing
@@ -55,6 +57,7 @@ func TestGitHubActionVersions(t *testing.T) {
 	})
 
 	t.Run("docker actions versions", func(t *testing.T) {
+		assert.Equal(t, "docker/setup-qemu-action@v3", GitHubActionVersions.DockerSetupQEMU)
 		assert.Equal(t, "docker/setup-buildx-action@v3", GitHubActionVersions.DockerSetupBuildx)
 		assert.Equal(t, "docker/login-action@v3", GitHubActionVersions.DockerLogin)
 		assert.Equal(t, "docker/build-push-action@v5", GitHubActionVersions.DockerBuildPush)
@@ -63,6 +66,7 @@ func TestGitHubActionVersions(t *testing.T) {
 	t.Run("security actions versions", func(t *testing.T) {
 		assert.Equal(t, "github/codeql-action/upload-sarif@v3", GitHubActionVersions.CodeQLUploadSARIF)
 		assert.Equal(t, "aquasecurity/trivy-action@master", GitHubActionVersions.TrivyAction)
+		assert.Equal(t, "securego/gosec@master", GitHubActionVersions.Gosec)
 	})
 }
 
@@ -110,6 +114,11 @@ func TestConditionBuilder(t *testing.T) {
 		assert.Equal(t, testRefTagsStartsWithCondition, cb.And())
 	})
 
+	t.Run("negated ref starts with condition", func(t *testing.T) {
+		cb := NewConditionBuilder().WithNotRefStartsWith(testRefTagsPrefix)
+		assert.Equal(t, "!"+testRefTagsStartsWithCondition, cb.And())
+	})
+
 	t.Run("always condition", func(t *testing.T) {
 		cb := NewConditionBuilder().
 			WithInputCondition(testSecurityTrivyEnabledInput).
@@ -165,7 +174,7 @@ func TestContainerConditions(t *testing.T) {
 		// Should contain all the main components
 		assert.Contains(t, condition, testContainerEnabledTemplate)
 		assert.Contains(t, condition, testContainerPushEnabledTemplate)
-		assert.Contains(t, condition, testContainerPushAlwaysPushTemplate)
+		assert.Contains(t, condition, testContainerBuildAlwaysPushTemplate)
 		assert.Contains(t, condition, testContainerPushOnProductionTemplate)
 		assert.Contains(t, condition, testEventPushCondition)
 		assert.Contains(t, condition, testRefTagsStartsWithCondition)
@@ -206,7 +215,7 @@ func TestContainerConditions(t *testing.T) {
 		expectedParts := []string{
 			testContainerEnabledTemplate,
 			testContainerPushEnabledTemplate,
-			testContainerPushAlwaysPushTemplate,
+			testContainerBuildAlwaysPushTemplate,
 			testContainerPushOnProductionTemplate,
 			testEventPushCondition,
 			testRefTagsStartsWithCondition,
@@ -230,6 +239,61 @@ func TestSecurityConditions(t *testing.T) {
 		expected := testSecurityTrivyEnabledWithAlwaysTemplate
 		assert.Equal(t, expected, condition)
 	})
+
+	t.Run("gosec scan condition", func(t *testing.T) {
+		condition := SecurityCond.GosecScanCondition()
+		assert.Equal(t, "{{ .Inputs.security.gosec.enabled }}", condition)
+	})
+
+	t.Run("gosec upload condition", func(t *testing.T) {
+		condition := SecurityCond.GosecUploadCondition()
+		assert.Equal(t, "{{ .Inputs.security.gosec.enabled }} && always()", condition)
+	})
+
+	t.Run("bandit scan condition", func(t *testing.T) {
+		condition := SecurityCond.BanditScanCondition()
+		assert.Equal(t, "{{ .Inputs.security.bandit.enabled }}", condition)
+	})
+
+	t.Run("bandit upload condition", func(t *testing.T) {
+		condition := SecurityCond.BanditUploadCondition()
+		assert.Equal(t, "{{ .Inputs.security.bandit.enabled }} && always()", condition)
+	})
+}
+
+func TestBuildConditions(t *testing.T) {
+	t.Run("cross compile condition", func(t *testing.T) {
+		condition := BuildCond.CrossCompileCondition()
+		assert.Equal(t, "{{ .Inputs.crossCompile }}", condition)
+	})
+
+	t.Run("artifacts condition", func(t *testing.T) {
+		condition := BuildCond.ArtifactsCondition()
+		assert.Equal(t, "{{ .Inputs.artifacts.enabled }} && !cancelled()", condition)
+	})
+
+	t.Run("notification condition", func(t *testing.T) {
+		assert.Equal(t, "failure()", BuildCond.NotificationCondition())
+	})
+}
+
+func TestEnvironmentConditions(t *testing.T) {
+	t.Run("production events", func(t *testing.T) {
+		condition, err := EnvironmentCond.ForEnvironment(EnvironmentProduction)
+		require.NoError(t, err)
+		assert.Equal(t, "("+testEventPushCondition+" && "+testRefTagsStartsWithCondition+" || "+testEventReleaseCondition+")", condition)
+	})
+
+	t.Run("staging events", func(t *testing.T) {
+		condition, err := EnvironmentCond.ForEnvironment(EnvironmentStaging)
+		require.NoError(t, err)
+		assert.Equal(t, "(github.event_name == 'pull_request' || "+testEventPushCondition+" && !"+testRefTagsStartsWithCondition+")", condition)
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		_, err := EnvironmentCond.ForEnvironment("qa")
+		assert.Error(t, err)
+	})
 }
 
 func TestEventConstants(t *testing.T) {
@@ -319,3 +383,91 @@ func TestRealWorldConditionExamples(t *testing.T) {
 		assert.Contains(t, condition, "{{ .Inputs.environment.staging }}")
 	})
 }
+
+func TestSetActionVersions(t *testing.T) {
+	original := GitHubActionVersions
+	defer func() { GitHubActionVersions = original }()
+
+	t.Run("full reference and bare version overrides", func(t *testing.T) {
+		GitHubActionVersions = original
+
+		err := SetActionVersions(map[string]string{
+			"checkout": "actions/checkout@v3",
+			"setupGo":  "v5",
+			"gosec":    "@v2.21.4",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "actions/checkout@v3", GitHubActionVersions.Checkout)
+		assert.Equal(t, "actions/setup-go@v5", GitHubActionVersions.SetupGo)
+		assert.Equal(t, "securego/gosec@v2.21.4", GitHubActionVersions.Gosec)
+		assert.Equal(t, original.SetupNode, GitHubActionVersions.SetupNode)
+	})
+
+	t.Run("overridden version is used by templates", func(t *testing.T) {
+		GitHubActionVersions = original
+		require.NoError(t, SetActionVersions(map[string]string{"checkout": "@v3"}))
+
+		assert.Equal(t, "actions/checkout@v3", createCheckoutStep().Uses)
+	})
+
+	t.Run("unknown action leaves defaults untouched", func(t *testing.T) {
+		GitHubActionVersions = original
+
+		err := SetActionVersions(map[string]string{
+			"checkout": "v3",
+			"unknown":  "v1",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown action "unknown"`)
+		assert.Equal(t, original.Checkout, GitHubActionVersions.Checkout)
+	})
+
+	t.Run("empty version is rejected", func(t *testing.T) {
+		GitHubActionVersions = original
+
+		err := SetActionVersions(map[string]string{"checkout": " "})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot be empty")
+	})
+}
+
+func TestActionVersionNames(t *testing.T) {
+	names := make([]string, 0, len(actionVersionFields()))
+	for name := range actionVersionFields() {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, config.ActionNames, names, "manifests are validated against config.ActionNames")
+
+	tm := NewTemplateManager("")
+	for _, name := range tm.ListTemplates() {
+		tmpl, err := tm.LoadTemplate(name)
+		require.NoError(t, err)
+		for _, step := range tmpl.Steps {
+			if step.Uses != "" {
+				assert.Contains(t, config.ActionNames, step.Action, "%s step %s names its action", name, step.ID)
+			}
+		}
+	}
+}
+
+func TestResolveActionVersions(t *testing.T) {
+	replacements, err := ResolveActionVersions(map[string]string{
+		"checkout":    "v5",
+		"trivyAction": "aquasecurity/trivy-action@0.28.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"checkout":    "actions/checkout@v5",
+		"trivyAction": "aquasecurity/trivy-action@0.28.0",
+	}, replacements)
+	assert.Equal(t, "actions/checkout@v4", GitHubActionVersions.Checkout, "built-in versions are untouched")
+
+	replacements, err = ResolveActionVersions(map[string]string{"trivy": "v1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"trivyAction": "aquasecurity/trivy-action@v1"}, replacements, "aliases resolve to the action name")
+
+	_, err = ResolveActionVersions(map[string]string{"trivvy": "v1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown action "trivvy"`)
+}
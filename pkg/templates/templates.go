@@ -1,10 +1,17 @@
 package templates
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/terrpan/gpgen/pkg/config"
+	"github.com/terrpan/gpgen/pkg/manifest"
 	"github.com/terrpan/gpgen/pkg/models"
+	"gopkg.in/yaml.v3"
 )
 
 // Alias shared types from pkg/models for clarity
@@ -51,9 +58,51 @@ func (tm *TemplateManager) LoadTemplate(name string) (*Template, error) {
 	return template, nil
 }
 
+// RegisterTemplate makes a template available by name in addition to the built-ins,
+// replacing any template already loaded under that name
+func (tm *TemplateManager) RegisterTemplate(template *Template) {
+	tm.templates[template.Name] = template
+}
+
+// HasInput reports whether a template declares the named input
+func HasInput(template *Template, name string) bool {
+	if template == nil {
+		return false
+	}
+	_, exists := template.Inputs[name]
+	return exists
+}
+
+// TemplateHash returns a short content hash of a template, used to tell when
+// workflows generated from it have gone stale
+func (tm *TemplateManager) TemplateHash(name string) (string, error) {
+	content, err := tm.TemplateYAML(name)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
+
+// TemplateYAML returns the full definition of a template (inputs and steps) as YAML,
+// e.g. to save into a templates directory as the starting point of a custom template
+func (tm *TemplateManager) TemplateYAML(name string) ([]byte, error) {
+	template, err := tm.LoadTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := yaml.Marshal(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template %s: %w", name, err)
+	}
+	return content, nil
+}
+
 // ListTemplates returns available template names
 func (tm *TemplateManager) ListTemplates() []string {
-	return []string{"node-app", "go-service", "python-app"}
+	return []string{"node-app", "go-service", "python-app", "rust-service", "java-app"}
 }
 
 // ValidateInputs validates that provided inputs match template requirements
@@ -102,6 +151,22 @@ func (tm *TemplateManager) ValidateInputValue(name string, value interface{}, de
 		if _, ok := value.([]interface{}); !ok {
 			return fmt.Errorf("input '%s' must be an array", name)
 		}
+	case models.InputTypeDuration:
+		if _, err := ParseDurationMinutes(value); err != nil {
+			return fmt.Errorf("input '%s' %w", name, err)
+		}
+	case models.InputTypeObject:
+		if def.ValueType != "" {
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("input '%s' must be an object", name)
+			}
+			for key, v := range values {
+				if err := tm.ValidateInputValue(name+"."+key, v, Input{Type: def.ValueType}); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	// Validate options if provided
@@ -118,6 +183,145 @@ func (tm *TemplateManager) ValidateInputValue(name string, value interface{}, de
 	return nil
 }
 
+// CoerceInputValue converts scalar values to the input's declared type where the intent is
+// unambiguous: "true"/"false" strings for booleans, numeric strings for numbers, and YAML
+// booleans/numbers for strings (e.g. goVersion: 1.22). Object values are coerced key by key,
+// against ValueType or the type of the matching key in the input's default. Other values are
+// returned unchanged.
+func CoerceInputValue(value interface{}, def Input) interface{} {
+	switch def.Type {
+	case models.InputTypeBoolean:
+		if s, ok := value.(string); ok {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "true":
+				return true
+			case "false":
+				return false
+			}
+		}
+	case models.InputTypeNumber:
+		if s, ok := value.(string); ok {
+			if i, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+				return i
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return f
+			}
+		}
+	case models.InputTypeString:
+		switch v := value.(type) {
+		case bool:
+			return strconv.FormatBool(v)
+		case int:
+			return strconv.Itoa(v)
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case models.InputTypeDuration:
+		if minutes, err := ParseDurationMinutes(value); err == nil {
+			return minutes
+		}
+	case models.InputTypeObject:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		shape := objectShape(def.Default)
+		coerced := make(map[string]interface{}, len(values))
+		for key, v := range values {
+			switch field, known := shape[key]; {
+			case known:
+				coerced[key] = CoerceInputValue(v, Input{Type: inputTypeOf(field), Default: field})
+			case def.ValueType != "":
+				coerced[key] = CoerceInputValue(v, Input{Type: def.ValueType})
+			default:
+				coerced[key] = v
+			}
+		}
+		return coerced
+	}
+	return value
+}
+
+// objectShape returns an object input's default as a map, converting typed defaults such
+// as models.ContainerConfig through their YAML form. It returns nil for non-object defaults.
+func objectShape(defaultValue interface{}) map[string]interface{} {
+	if defaultValue == nil {
+		return nil
+	}
+	if shape, ok := defaultValue.(map[string]interface{}); ok {
+		return shape
+	}
+	data, err := yaml.Marshal(defaultValue)
+	if err != nil {
+		return nil
+	}
+	var shape map[string]interface{}
+	if err := yaml.Unmarshal(data, &shape); err != nil {
+		return nil
+	}
+	return shape
+}
+
+// inputTypeOf returns the input type matching a default value's Go type
+func inputTypeOf(value interface{}) models.InputType {
+	switch value.(type) {
+	case bool:
+		return models.InputTypeBoolean
+	case int, float64:
+		return models.InputTypeNumber
+	case string:
+		return models.InputTypeString
+	case []interface{}:
+		return models.InputTypeArray
+	case map[string]interface{}:
+		return models.InputTypeObject
+	}
+	return ""
+}
+
+// Duration inputs are bounded by GitHub's 6 hour job execution limit
+const (
+	MinDurationMinutes = 1
+	MaxDurationMinutes = 360
+)
+
+// ParseDurationMinutes converts a duration input to whole minutes. Numbers are taken
+// as minutes; strings may be plain minutes ("15") or Go durations ("15m", "1h30m").
+func ParseDurationMinutes(value interface{}) (int, error) {
+	var minutes int
+	switch v := value.(type) {
+	case int:
+		minutes = v
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("must be a whole number of minutes, got %v", v)
+		}
+		minutes = int(v)
+	case string:
+		s := strings.TrimSpace(v)
+		if i, err := strconv.Atoi(s); err == nil {
+			minutes = i
+			break
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("must be a duration such as \"15m\" or \"1h30m\", got %q", v)
+		}
+		if d%time.Minute != 0 {
+			return 0, fmt.Errorf("must be a whole number of minutes, got %q", v)
+		}
+		minutes = int(d / time.Minute)
+	default:
+		return 0, fmt.Errorf("must be a duration such as \"15m\" or \"1h30m\"")
+	}
+
+	if minutes < MinDurationMinutes || minutes > MaxDurationMinutes {
+		return 0, fmt.Errorf("must be between %dm and %dm, got %dm", MinDurationMinutes, MaxDurationMinutes, minutes)
+	}
+	return minutes, nil
+}
+
 // getBuiltinTemplate returns built-in template definitions
 func getBuiltinTemplate(name string) (*Template, error) {
 	switch name {
@@ -127,6 +331,10 @@ func getBuiltinTemplate(name string) (*Template, error) {
 		return getGoServiceTemplate(), nil
 	case "python-app":
 		return getPythonAppTemplate(), nil
+	case "rust-service":
+		return getRustServiceTemplate(), nil
+	case "java-app":
+		return getJavaAppTemplate(), nil
 	default:
 		return nil, fmt.Errorf("unknown template: %s", name)
 	}
@@ -144,42 +352,52 @@ func getNodeAppTemplate() *Template {
 	}
 
 	// Merge with security and container inputs
-	allInputs := mergeInputs(baseInputs, createSecurityInputs(), createContainerInputs())
+	allInputs := mergeInputs(baseInputs, createCheckoutInputs(), createTimeoutInputs(), createInstallInputs(), createSecurityInputs(config.LanguageNode), createContainerInputs(), createArtifactsInputs(), createNotificationsInputs())
 
 	// Create base steps
 	steps := []Step{
 		createCheckoutStep(),
 		{
-			ID:   "setup-node",
-			Name: "Setup Node.js",
-			Uses: GitHubActionVersions.SetupNode,
+			ID:     "setup-node",
+			Name:   "Setup Node.js",
+			Uses:   GitHubActionVersions.SetupNode,
+			Action: "setupNode",
 			With: map[string]string{
 				"node-version": "{{ .Inputs.nodeVersion }}",
 				"cache":        "{{ .Inputs.packageManager }}",
 			},
 		},
 		{
-			ID:   "install",
-			Name: "Install dependencies",
-			Run:  "{{ .Inputs.packageManager }} {{ if eq .Inputs.packageManager \"npm\" }}ci{{ else }}install --frozen-lockfile{{ end }}",
+			ID:           "install",
+			Name:         "Install dependencies",
+			Run:          withInstallRetries(selectPackageManagerCommand(nodeInstallCommands)),
+			TimeoutInput: "installTimeout",
 		},
 		{
-			ID:   "test",
-			Name: "Run tests",
-			Run:  "{{ .Inputs.testCommand }}",
+			ID:          "test",
+			Name:        "Run tests",
+			Run:         "{{ .Inputs.testCommand }}",
+			TimeoutMins: nodeConfig.DefaultTestTimeout,
 		},
 		{
-			ID:   "build",
-			Name: "Build application",
-			Run:  "{{ .Inputs.buildCommand }}",
-			If:   "{{ .Inputs.buildCommand }}",
+			ID:          "build",
+			Name:        "Build application",
+			Run:         "{{ .Inputs.buildCommand }}",
+			If:          "{{ .Inputs.buildCommand }}",
+			TimeoutMins: nodeConfig.DefaultBuildTimeout,
 		},
 	}
 
+	// Upload build outputs once they exist
+	steps = append(steps, createArtifactsStep())
+
 	// Add security and container steps
 	steps = append(steps, createSecuritySteps()...)
 	steps = append(steps, createContainerSteps()...)
 
+	// Report failures once every other step has run
+	steps = append(steps, createSlackNotificationStep())
+
 	return &Template{
 		Name:        "node-app",
 		Description: "Node.js application with testing, building, and deployment",
@@ -188,6 +406,11 @@ func getNodeAppTemplate() *Template {
 		Tags:        []string{"nodejs", "javascript", "web"},
 		Inputs:      allInputs,
 		Steps:       steps,
+		Permissions: createBasePermissions(),
+		DefaultEnvironments: createDefaultEnvironments(
+			map[string]interface{}{"testCommand": "npm run test:ci"},
+			map[string]interface{}{"nodeVersion": "20", "testCommand": "npm run test:all"},
+		),
 	}
 }
 
@@ -205,39 +428,82 @@ func getGoServiceTemplate() *Template {
 			Default:     "linux/amd64,darwin/amd64",
 			Required:    false,
 		},
+		"crossCompile": {
+			Type:        models.InputTypeBoolean,
+			Description: "Build per-platform binaries in a matrix over platforms and upload them as artifacts",
+			Default:     false,
+			Required:    false,
+		},
+		"cacheEnabled": {
+			Type:        models.InputTypeBoolean,
+			Description: "Cache Go modules and the build cache in setup-go",
+			Default:     true,
+			Required:    false,
+		},
+		"cacheDependencyPath": {
+			Type:        models.InputTypeString,
+			Description: "Dependency files the cache key is computed from, e.g. \"**/go.sum\" (default: go.sum in the repository root)",
+			Default:     "",
+			Required:    false,
+		},
 	}
 
 	// Merge with security and container inputs
-	allInputs := mergeInputs(baseInputs, createSecurityInputs(), createContainerInputs())
+	allInputs := mergeInputs(baseInputs, createCheckoutInputs(), createTimeoutInputs(), createSecurityInputs(config.LanguageGo), createContainerInputs(), createArtifactsInputs(), createNotificationsInputs())
 
 	// Create base steps
 	steps := []Step{
 		createCheckoutStep(),
 		{
-			ID:   "setup-go",
-			Name: "Setup Go",
-			Uses: GitHubActionVersions.SetupGo,
+			ID:     "setup-go",
+			Name:   "Setup Go",
+			Uses:   GitHubActionVersions.SetupGo,
+			Action: "setupGo",
 			With: map[string]string{
-				"go-version": "{{ .Inputs.goVersion }}",
-				"cache":      "true",
+				"go-version":            "{{ .Inputs.goVersion }}",
+				"cache":                 "{{ .Inputs.cacheEnabled }}",
+				"cache-dependency-path": "{{ if .Inputs.cacheEnabled }}{{ .Inputs.cacheDependencyPath }}{{ end }}",
 			},
 		},
 		{
-			ID:   "test",
-			Name: "Run tests",
-			Run:  "{{ .Inputs.testCommand }}",
+			ID:          "test",
+			Name:        "Run tests",
+			Run:         "{{ .Inputs.testCommand }}",
+			TimeoutMins: goConfig.DefaultTestTimeout,
 		},
 		{
 			ID:   "build",
 			Name: "Build service",
 			Run:  "{{ .Inputs.buildCommand }}",
+			Env: map[string]string{
+				"GOOS":   "{{ if .Inputs.crossCompile }}" + GitHubPlaceholders.MatrixGOOSPlaceholder + "{{ end }}",
+				"GOARCH": "{{ if .Inputs.crossCompile }}" + GitHubPlaceholders.MatrixGOARCHPlaceholder + "{{ end }}",
+			},
+			TimeoutMins: goConfig.DefaultBuildTimeout,
+		},
+		{
+			ID:     CrossCompileArtifactsStepID,
+			Name:   "Upload build artifacts",
+			Uses:   GitHubActionVersions.UploadArtifact,
+			Action: "uploadArtifact",
+			With: map[string]string{
+				"name": "service-" + GitHubPlaceholders.MatrixGOOSPlaceholder + "-" + GitHubPlaceholders.MatrixGOARCHPlaceholder,
+				"path": "bin/",
+			},
 		},
 	}
 
+	// Upload build outputs once they exist
+	steps = append(steps, createArtifactsStep())
+
 	// Add security and container steps
 	steps = append(steps, createSecuritySteps()...)
+	steps = append(steps, createGoSecuritySteps()...)
 	steps = append(steps, createContainerSteps()...)
 
+	// Report failures once every other step has run
+	steps = append(steps, createSlackNotificationStep())
+
 	return &Template{
 		Name:        "go-service",
 		Description: "Go service with testing, building, and cross-compilation",
@@ -246,6 +512,11 @@ func getGoServiceTemplate() *Template {
 		Tags:        []string{"go", "golang", "service", "api"},
 		Inputs:      allInputs,
 		Steps:       steps,
+		Permissions: createBasePermissions(),
+		DefaultEnvironments: createDefaultEnvironments(
+			map[string]interface{}{"testCommand": "go test -race ./...", "security": trivySeverityInput("CRITICAL,HIGH,MEDIUM")},
+			map[string]interface{}{"goVersion": "1.22", "testCommand": "go test -race -cover ./...", "security": trivySeverityInput("CRITICAL")},
+		),
 	}
 }
 
@@ -258,6 +529,12 @@ func getPythonAppTemplate() *Template {
 		"packageManager": createPackageManagerInput(string(pythonConfig.DefaultManager), config.Config.GetPackageManagerOptions(config.LanguagePython)),
 		"testCommand":    createCommandInput("Command to run tests", pythonConfig.DefaultTestCmd, true),
 		"lintCommand":    createCommandInput("Command to run linting", pythonConfig.DefaultLintCmd, false),
+		"failOnLint": {
+			Type:        models.InputTypeBoolean,
+			Description: "Fail the build when linting fails (by default lint findings are advisory)",
+			Default:     false,
+			Required:    false,
+		},
 		"requirements": {
 			Type:        models.InputTypeString,
 			Description: "Requirements file path",
@@ -267,42 +544,54 @@ func getPythonAppTemplate() *Template {
 	}
 
 	// Merge with security and container inputs
-	allInputs := mergeInputs(baseInputs, createSecurityInputs(), createContainerInputs())
+	allInputs := mergeInputs(baseInputs, createCheckoutInputs(), createTimeoutInputs(), createInstallInputs(), createSecurityInputs(config.LanguagePython), createContainerInputs(), createArtifactsInputs(), createNotificationsInputs())
 
 	// Create base steps
 	steps := []Step{
 		createCheckoutStep(),
 		{
-			ID:   "setup-python",
-			Name: "Setup Python",
-			Uses: GitHubActionVersions.SetupPython,
+			ID:     "setup-python",
+			Name:   "Setup Python",
+			Uses:   GitHubActionVersions.SetupPython,
+			Action: "setupPython",
 			With: map[string]string{
 				"python-version": "{{ .Inputs.pythonVersion }}",
 				"cache":          "{{ .Inputs.packageManager }}",
 			},
 		},
 		{
-			ID:   "install",
-			Name: "Install dependencies",
-			Run:  "{{ if eq .Inputs.packageManager \"pip\" }}pip install -r {{ .Inputs.requirements }}{{ else if eq .Inputs.packageManager \"poetry\" }}poetry install{{ else }}pipenv install{{ end }}",
+			ID:           "install",
+			Name:         "Install dependencies",
+			Run:          withInstallRetries(selectPackageManagerCommand(pythonInstallCommands)),
+			TimeoutInput: "installTimeout",
 		},
 		{
 			ID:   "lint",
 			Name: "Run linting",
 			Run:  "{{ .Inputs.lintCommand }}",
 			If:   "{{ .Inputs.lintCommand }}",
+			// Lint is advisory unless the manifest opts into strict linting
+			ContinueOnError: "{{ not .Inputs.failOnLint }}",
 		},
 		{
-			ID:   "test",
-			Name: "Run tests",
-			Run:  "{{ .Inputs.testCommand }}",
+			ID:          "test",
+			Name:        "Run tests",
+			Run:         "{{ .Inputs.testCommand }}",
+			TimeoutMins: pythonConfig.DefaultTestTimeout,
 		},
 	}
 
+	// Upload build outputs once they exist
+	steps = append(steps, createArtifactsStep())
+
 	// Add security and container steps
 	steps = append(steps, createSecuritySteps()...)
+	steps = append(steps, createBanditSteps()...)
 	steps = append(steps, createContainerSteps()...)
 
+	// Report failures once every other step has run
+	steps = append(steps, createSlackNotificationStep())
+
 	return &Template{
 		Name:        "python-app",
 		Description: "Python application with testing, linting, and packaging",
@@ -311,6 +600,160 @@ func getPythonAppTemplate() *Template {
 		Tags:        []string{"python", "web", "application"},
 		Inputs:      allInputs,
 		Steps:       steps,
+		Permissions: createBasePermissions(),
+		DefaultEnvironments: createDefaultEnvironments(
+			map[string]interface{}{"testCommand": "pytest --cov=. --cov-report=xml"},
+			map[string]interface{}{"pythonVersion": "3.12", "testCommand": "pytest --cov=. --cov-report=xml --cov-fail-under=80"},
+		),
+	}
+}
+
+func getRustServiceTemplate() *Template {
+	// Create base inputs for Rust language using type-safe config
+	rustConfig := config.Config.Languages[config.LanguageRust]
+
+	baseInputs := map[string]Input{
+		"rustVersion":  createLanguageVersionInput("Rust toolchain", rustConfig.DefaultVersion, rustConfig.Versions),
+		"testCommand":  createCommandInput("Command to run tests", rustConfig.DefaultTestCmd, true),
+		"buildCommand": createCommandInput("Command to build the service", rustConfig.DefaultBuildCmd, true),
+	}
+
+	// Merge with security and container inputs
+	allInputs := mergeInputs(baseInputs, createCheckoutInputs(), createTimeoutInputs(), createSecurityInputs(config.LanguageRust), createContainerInputs(), createArtifactsInputs(), createNotificationsInputs())
+
+	// Create base steps
+	steps := []Step{
+		createCheckoutStep(),
+		{
+			ID:     "setup-rust",
+			Name:   "Setup Rust",
+			Uses:   GitHubActionVersions.RustToolchain,
+			Action: "rustToolchain",
+			With: map[string]string{
+				"toolchain": "{{ .Inputs.rustVersion }}",
+			},
+		},
+		{
+			ID:          "test",
+			Name:        "Run tests",
+			Run:         "{{ .Inputs.testCommand }}",
+			TimeoutMins: rustConfig.DefaultTestTimeout,
+		},
+		{
+			ID:          "build",
+			Name:        "Build service",
+			Run:         "{{ .Inputs.buildCommand }}",
+			TimeoutMins: rustConfig.DefaultBuildTimeout,
+		},
+	}
+
+	// Upload build outputs once they exist
+	steps = append(steps, createArtifactsStep())
+
+	// Add security and container steps
+	steps = append(steps, createSecuritySteps()...)
+	steps = append(steps, createContainerSteps()...)
+
+	// Report failures once every other step has run
+	steps = append(steps, createSlackNotificationStep())
+
+	return &Template{
+		Name:        "rust-service",
+		Description: "Rust service with testing, release builds, and container packaging",
+		Version:     "1.0.0",
+		Author:      TemplateAuthor,
+		Tags:        []string{"rust", "cargo", "service"},
+		Inputs:      allInputs,
+		Steps:       steps,
+		Permissions: createBasePermissions(),
+		DefaultEnvironments: createDefaultEnvironments(
+			map[string]interface{}{"testCommand": "cargo test --all-features"},
+			map[string]interface{}{"testCommand": "cargo test --all-features --release"},
+		),
+	}
+}
+
+// javaDistributions lists the JDK distributions offered by the java-app template
+var javaDistributions = []string{"temurin", "zulu", "corretto", "microsoft", "oracle"}
+
+func getJavaAppTemplate() *Template {
+	// Create base inputs for Java language using type-safe config
+	javaConfig := config.Config.Languages[config.LanguageJava]
+
+	baseInputs := map[string]Input{
+		"javaVersion": createLanguageVersionInput("Java", javaConfig.DefaultVersion, javaConfig.Versions),
+		"javaDistribution": {
+			Type:        models.InputTypeString,
+			Description: "JDK distribution to install",
+			Default:     javaDistributions[0],
+			Required:    true,
+			Options:     javaDistributions,
+		},
+		"packageManager": createPackageManagerInput(string(javaConfig.DefaultManager), config.Config.GetPackageManagerOptions(config.LanguageJava)),
+		"testCommand":    createCommandInput("Command to run tests; defaults to the package manager's (mvn -B test or ./gradlew test)", "", false),
+		"buildCommand":   createCommandInput("Command to build the application; defaults to the package manager's (mvn -B package or ./gradlew build)", "", false),
+	}
+
+	// Merge with security and container inputs
+	allInputs := mergeInputs(baseInputs, createCheckoutInputs(), createTimeoutInputs(), createSecurityInputs(config.LanguageJava), createContainerInputs(), createArtifactsInputs(), createNotificationsInputs())
+
+	// Create base steps
+	steps := []Step{
+		createCheckoutStep(),
+		{
+			ID:     "setup-java",
+			Name:   "Setup Java",
+			Uses:   GitHubActionVersions.SetupJava,
+			Action: "setupJava",
+			With: map[string]string{
+				"distribution": "{{ .Inputs.javaDistribution }}",
+				"java-version": "{{ .Inputs.javaVersion }}",
+				"cache":        "{{ .Inputs.packageManager }}",
+			},
+		},
+		{
+			ID:          "test",
+			Name:        "Run tests",
+			Run:         "{{ with .Inputs.testCommand }}{{ . }}{{ else }}" + selectPackageManagerCommand(javaTestCommands) + "{{ end }}",
+			TimeoutMins: javaConfig.DefaultTestTimeout,
+		},
+		{
+			ID:          "build",
+			Name:        "Build application",
+			Run:         "{{ with .Inputs.buildCommand }}{{ . }}{{ else }}" + selectPackageManagerCommand(javaBuildCommands) + "{{ end }}",
+			TimeoutMins: javaConfig.DefaultBuildTimeout,
+		},
+	}
+
+	// Upload build outputs once they exist
+	steps = append(steps, createArtifactsStep())
+
+	// Add security and container steps
+	steps = append(steps, createSecuritySteps()...)
+	steps = append(steps, createContainerSteps()...)
+
+	// Report failures once every other step has run
+	steps = append(steps, createSlackNotificationStep())
+
+	return &Template{
+		Name:        "java-app",
+		Description: "Java application with Maven or Gradle testing, packaging, and security scanning",
+		Version:     "1.0.0",
+		Author:      TemplateAuthor,
+		Tags:        []string{"java", "maven", "gradle", "application"},
+		Inputs:      allInputs,
+		Steps:       steps,
+		Permissions: createBasePermissions(),
+		DefaultEnvironments: createDefaultEnvironments(
+			map[string]interface{}{"testCommand": ManagerValues{
+				config.PackageManagerMaven:  "mvn -B verify",
+				config.PackageManagerGradle: "./gradlew check",
+			}},
+			map[string]interface{}{"testCommand": ManagerValues{
+				config.PackageManagerMaven:  "mvn -B verify -Pci",
+				config.PackageManagerGradle: "./gradlew check -Pci",
+			}},
+		),
 	}
 }
 
@@ -348,18 +791,128 @@ func createCommandInput(description string, defaultCmd string, required bool) In
 	}
 }
 
-// createSecurityInputs creates the standard security configuration inputs
-func createSecurityInputs() map[string]Input {
+// createBasePermissions creates the permissions every built-in template needs to check out code
+func createBasePermissions() map[string]string {
+	return map[string]string{
+		"contents": "read",
+	}
+}
+
+// ManagerValues is a default environment input whose value depends on the packageManager
+// input, e.g. a Maven or a Gradle test command
+type ManagerValues map[config.PackageManager]string
+
+// DefaultEnvironment returns a copy of a template's default configuration for an environment,
+// with ManagerValues inputs resolved for packageManager, or for the template's default
+// package manager when it is empty. Inputs without a value for the manager are left out.
+func DefaultEnvironment(tmpl *Template, environment, packageManager string) (manifest.EnvironmentConfig, bool) {
+	defaults, exists := tmpl.DefaultEnvironments[environment]
+	if !exists {
+		return manifest.EnvironmentConfig{}, false
+	}
+	if packageManager == "" {
+		packageManager, _ = tmpl.Inputs["packageManager"].Default.(string)
+	}
+
+	inputs := make(map[string]interface{}, len(defaults))
+	for k, v := range defaults {
+		if values, isManagerValues := v.(ManagerValues); isManagerValues {
+			value, hasValue := values[config.PackageManager(packageManager)]
+			if !hasValue {
+				continue
+			}
+			v = value
+		}
+		inputs[k] = v
+	}
+	return manifest.EnvironmentConfig{Inputs: inputs}, true
+}
+
+// createDefaultEnvironments creates the staging and production environments a template
+// suggests, with the given input overrides
+func createDefaultEnvironments(staging, production map[string]interface{}) map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{
+		"staging":    staging,
+		"production": production,
+	}
+}
+
+// trivySeverityInput creates a security input override setting only the Trivy severity
+func trivySeverityInput(severity string) map[string]interface{} {
+	return map[string]interface{}{
+		"trivy": map[string]interface{}{"severity": severity},
+	}
+}
+
+// createCheckoutInputs creates the standard checkout configuration inputs
+func createCheckoutInputs() map[string]Input {
+	return map[string]Input{
+		"fetchDepth": {
+			Type:        models.InputTypeString,
+			Description: "Number of commits to fetch (0 for full history, an expression, or 'auto' for full history on tags only)",
+			Default:     "1",
+			Required:    false,
+		},
+	}
+}
+
+// createInstallInputs creates the dependency installation timeout and retry inputs
+func createInstallInputs() map[string]Input {
+	return map[string]Input{
+		"installTimeout": {
+			Type:        models.InputTypeDuration,
+			Description: "Timeout for the install dependencies step (e.g. \"10m\")",
+			Required:    false,
+		},
+		"installRetries": {
+			Type:        models.InputTypeNumber,
+			Description: "Number of times to retry a failed dependency install",
+			Default:     0,
+			Required:    false,
+		},
+	}
+}
+
+// createTimeoutInputs creates the per-step timeout override input
+func createTimeoutInputs() map[string]Input {
+	return map[string]Input{
+		"timeouts": {
+			Type:        models.InputTypeObject,
+			ValueType:   models.InputTypeDuration,
+			Description: "Per-step timeout overrides as durations (e.g. \"15m\", \"1h\") or minutes, keyed by step ID (e.g. test, build, security-scan)",
+			Required:    false,
+		},
+	}
+}
+
+// createSecurityInputs creates the standard security configuration inputs, enabling the
+// language's default scanners
+func createSecurityInputs(language config.Language) map[string]Input {
 	return map[string]Input{
 		"security": {
 			Type:        models.InputTypeObject,
 			Description: "Security scanning configuration",
-			Default:     models.DefaultSecurityConfig(),
+			Default:     defaultSecurityConfig(language),
+			Required:    false,
+		},
+		"trivyScanEnabled": {
+			Type:        models.InputTypeBoolean,
+			Description: "Deprecated: use security.trivy.enabled",
 			Required:    false,
 		},
 	}
 }
 
+// defaultSecurityConfig returns the default security configuration with the scanners
+// configured for the language enabled
+func defaultSecurityConfig(language config.Language) models.SecurityConfig {
+	security := models.DefaultSecurityConfig()
+	security.Trivy.Enabled = config.Config.HasDefaultScanner(language, config.ScannerTrivy)
+	security.Gosec.Enabled = config.Config.HasDefaultScanner(language, config.ScannerGosec)
+	security.Bandit.Enabled = config.Config.HasDefaultScanner(language, config.ScannerBandit)
+	return security
+}
+
 // createContainerInputs creates the standard container configuration inputs
 func createContainerInputs() map[string]Input {
 	return map[string]Input{
@@ -369,6 +922,87 @@ func createContainerInputs() map[string]Input {
 			Default:     models.DefaultContainerConfig(),
 			Required:    false,
 		},
+		"containerEnabled": {
+			Type:        models.InputTypeBoolean,
+			Description: "Deprecated: use container.enabled",
+			Required:    false,
+		},
+	}
+}
+
+// ArtifactsStepID is the ID of the step uploading the artifacts input's paths
+const ArtifactsStepID = "publish-artifacts"
+
+// QEMUStepID is the ID of the step registering QEMU emulators, which only runs when
+// container.platforms lists an architecture the runner can't execute natively
+const QEMUStepID = "setup-qemu"
+
+// CrossCompileArtifactsStepID is the ID of go-service's per-platform binary upload, which
+// only runs in the crossCompile matrix
+const CrossCompileArtifactsStepID = "upload-artifacts"
+
+// WorkspaceStepIDs are the IDs of the steps that prepare a job's workspace: checkout, the
+// toolchain setup and the dependency install. Jobs split off the build job repeat them.
+var WorkspaceStepIDs = []string{"checkout", "setup-node", "setup-go", "setup-python", "setup-rust", "setup-java", "install"}
+
+// createArtifactsInputs creates the optional build artifact upload input
+func createArtifactsInputs() map[string]Input {
+	return map[string]Input{
+		"artifacts": {
+			Type:        models.InputTypeObject,
+			Description: "Build outputs to upload as a workflow artifact: paths, name and retentionDays",
+			Required:    false,
+		},
+	}
+}
+
+// createArtifactsStep creates the upload-artifact step for the artifacts input. Paths are
+// passed one per line, and an unset name or retention falls back to the action's default.
+func createArtifactsStep() Step {
+	return Step{
+		ID:     ArtifactsStepID,
+		Name:   "Upload artifacts",
+		Uses:   GitHubActionVersions.UploadArtifact,
+		Action: "uploadArtifact",
+		With: map[string]string{
+			"name":           "{{ with .Inputs.artifacts.name }}{{ . }}{{ end }}",
+			"path":           "{{ range $i, $path := .Inputs.artifacts.paths }}{{ if $i }}\n{{ end }}{{ $path }}{{ end }}",
+			"retention-days": "{{ with .Inputs.artifacts.retentionDays }}{{ . }}{{ end }}",
+		},
+		If: BuildCond.ArtifactsCondition(),
+	}
+}
+
+// SlackNotificationStepID is the ID of the step posting a Slack message when the job fails
+const SlackNotificationStepID = "notify-slack"
+
+// createNotificationsInputs creates the optional failure notification input
+func createNotificationsInputs() map[string]Input {
+	return map[string]Input{
+		"notifications": {
+			Type:        models.InputTypeObject,
+			Description: "Failure notifications: slack.webhookSecret (the secret holding an incoming webhook URL) and slack.channel",
+			Required:    false,
+		},
+	}
+}
+
+// createSlackNotificationStep creates the step posting to the notifications.slack webhook
+// when the job fails. The webhook is read from the named secret, and the message links to
+// the failed run.
+func createSlackNotificationStep() Step {
+	return Step{
+		ID:     SlackNotificationStepID,
+		Name:   "Notify Slack of failure",
+		Uses:   GitHubActionVersions.SlackGitHubAction,
+		Action: "slackGithubAction",
+		With: map[string]string{
+			"webhook":      GitHubPlaceholders.SecretPlaceholderPrefix + "{{ .Inputs.notifications.slack.webhookSecret }}",
+			"webhook-type": "incoming-webhook",
+			"payload": "{{ with .Inputs.notifications.slack.channel }}channel: {{ printf \"%q\" . }}\n{{ end }}" +
+				"text: \"" + GitHubPlaceholders.WorkflowPlaceholder + " failed: " + GitHubPlaceholders.RunURLPlaceholder + "\"",
+		},
+		If: BuildCond.NotificationCondition(),
 	}
 }
 
@@ -385,12 +1019,99 @@ func mergeInputs(inputMaps ...map[string]Input) map[string]Input {
 
 // Common step definitions
 
+// packageManagerCommand is a command run with a package manager
+type packageManagerCommand struct {
+	manager config.PackageManager
+	command string
+}
+
+// nodeInstallCommands are the Node.js install commands, which fail on an outdated lockfile
+var nodeInstallCommands = []packageManagerCommand{
+	{config.PackageManagerNpm, "npm ci"},
+	{config.PackageManagerYarn, "yarn install --frozen-lockfile"},
+	{config.PackageManagerPnpm, "pnpm install --frozen-lockfile"},
+}
+
+// pythonInstallCommands are the Python install commands
+var pythonInstallCommands = []packageManagerCommand{
+	{config.PackageManagerPip, "pip install -r {{ .Inputs.requirements }}"},
+	{config.PackageManagerPoetry, "poetry install"},
+	{config.PackageManagerPipenv, "pipenv install"},
+}
+
+// javaTestCommands are the Java test commands used when testCommand isn't set
+var javaTestCommands = []packageManagerCommand{
+	{config.PackageManagerMaven, "mvn -B test"},
+	{config.PackageManagerGradle, "./gradlew test"},
+}
+
+// javaBuildCommands are the Java build commands used when buildCommand isn't set
+var javaBuildCommands = []packageManagerCommand{
+	{config.PackageManagerMaven, "mvn -B package"},
+	{config.PackageManagerGradle, "./gradlew build"},
+}
+
+// JavaCommands returns the default test and build commands of a Java package manager
+func JavaCommands(manager config.PackageManager) (test, build string) {
+	return commandFor(javaTestCommands, manager), commandFor(javaBuildCommands, manager)
+}
+
+// commandFor returns a package manager's command, or "" when it has none
+func commandFor(commands []packageManagerCommand, manager config.PackageManager) string {
+	for _, c := range commands {
+		if c.manager == manager {
+			return c.command
+		}
+	}
+	return ""
+}
+
+// selectPackageManagerCommand renders the command of the packageManager input. When
+// packageManager is a matrix dimension it resolves to ${{ matrix.packageManager }}, so the
+// command is chosen at runtime with a case over the matrix value instead.
+func selectPackageManagerCommand(commands []packageManagerCommand) string {
+	var b strings.Builder
+	for i, c := range commands {
+		if i > 0 {
+			b.WriteString("{{ else ")
+		} else {
+			b.WriteString("{{ ")
+		}
+		fmt.Fprintf(&b, "if eq .Inputs.packageManager %q }}%s", string(c.manager), c.command)
+	}
+
+	b.WriteString("{{ else }}case \"{{ .Inputs.packageManager }}\" in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  %s) %s ;;\n", c.manager, c.command)
+	}
+	b.WriteString("  *) echo \"Unsupported package manager: {{ .Inputs.packageManager }}\" >&2; exit 1 ;;\nesac{{ end }}")
+	return b.String()
+}
+
+// withInstallRetries wraps an install command so it is retried installRetries times
+// before failing, sleeping between attempts to ride out registry outages
+func withInstallRetries(command string) string {
+	return "{{ if .Inputs.installRetries }}attempt=0\n" +
+		"until " + command + "; do\n" +
+		"  attempt=$((attempt + 1))\n" +
+		"  if [ \"$attempt\" -gt {{ .Inputs.installRetries }} ]; then\n" +
+		"    exit 1\n" +
+		"  fi\n" +
+		"  echo \"Install failed, retrying ($attempt/{{ .Inputs.installRetries }})...\"\n" +
+		"  sleep 10\n" +
+		"done{{ else }}" + command + "{{ end }}"
+}
+
 // createCheckoutStep creates a standard checkout step
 func createCheckoutStep() Step {
 	return Step{
-		ID:   "checkout",
-		Name: "Checkout code",
-		Uses: GitHubActionVersions.Checkout,
+		ID:     "checkout",
+		Name:   "Checkout code",
+		Uses:   GitHubActionVersions.Checkout,
+		Action: "checkout",
+		With: map[string]string{
+			"fetch-depth": "{{ .Inputs.fetchDepth }}",
+		},
 	}
 }
 
@@ -398,9 +1119,10 @@ func createCheckoutStep() Step {
 func createSecuritySteps() []Step {
 	return []Step{
 		{
-			ID:   "security-scan",
-			Name: "Run Trivy vulnerability scanner",
-			Uses: GitHubActionVersions.TrivyAction,
+			ID:     "security-scan",
+			Name:   "Run Trivy vulnerability scanner",
+			Uses:   GitHubActionVersions.TrivyAction,
+			Action: "trivyAction",
 			With: map[string]string{
 				"scan-type": "fs",
 				"scan-ref":  ".",
@@ -409,33 +1131,156 @@ func createSecuritySteps() []Step {
 				"severity":  "{{ .Inputs.security.trivy.severity }}",
 				"exit-code": "1",
 			},
-			If: SecurityCond.TrivyScanCondition(),
+			If:          SecurityCond.TrivyScanCondition(),
+			TimeoutMins: config.Config.Security.DefaultTimeout,
 		},
+		createSarifUploadStep("upload-sarif", "Trivy", "trivy-results.sarif", "trivy", SecurityCond.TrivyUploadCondition()),
+	}
+}
+
+// CreateTrivyScanSteps creates the scan and SARIF upload steps for one entry of
+// security.trivy.scans. key distinguishes the scan's step IDs, output and SARIF category.
+// Image scans pull the built image, so they only run when it was pushed.
+func CreateTrivyScanSteps(key string, scan models.TrivyScan) []Step {
+	// trivy-action takes image-ref for images and scan-ref for everything else
+	refInput, ref := "scan-ref", "."
+	if scan.ScanType == models.TrivyScanImage {
+		refInput, ref = "image-ref", "{{ .Inputs.container.registry }}/{{ .Inputs.container.imageName }}:{{ .Inputs.container.imageTag }}"
+	}
+	if scan.Ref != "" {
+		ref = scan.Ref
+	}
+
+	output := scan.Output
+	if output == "" {
+		output = fmt.Sprintf("trivy-%s-results.sarif", key)
+	}
+
+	scanCondition := SecurityCond.TrivyScanCondition()
+	uploadCondition := SecurityCond.TrivyUploadCondition()
+	if scan.ScanType == models.TrivyScanImage {
+		scanCondition = NewConditionBuilder().
+			WithCustomCondition(scanCondition).
+			WithCustomCondition(ContainerCond.PushCondition()).
+			And()
+		uploadCondition = NewConditionBuilder().
+			WithCustomCondition(uploadCondition).
+			WithCustomCondition(ContainerCond.PushCondition()).
+			And()
+	}
+
+	upload := createSarifUploadStep("upload-sarif-"+key, "Trivy "+key, output, "trivy-"+key, uploadCondition)
+
+	return []Step{
+		{
+			ID:     "security-scan-" + key,
+			Name:   fmt.Sprintf("Run Trivy %s vulnerability scan", key),
+			Uses:   GitHubActionVersions.TrivyAction,
+			Action: "trivyAction",
+			With: map[string]string{
+				"scan-type": scan.ScanType,
+				refInput:    ref,
+				"format":    "sarif",
+				"output":    output,
+				"severity":  "{{ .Inputs.security.trivy.severity }}",
+				"exit-code": "1",
+			},
+			If:          scanCondition,
+			TimeoutMins: config.Config.Security.DefaultTimeout,
+		},
+		upload,
+	}
+}
+
+// createGoSecuritySteps creates Go static analysis steps that report findings as SARIF
+func createGoSecuritySteps() []Step {
+	return []Step{
 		{
-			ID:   "upload-sarif",
-			Name: "Upload Trivy scan results to GitHub Security tab",
-			Uses: GitHubActionVersions.CodeQLUploadSARIF,
+			ID:     "gosec-scan",
+			Name:   "Run gosec security scanner",
+			Uses:   GitHubActionVersions.Gosec,
+			Action: "gosec",
 			With: map[string]string{
-				"sarif_file": "trivy-results.sarif",
+				"args": "-no-fail -fmt sarif -out gosec-results.sarif ./...",
 			},
-			If: SecurityCond.TrivyUploadCondition(),
+			If:          SecurityCond.GosecScanCondition(),
+			TimeoutMins: config.Config.Security.DefaultTimeout,
+		},
+		createSarifUploadStep("upload-gosec-sarif", "gosec", "gosec-results.sarif", "gosec", SecurityCond.GosecUploadCondition()),
+	}
+}
+
+// banditCommand installs bandit with SARIF support and scans the repository, applying the
+// configured config file and extra arguments. Findings are reported through SARIF, not the exit code.
+const banditCommand = `pip install "bandit[sarif,toml]"
+bandit -r . -f sarif -o bandit-results.sarif --exit-zero{{ if .Inputs.security.bandit.configFile }} -c {{ .Inputs.security.bandit.configFile }}{{ end }}{{ if .Inputs.security.bandit.args }} {{ .Inputs.security.bandit.args }}{{ end }}`
+
+// createBanditSteps creates Python static analysis steps that report findings as SARIF
+func createBanditSteps() []Step {
+	return []Step{
+		{
+			ID:          "bandit-scan",
+			Name:        "Run bandit security scanner",
+			Run:         banditCommand,
+			If:          SecurityCond.BanditScanCondition(),
+			TimeoutMins: config.Config.Security.DefaultTimeout,
+		},
+		createSarifUploadStep("upload-bandit-sarif", "bandit", "bandit-results.sarif", "bandit", SecurityCond.BanditUploadCondition()),
+	}
+}
+
+// createSarifUploadStep creates a step uploading a scanner's SARIF file to the GitHub Security tab.
+// GitHub replaces earlier results uploaded under the same category, so every scanner (and every
+// configured Trivy scan) uploads under its own.
+func createSarifUploadStep(id, scanner, file, category, condition string) Step {
+	return Step{
+		ID:     id,
+		Name:   fmt.Sprintf("Upload %s scan results to GitHub Security tab", scanner),
+		Uses:   GitHubActionVersions.CodeQLUploadSARIF,
+		Action: "codeqlUploadSarif",
+		With: map[string]string{
+			"sarif_file": file,
+			"category":   category,
 		},
+		If: condition,
 	}
 }
 
+// insecureRegistryBuildkitConfig marks the configured registry as plain HTTP with TLS
+// verification disabled in buildkitd; it renders empty unless container.insecureRegistry is set.
+// docker/login-action has no TLS option, so login relies on the runner's daemon settings.
+const insecureRegistryBuildkitConfig = `{{ if .Inputs.container.insecureRegistry }}[registry."{{ .Inputs.container.registry }}"]
+  http = true
+  insecure = true{{ end }}`
+
 // createContainerSteps creates standard container building steps
 func createContainerSteps() []Step {
 	return []Step{
 		{
-			ID:   "setup-docker-buildx",
-			Name: "Set up Docker Buildx",
-			Uses: GitHubActionVersions.DockerSetupBuildx,
-			If:   ContainerCond.BuildCondition(),
+			ID:     QEMUStepID,
+			Name:   "Set up QEMU",
+			Uses:   GitHubActionVersions.DockerSetupQEMU,
+			Action: "dockerSetupQemu",
+			With: map[string]string{
+				"platforms": "{{ .Inputs.container.platforms }}",
+			},
+			If: ContainerCond.BuildCondition(),
+		},
+		{
+			ID:     "setup-docker-buildx",
+			Name:   "Set up Docker Buildx",
+			Uses:   GitHubActionVersions.DockerSetupBuildx,
+			Action: "dockerSetupBuildx",
+			With: map[string]string{
+				"buildkitd-config-inline": insecureRegistryBuildkitConfig,
+			},
+			If: ContainerCond.BuildCondition(),
 		},
 		{
-			ID:   "login-registry",
-			Name: "Log in to Container Registry",
-			Uses: GitHubActionVersions.DockerLogin,
+			ID:     "login-registry",
+			Name:   "Log in to Container Registry",
+			Uses:   GitHubActionVersions.DockerLogin,
+			Action: "dockerLogin",
 			With: map[string]string{
 				"registry": "{{ .Inputs.container.registry }}",
 				"username": GitHubPlaceholders.ActorPlaceholder,
@@ -444,15 +1289,18 @@ func createContainerSteps() []Step {
 			If: ContainerCond.PushCondition(),
 		},
 		{
-			ID:   "build-and-push",
-			Name: "Build and push container image",
-			Uses: GitHubActionVersions.DockerBuildPush,
+			ID:     "build-and-push",
+			Name:   "Build and push container image",
+			Uses:   GitHubActionVersions.DockerBuildPush,
+			Action: "dockerBuildPush",
 			With: map[string]string{
 				"context":    "{{ .Inputs.container.buildContext }}",
 				"file":       "{{ .Inputs.container.dockerfile }}",
 				"push":       "{{ .Inputs.container.push.enabled }}",
 				"tags":       "{{ .Inputs.container.registry }}/{{ .Inputs.container.imageName }}:{{ .Inputs.container.imageTag }}",
 				"build-args": "{{ .Inputs.container.buildArgs }}",
+				"target":     "{{ .Inputs.container.target }}",
+				"platforms":  "{{ .Inputs.container.platforms }}",
 				"cache-from": "type=gha",
 				"cache-to":   "type=gha,mode=max",
 			},
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/terrpan/gpgen/pkg/config"
 	"github.com/terrpan/gpgen/pkg/models"
 )
 
@@ -16,7 +17,7 @@ const (
 	integrationContainerBuildOnPRTemplate             = "{{ .Inputs.container.build.onPR }}"
 	integrationContainerBuildOnProductionTemplate     = "{{ .Inputs.container.build.onProduction }}"
 	integrationContainerPushEnabledTemplate           = "{{ .Inputs.container.push.enabled }}"
-	integrationContainerPushAlwaysPushTemplate        = "{{ .Inputs.container.push.alwaysPush }}"
+	integrationContainerBuildAlwaysPushTemplate       = "{{ .Inputs.container.build.alwaysPush }}"
 	integrationContainerPushOnProductionTemplate      = "{{ .Inputs.container.push.onProduction }}"
 	integrationSecurityTrivyEnabledTemplate           = "{{ .Inputs.security.trivy.enabled }}"
 	integrationSecurityTrivyEnabledWithAlwaysTemplate = "{{ .Inputs.security.trivy.enabled }} && always()"
@@ -48,6 +49,9 @@ func testTemplateStructure(t *testing.T, tc templateTestCase) {
 	checkoutStep := tc.template.Steps[0]
 	assert.Equal(t, "checkout", checkoutStep.ID)
 	assert.Equal(t, GitHubActionVersions.Checkout, checkoutStep.Uses)
+
+	// Verify declared permissions cover checking out code
+	assert.Equal(t, "read", tc.template.Permissions["contents"])
 }
 
 // testLanguageVersionInput validates language version input configuration
@@ -92,6 +96,11 @@ func testCommonInputs(t *testing.T, template *Template) {
 	containerInput, exists := template.Inputs["container"]
 	assert.True(t, exists, "Template should have container input")
 	assert.Equal(t, models.InputTypeObject, containerInput.Type)
+
+	// Check artifacts input
+	artifactsInput, exists := template.Inputs["artifacts"]
+	assert.True(t, exists, "Template should have artifacts input")
+	assert.Equal(t, models.InputTypeObject, artifactsInput.Type)
 }
 
 // testCommonSteps validates that all templates have security and container steps
@@ -111,6 +120,9 @@ func testCommonSteps(t *testing.T, template *Template) {
 	assert.True(t, stepIDs["setup-docker-buildx"], "Template should have setup-docker-buildx step")
 	assert.True(t, stepIDs["login-registry"], "Template should have login-registry step")
 	assert.True(t, stepIDs["build-and-push"], "Template should have build-and-push step")
+
+	// Check for the artifact upload step
+	assert.True(t, stepIDs[ArtifactsStepID], "Template should have %s step", ArtifactsStepID)
 }
 
 func TestTemplateManager_LoadTemplate(t *testing.T) {
@@ -261,6 +273,59 @@ func TestGoServiceTemplate(t *testing.T) {
 	assert.Equal(t, models.InputTypeString, buildCommandInput.Type)
 	assert.True(t, buildCommandInput.Required)
 
+	// Test default step timeouts
+	goConfig := config.Config.Languages[config.LanguageGo]
+	expectedTimeouts := map[string]int{
+		"test":          goConfig.DefaultTestTimeout,
+		"build":         goConfig.DefaultBuildTimeout,
+		"security-scan": config.Config.Security.DefaultTimeout,
+	}
+	for _, step := range template.Steps {
+		if expected, ok := expectedTimeouts[step.ID]; ok {
+			assert.Equal(t, expected, step.TimeoutMins, "Step %s should have default timeout", step.ID)
+		}
+	}
+	assert.Equal(t, 15, goConfig.DefaultTestTimeout)
+
+	// Test cross-compilation input and artifact upload step
+	crossCompileInput, exists := template.Inputs["crossCompile"]
+	require.True(t, exists)
+	assert.Equal(t, models.InputTypeBoolean, crossCompileInput.Type)
+	assert.Equal(t, false, crossCompileInput.Default)
+
+	var uploadStep *Step
+	for i := range template.Steps {
+		if template.Steps[i].ID == CrossCompileArtifactsStepID {
+			uploadStep = &template.Steps[i]
+		}
+	}
+	require.NotNil(t, uploadStep, "go-service should have an upload-artifacts step")
+	assert.Equal(t, GitHubActionVersions.UploadArtifact, uploadStep.Uses)
+	assert.Empty(t, uploadStep.If, "the generator leaves the step out when crossCompile is off")
+
+	// Test module caching inputs and their use in setup-go
+	cacheEnabledInput, exists := template.Inputs["cacheEnabled"]
+	require.True(t, exists)
+	assert.Equal(t, models.InputTypeBoolean, cacheEnabledInput.Type)
+	assert.Equal(t, true, cacheEnabledInput.Default)
+
+	cacheDependencyPathInput, exists := template.Inputs["cacheDependencyPath"]
+	require.True(t, exists)
+	assert.Equal(t, "", cacheDependencyPathInput.Default)
+
+	for _, step := range template.Steps {
+		if step.ID == "setup-go" {
+			assert.Equal(t, "{{ .Inputs.cacheEnabled }}", step.With["cache"])
+			assert.Contains(t, step.With["cache-dependency-path"], "{{ .Inputs.cacheDependencyPath }}")
+		}
+	}
+
+	// Test language security defaults: Trivy and gosec
+	security := template.Inputs["security"].Default.(models.SecurityConfig)
+	assert.True(t, security.Trivy.Enabled)
+	assert.True(t, security.Gosec.Enabled)
+	assert.False(t, security.Bandit.Enabled)
+
 	// Test common inputs and steps
 	testCommonInputs(t, template)
 	testCommonSteps(t, template)
@@ -284,11 +349,168 @@ func TestPythonAppTemplate(t *testing.T) {
 	require.True(t, exists)
 	assert.Equal(t, models.InputTypeString, requirementsInput.Type)
 
+	// Test language security defaults: Trivy and bandit
+	security := template.Inputs["security"].Default.(models.SecurityConfig)
+	assert.True(t, security.Trivy.Enabled)
+	assert.False(t, security.Gosec.Enabled)
+	assert.True(t, security.Bandit.Enabled)
+
+	// Test common inputs and steps
+	testCommonInputs(t, template)
+	testCommonSteps(t, template)
+}
+
+func TestRustServiceTemplate(t *testing.T) {
+	template := getRustServiceTemplate()
+
+	// Test basic template structure
+	testTemplateStructure(t, templateTestCase{
+		template:     template,
+		expectedName: "rust-service",
+	})
+
+	// Test Rust-specific configuration
+	testLanguageVersionInput(t, template, "rustVersion", []string{"stable", "beta", "nightly"})
+	testLanguageSetupStep(t, template, "setup-rust", GitHubActionVersions.RustToolchain)
+
+	// Test Rust-specific inputs
+	testCommandInput, exists := template.Inputs["testCommand"]
+	require.True(t, exists)
+	assert.Equal(t, "cargo test", testCommandInput.Default)
+	assert.True(t, testCommandInput.Required)
+
+	buildCommandInput, exists := template.Inputs["buildCommand"]
+	require.True(t, exists)
+	assert.Equal(t, "cargo build --release", buildCommandInput.Default)
+	assert.True(t, buildCommandInput.Required)
+
+	// Test default step timeouts
+	rustConfig := config.Config.Languages[config.LanguageRust]
+	expectedTimeouts := map[string]int{
+		"test":          rustConfig.DefaultTestTimeout,
+		"build":         rustConfig.DefaultBuildTimeout,
+		"security-scan": config.Config.Security.DefaultTimeout,
+	}
+	for _, step := range template.Steps {
+		if expected, ok := expectedTimeouts[step.ID]; ok {
+			assert.Equal(t, expected, step.TimeoutMins, "Step %s should have default timeout", step.ID)
+		}
+	}
+
+	// Test language security defaults: Trivy only
+	security := template.Inputs["security"].Default.(models.SecurityConfig)
+	assert.True(t, security.Trivy.Enabled)
+	assert.False(t, security.Gosec.Enabled)
+	assert.False(t, security.Bandit.Enabled)
+
 	// Test common inputs and steps
 	testCommonInputs(t, template)
 	testCommonSteps(t, template)
 }
 
+func TestJavaAppTemplate(t *testing.T) {
+	template := getJavaAppTemplate()
+
+	// Test basic template structure
+	testTemplateStructure(t, templateTestCase{
+		template:     template,
+		expectedName: "java-app",
+	})
+
+	// Test Java-specific configuration
+	testLanguageVersionInput(t, template, "javaVersion", []string{"11", "17", "21"})
+	testLanguageSetupStep(t, template, "setup-java", GitHubActionVersions.SetupJava)
+
+	// Test Java-specific inputs
+	distributionInput, exists := template.Inputs["javaDistribution"]
+	require.True(t, exists)
+	assert.Equal(t, "temurin", distributionInput.Default)
+	assert.Contains(t, distributionInput.Options, "zulu")
+
+	packageManagerInput, exists := template.Inputs["packageManager"]
+	require.True(t, exists)
+	assert.Equal(t, "maven", packageManagerInput.Default)
+	assert.Equal(t, []string{"maven", "gradle"}, packageManagerInput.Options)
+
+	// Unset commands fall back to the package manager's
+	testCommandInput, exists := template.Inputs["testCommand"]
+	require.True(t, exists)
+	assert.Equal(t, "", testCommandInput.Default)
+	assert.False(t, testCommandInput.Required)
+
+	buildCommandInput, exists := template.Inputs["buildCommand"]
+	require.True(t, exists)
+	assert.Equal(t, "", buildCommandInput.Default)
+	assert.False(t, buildCommandInput.Required)
+
+	test, build := JavaCommands(config.PackageManagerGradle)
+	assert.Equal(t, "./gradlew test", test)
+	assert.Equal(t, "./gradlew build", build)
+
+	staging, exists := DefaultEnvironment(template, "staging", "gradle")
+	require.True(t, exists)
+	assert.Equal(t, "./gradlew check", staging.Inputs["testCommand"])
+	production, exists := DefaultEnvironment(template, "production", "")
+	require.True(t, exists)
+	assert.Equal(t, "mvn -B verify -Pci", production.Inputs["testCommand"], "the default package manager applies")
+
+	// Test setup-java receives the distribution, version and build tool cache
+	for _, step := range template.Steps {
+		if step.ID == "setup-java" {
+			assert.Equal(t, "{{ .Inputs.javaDistribution }}", step.With["distribution"])
+			assert.Equal(t, "{{ .Inputs.javaVersion }}", step.With["java-version"])
+			assert.Equal(t, "{{ .Inputs.packageManager }}", step.With["cache"])
+		}
+	}
+
+	// Test default step timeouts
+	javaConfig := config.Config.Languages[config.LanguageJava]
+	expectedTimeouts := map[string]int{
+		"test":          javaConfig.DefaultTestTimeout,
+		"build":         javaConfig.DefaultBuildTimeout,
+		"security-scan": config.Config.Security.DefaultTimeout,
+	}
+	for _, step := range template.Steps {
+		if expected, ok := expectedTimeouts[step.ID]; ok {
+			assert.Equal(t, expected, step.TimeoutMins, "Step %s should have default timeout", step.ID)
+		}
+	}
+
+	// Test language security defaults: Trivy only
+	security := template.Inputs["security"].Default.(models.SecurityConfig)
+	assert.True(t, security.Trivy.Enabled)
+	assert.False(t, security.Gosec.Enabled)
+	assert.False(t, security.Bandit.Enabled)
+
+	// Test common inputs and steps
+	testCommonInputs(t, template)
+	testCommonSteps(t, template)
+}
+
+func TestBuiltinTemplateDefaultEnvironments(t *testing.T) {
+	tm := NewTemplateManager("")
+
+	for _, name := range tm.ListTemplates() {
+		t.Run(name, func(t *testing.T) {
+			template, err := tm.LoadTemplate(name)
+			require.NoError(t, err)
+
+			assert.Contains(t, template.DefaultEnvironments, "staging")
+			assert.Contains(t, template.DefaultEnvironments, "production")
+			managers := append([]string{""}, template.Inputs["packageManager"].Options...)
+			for env := range template.DefaultEnvironments {
+				for _, manager := range managers {
+					envConfig, _ := DefaultEnvironment(template, env, manager)
+					for input, value := range envConfig.Inputs {
+						require.True(t, HasInput(template, input), "environment %s sets unknown input %s", env, input)
+						assert.NoError(t, tm.ValidateInputValue(input, value, template.Inputs[input]), "environment %s", env)
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestTemplateManager_ListTemplates(t *testing.T) {
 	tm := NewTemplateManager("")
 	templates := tm.ListTemplates()
@@ -296,7 +518,9 @@ func TestTemplateManager_ListTemplates(t *testing.T) {
 	assert.Contains(t, templates, "node-app")
 	assert.Contains(t, templates, "go-service")
 	assert.Contains(t, templates, "python-app")
-	assert.Len(t, templates, 3)
+	assert.Contains(t, templates, "rust-service")
+	assert.Contains(t, templates, "java-app")
+	assert.Len(t, templates, 5)
 }
 
 func TestValidateInputValue(t *testing.T) {
@@ -420,24 +644,101 @@ func TestConditionIntegration(t *testing.T) {
 		assert.Equal(t, SecurityCond.TrivyUploadCondition(), uploadStep.If)
 	})
 
+	t.Run("go security steps use condition builders", func(t *testing.T) {
+		steps := createGoSecuritySteps()
+		require.Len(t, steps, 2)
+
+		gosecStep := steps[0]
+		assert.Equal(t, "gosec-scan", gosecStep.ID)
+		assert.Equal(t, GitHubActionVersions.Gosec, gosecStep.Uses)
+		assert.Equal(t, SecurityCond.GosecScanCondition(), gosecStep.If)
+		assert.Contains(t, gosecStep.With["args"], "-out gosec-results.sarif")
+	})
+
+	t.Run("scanners upload their own SARIF results", func(t *testing.T) {
+		tests := []struct {
+			steps     []Step
+			id        string
+			file      string
+			category  string
+			condition string
+		}{
+			{createSecuritySteps(), "upload-sarif", "trivy-results.sarif", "trivy", SecurityCond.TrivyUploadCondition()},
+			{createGoSecuritySteps(), "upload-gosec-sarif", "gosec-results.sarif", "gosec", SecurityCond.GosecUploadCondition()},
+			{createBanditSteps(), "upload-bandit-sarif", "bandit-results.sarif", "bandit", SecurityCond.BanditUploadCondition()},
+		}
+
+		for _, tt := range tests {
+			uploadStep := tt.steps[len(tt.steps)-1]
+			assert.Equal(t, tt.id, uploadStep.ID)
+			assert.Equal(t, GitHubActionVersions.CodeQLUploadSARIF, uploadStep.Uses)
+			assert.Equal(t, tt.file, uploadStep.With["sarif_file"])
+			assert.Equal(t, tt.category, uploadStep.With["category"])
+			assert.Equal(t, tt.condition, uploadStep.If)
+		}
+	})
+
+	t.Run("SARIF uploads use a distinct category per scanner", func(t *testing.T) {
+		tm := NewTemplateManager("")
+		for _, name := range tm.ListTemplates() {
+			template, err := tm.LoadTemplate(name)
+			require.NoError(t, err)
+
+			categories := make(map[string]string)
+			for _, step := range template.Steps {
+				if step.Uses != GitHubActionVersions.CodeQLUploadSARIF {
+					continue
+				}
+				category := step.With["category"]
+				require.NotEmpty(t, category, "%s: step %s has no category", name, step.ID)
+				if other, exists := categories[category]; exists {
+					t.Errorf("%s: steps %s and %s share SARIF category %q", name, other, step.ID, category)
+				}
+				categories[category] = step.ID
+			}
+		}
+	})
+
+	t.Run("only go-service includes gosec steps", func(t *testing.T) {
+		hasStep := func(template *Template, id string) bool {
+			for _, step := range template.Steps {
+				if step.ID == id {
+					return true
+				}
+			}
+			return false
+		}
+
+		assert.True(t, hasStep(getGoServiceTemplate(), "gosec-scan"))
+		assert.True(t, hasStep(getGoServiceTemplate(), "upload-gosec-sarif"))
+		assert.False(t, hasStep(getNodeAppTemplate(), "gosec-scan"))
+		assert.False(t, hasStep(getPythonAppTemplate(), "gosec-scan"))
+	})
+
 	t.Run("container steps use condition builders", func(t *testing.T) {
 		steps := createContainerSteps()
-		require.Len(t, steps, 3)
+		require.Len(t, steps, 4)
+
+		// Verify setup QEMU step uses ContainerCond.BuildCondition()
+		qemuStep := steps[0]
+		assert.Equal(t, QEMUStepID, qemuStep.ID)
+		assert.Equal(t, GitHubActionVersions.DockerSetupQEMU, qemuStep.Uses)
+		assert.Equal(t, ContainerCond.BuildCondition(), qemuStep.If)
 
 		// Verify setup buildx step uses ContainerCond.BuildCondition()
-		buildxStep := steps[0]
+		buildxStep := steps[1]
 		assert.Equal(t, "setup-docker-buildx", buildxStep.ID)
 		assert.Equal(t, GitHubActionVersions.DockerSetupBuildx, buildxStep.Uses)
 		assert.Equal(t, ContainerCond.BuildCondition(), buildxStep.If)
 
 		// Verify login step uses ContainerCond.PushCondition()
-		loginStep := steps[1]
+		loginStep := steps[2]
 		assert.Equal(t, "login-registry", loginStep.ID)
 		assert.Equal(t, GitHubActionVersions.DockerLogin, loginStep.Uses)
 		assert.Equal(t, ContainerCond.PushCondition(), loginStep.If)
 
 		// Verify build-push step uses ContainerCond.BuildCondition()
-		buildPushStep := steps[2]
+		buildPushStep := steps[3]
 		assert.Equal(t, "build-and-push", buildPushStep.ID)
 		assert.Equal(t, GitHubActionVersions.DockerBuildPush, buildPushStep.Uses)
 		assert.Equal(t, ContainerCond.BuildCondition(), buildPushStep.If)
@@ -445,7 +746,7 @@ func TestConditionIntegration(t *testing.T) {
 
 	t.Run("container steps use placeholder constants", func(t *testing.T) {
 		steps := createContainerSteps()
-		loginStep := steps[1] // login-registry step
+		loginStep := steps[2] // login-registry step
 
 		assert.Equal(t, GitHubPlaceholders.ActorPlaceholder, loginStep.With["username"])
 		assert.Equal(t, GitHubPlaceholders.TokenPlaceholder, loginStep.With["password"])
@@ -497,11 +798,15 @@ func getValidVersionConstants() map[string]bool {
 		GitHubActionVersions.SetupNode:         true,
 		GitHubActionVersions.SetupGo:           true,
 		GitHubActionVersions.SetupPython:       true,
+		GitHubActionVersions.DockerSetupQEMU:   true,
 		GitHubActionVersions.DockerSetupBuildx: true,
 		GitHubActionVersions.DockerLogin:       true,
 		GitHubActionVersions.DockerBuildPush:   true,
 		GitHubActionVersions.CodeQLUploadSARIF: true,
 		GitHubActionVersions.TrivyAction:       true,
+		GitHubActionVersions.Gosec:             true,
+		GitHubActionVersions.UploadArtifact:    true,
+		GitHubActionVersions.SlackGitHubAction: true,
 	}
 	return constants
 }
@@ -547,7 +852,7 @@ func TestConditionComplexity(t *testing.T) {
 		assert.Contains(t, condition, integrationContainerPushEnabledTemplate)
 
 		// Should contain push triggers
-		assert.Contains(t, condition, integrationContainerPushAlwaysPushTemplate)
+		assert.Contains(t, condition, integrationContainerBuildAlwaysPushTemplate)
 		assert.Contains(t, condition, integrationContainerPushOnProductionTemplate)
 	})
 
@@ -562,3 +867,67 @@ func TestConditionComplexity(t *testing.T) {
 		assert.Equal(t, integrationSecurityTrivyEnabledWithAlwaysTemplate, uploadCondition)
 	})
 }
+
+func TestParseDurationMinutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected int
+		errorMsg string
+	}{
+		{"hours and minutes", "1h30m", 90, ""},
+		{"minutes", "15m", 15, ""},
+		{"plain minutes string", "45", 45, ""},
+		{"integer minutes", 20, 20, ""},
+		{"float minutes", float64(10), 10, ""},
+		{"invalid duration", "soon", 0, "must be a duration"},
+		{"seconds", "90s", 0, "whole number of minutes"},
+		{"too long", "7h", 0, "must be between 1m and 360m"},
+		{"zero", "0m", 0, "must be between 1m and 360m"},
+		{"wrong type", true, 0, "must be a duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minutes, err := ParseDurationMinutes(tt.value)
+			if tt.errorMsg != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, minutes)
+		})
+	}
+}
+
+func TestCoerceInputValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		def      Input
+		expected interface{}
+	}{
+		{"string true to boolean", "true", Input{Type: models.InputTypeBoolean}, true},
+		{"string FALSE to boolean", "FALSE", Input{Type: models.InputTypeBoolean}, false},
+		{"non-boolean string is kept", "yes", Input{Type: models.InputTypeBoolean}, "yes"},
+		{"boolean is kept", true, Input{Type: models.InputTypeBoolean}, true},
+		{"integer string to number", "30", Input{Type: models.InputTypeNumber}, 30},
+		{"float string to number", "1.5", Input{Type: models.InputTypeNumber}, 1.5},
+		{"float to string", 1.22, Input{Type: models.InputTypeString}, "1.22"},
+		{"integer to string", 18, Input{Type: models.InputTypeString}, "18"},
+		{"boolean to string", false, Input{Type: models.InputTypeString}, "false"},
+		{"object without shape is kept", map[string]interface{}{"enabled": "true"}, Input{Type: models.InputTypeObject}, map[string]interface{}{"enabled": "true"}},
+		{"object values coerced by default", map[string]interface{}{"enabled": "true", "push": map[string]interface{}{"onProduction": "false"}, "imageTag": 2.1}, Input{Type: models.InputTypeObject, Default: models.DefaultContainerConfig()}, map[string]interface{}{"enabled": true, "push": map[string]interface{}{"onProduction": false}, "imageTag": "2.1"}},
+		{"unknown object keys are kept", map[string]interface{}{"extra": "true"}, Input{Type: models.InputTypeObject, Default: map[string]interface{}{"enabled": false}}, map[string]interface{}{"extra": "true"}},
+		{"duration string to minutes", "1h30m", Input{Type: models.InputTypeDuration}, 90},
+		{"invalid duration is kept", "soon", Input{Type: models.InputTypeDuration}, "soon"},
+		{"object values coerced by value type", map[string]interface{}{"test": "15m"}, Input{Type: models.InputTypeObject, ValueType: models.InputTypeDuration}, map[string]interface{}{"test": 15}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CoerceInputValue(tt.value, tt.def))
+		})
+	}
+}